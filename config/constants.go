@@ -20,4 +20,8 @@ var (
 	PublicHLSStoragePath = filepath.Join(WebRoot, "hls")
 	// BackupDirectory is the directory we write backup files to.
 	BackupDirectory = filepath.Join(DataDirectory, "backup")
+	// RecordingsStoragePath is the directory archived broadcasts are written to.
+	RecordingsStoragePath = filepath.Join(DataDirectory, "recordings")
+	// PremiereStoragePath is the directory uploaded premiere videos are saved to.
+	PremiereStoragePath = filepath.Join(DataDirectory, "premieres")
 )
@@ -16,6 +16,8 @@ type Defaults struct {
 	WebServerPort    int
 	WebServerIP      string
 	RTMPServerPort   int
+	SRTServerPort    int
+	RTMPSServerPort  int
 	StreamKey        string
 
 	YPEnabled bool
@@ -46,10 +48,12 @@ func GetDefaults() Defaults {
 		YPEnabled: false,
 		YPServer:  "https://directory.owncast.online",
 
-		WebServerPort:  8080,
-		WebServerIP:    "0.0.0.0",
-		RTMPServerPort: 1935,
-		StreamKey:      "abc123",
+		WebServerPort:   8080,
+		WebServerIP:     "0.0.0.0",
+		RTMPServerPort:  1935,
+		SRTServerPort:   9000,
+		RTMPSServerPort: 1936,
+		StreamKey:       "abc123",
 
 		StreamVariants: []models.StreamOutputVariant{
 			{
@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+)
+
+type deleteAdminUserRequest struct {
+	Username string `json:"username"`
+}
+
+type createAdminUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// CreateAdminUser will add a new admin account.
+func CreateAdminUser(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request createAdminUserRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if request.Username == "" || request.Password == "" {
+		controllers.BadRequestHandler(w, errors.New("must provide a username and password"))
+		return
+	}
+
+	if !models.IsValidAdminRole(request.Role) {
+		controllers.BadRequestHandler(w, errors.New("invalid role provided"))
+		return
+	}
+
+	if err := data.InsertAdminUser(request.Username, request.Password, request.Role); err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	controllers.WriteResponse(w, models.AdminUser{
+		Username:  request.Username,
+		Role:      request.Role,
+		Timestamp: time.Now(),
+	})
+}
+
+// GetAdminUsers will return all the admin accounts.
+func GetAdminUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	users, err := data.GetAdminUsers()
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteResponse(w, users)
+}
+
+// GetCurrentAdminUser will return the admin account making the request.
+func GetCurrentAdminUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	username, _, _ := r.BasicAuth()
+
+	controllers.WriteResponse(w, models.AdminUser{
+		Username: username,
+		Role:     getAdminRole(r),
+	})
+}
+
+// getAdminRole will return the role of the admin account making the request.
+func getAdminRole(r *http.Request) string {
+	username, password, _ := r.BasicAuth()
+	role, _ := data.GetAdminRoleForCredentials(username, password)
+	return role
+}
+
+// DeleteAdminUser will delete a single admin account.
+func DeleteAdminUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != controllers.POST {
+		controllers.WriteSimpleResponse(w, false, r.Method+" not supported")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request deleteAdminUserRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if request.Username == "" {
+		controllers.BadRequestHandler(w, errors.New("must provide a username"))
+		return
+	}
+
+	if username, _, _ := r.BasicAuth(); username == request.Username {
+		controllers.BadRequestHandler(w, errors.New("can not delete your own account"))
+		return
+	}
+
+	if err := data.DeleteAdminUser(request.Username); err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "deleted admin user")
+}
@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/models"
+)
+
+// SendCaptions will add live captions to the stream.
+func SendCaptions(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type captionsRequest struct {
+		Cues []models.CaptionCue `json:"cues"`
+	}
+
+	var request captionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if err := transcoder.AddCaptionCues(request.Cues); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "captions sent")
+}
@@ -1,12 +1,14 @@
 package admin
 
 import (
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -14,9 +16,13 @@ import (
 	"github.com/owncast/owncast/controllers"
 	"github.com/owncast/owncast/core"
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/restream"
+	"github.com/owncast/owncast/core/rtmp"
+	"github.com/owncast/owncast/core/transcoder"
 	"github.com/owncast/owncast/models"
 	"github.com/owncast/owncast/utils"
 	log "github.com/sirupsen/logrus"
+	"github.com/teris-io/shortid"
 )
 
 // ConfigValue is a container object that holds a value, is encoded, and saved to the database.
@@ -181,6 +187,36 @@ func SetStreamKey(w http.ResponseWriter, r *http.Request) {
 	controllers.WriteSimpleResponse(w, true, "changed")
 }
 
+// SetBackupStreamKey will handle the web config request to set the key a backup encoder connects with.
+func SetBackupStreamKey(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		return
+	}
+
+	key, ok := configValue.Value.(string)
+	if !ok {
+		controllers.WriteSimpleResponse(w, false, "backup stream key must be a string")
+		return
+	}
+
+	if key != "" && key == data.GetStreamKey() {
+		controllers.WriteSimpleResponse(w, false, "backup stream key must be different from the stream key")
+		return
+	}
+
+	if err := data.SetBackupStreamKey(key); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "changed")
+}
+
 // SetLogo will handle a new logo image file being uploaded.
 func SetLogo(w http.ResponseWriter, r *http.Request) {
 	if !requirePOST(w, r) {
@@ -357,6 +393,86 @@ func SetRTMPServerPort(w http.ResponseWriter, r *http.Request) {
 	controllers.WriteSimpleResponse(w, true, "rtmp port set")
 }
 
+// SetSRTServerPort will handle the web config request to set the inbound SRT port.
+func SetSRTServerPort(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		return
+	}
+
+	if err := data.SetSRTPortNumber(configValue.Value.(float64)); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "srt port set")
+}
+
+// SetSRTEnabled will handle the web config request to enable the SRT server.
+func SetSRTEnabled(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		return
+	}
+
+	if err := data.SetSRTEnabled(configValue.Value.(bool)); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "srt server state changed")
+}
+
+// SetRTMPSConfiguration will handle the web config request to set up the RTMPS listener.
+func SetRTMPSConfiguration(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type rtmpsConfigurationRequest struct {
+		Value models.RTMPSConfig `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var newRTMPSConfig rtmpsConfigurationRequest
+	if err := decoder.Decode(&newRTMPSConfig); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update rtmps config with provided values")
+		return
+	}
+
+	if newRTMPSConfig.Value.Enabled {
+		if newRTMPSConfig.Value.CertificatePath == "" || newRTMPSConfig.Value.KeyPath == "" {
+			controllers.WriteSimpleResponse(w, false, "rtmps support requires a certificate and key")
+			return
+		}
+
+		if _, err := tls.LoadX509KeyPair(newRTMPSConfig.Value.CertificatePath, newRTMPSConfig.Value.KeyPath); err != nil {
+			controllers.WriteSimpleResponse(w, false, "unable to load the rtmps certificate: "+err.Error())
+			return
+		}
+	}
+
+	if err := data.SetRTMPSConfig(newRTMPSConfig.Value); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	if err := rtmp.StartTLS(); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "rtmps configuration changed")
+}
+
 // SetServerURL will handle the web config request to set the full server URL.
 func SetServerURL(w http.ResponseWriter, r *http.Request) {
 	if !requirePOST(w, r) {
@@ -405,6 +521,17 @@ func SetStreamLatencyLevel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	level, ok := models.GetLatencyConfigs()[int(configValue.Value.(float64))]
+	if !ok {
+		controllers.WriteSimpleResponse(w, false, "invalid stream latency level")
+		return
+	}
+
+	if level.IsLowLatencyHLS() && data.GetS3Config().Enabled {
+		controllers.WriteSimpleResponse(w, false, "low-latency HLS is not available when using external storage")
+		return
+	}
+
 	if err := data.SetStreamLatencyLevel(configValue.Value.(float64)); err != nil {
 		controllers.WriteSimpleResponse(w, false, "error setting stream latency "+err.Error())
 		return
@@ -452,6 +579,11 @@ func SetS3Configuration(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if newS3Config.Value.Enabled && data.GetStreamLatencyLevel().IsLowLatencyHLS() {
+		controllers.WriteSimpleResponse(w, false, "external storage is not available with low-latency HLS; select another latency level first")
+		return
+	}
+
 	data.SetS3Config(newS3Config.Value)
 	controllers.WriteSimpleResponse(w, true, "storage configuration changed")
 }
@@ -473,6 +605,18 @@ func SetStreamOutputVariants(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, variant := range videoVariants.Value {
+		if variant.VideoCodec != "" && !transcoder.IsValidCodec(variant.VideoCodec) {
+			controllers.WriteSimpleResponse(w, false, variant.VideoCodec+" is not a supported video codec")
+			return
+		}
+
+		if variant.AudioCodec != "" && !models.IsValidAudioCodec(variant.AudioCodec) {
+			controllers.WriteSimpleResponse(w, false, variant.AudioCodec+" is not a supported audio codec")
+			return
+		}
+	}
+
 	if err := data.SetStreamOutputVariants(videoVariants.Value); err != nil {
 		controllers.WriteSimpleResponse(w, false, "unable to update video config with provided values "+err.Error())
 		return
@@ -523,6 +667,52 @@ func SetChatDisabled(w http.ResponseWriter, r *http.Request) {
 	controllers.WriteSimpleResponse(w, true, "chat disabled status updated")
 }
 
+// SetAdminPassword will change the password of the authenticated admin account.
+func SetAdminPassword(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		return
+	}
+
+	password, ok := configValue.Value.(string)
+	if !ok || password == "" {
+		controllers.WriteSimpleResponse(w, false, "must provide a password")
+		return
+	}
+
+	username, _, _ := r.BasicAuth()
+	if err := data.SetAdminUser(username, password); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "admin password changed")
+}
+
+// SetRecordingEnabled will enable or disable the recording of broadcasts.
+func SetRecordingEnabled(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		controllers.WriteSimpleResponse(w, false, "unable to update recording enabled")
+		return
+	}
+
+	if err := data.SetRecordingEnabled(configValue.Value.(bool)); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "recording enabled status updated")
+}
+
 // SetVideoCodec will change the codec used for video encoding.
 func SetVideoCodec(w http.ResponseWriter, r *http.Request) {
 	if !requirePOST(w, r) {
@@ -543,6 +733,95 @@ func SetVideoCodec(w http.ResponseWriter, r *http.Request) {
 	controllers.WriteSimpleResponse(w, true, "video codec updated")
 }
 
+// SetSegmentFormat will change the container format of HLS segments.
+func SetSegmentFormat(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		controllers.WriteSimpleResponse(w, false, "unable to change segment format")
+		return
+	}
+
+	format, ok := configValue.Value.(string)
+	if !ok || !models.IsValidSegmentFormat(format) {
+		controllers.WriteSimpleResponse(w, false, "segment format must be mpegts or fmp4")
+		return
+	}
+
+	if err := data.SetSegmentFormat(format); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update segment format")
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "segment format updated")
+}
+
+// SetDASHEnabled will enable or disable writing a MPEG-DASH manifest alongside HLS.
+func SetDASHEnabled(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		controllers.WriteSimpleResponse(w, false, "unable to update dash enabled")
+		return
+	}
+
+	if err := data.SetDASHEnabled(configValue.Value.(bool)); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "dash enabled status updated")
+}
+
+// SetRadioModeEnabled will enable or disable audio-only streaming.
+func SetRadioModeEnabled(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		controllers.WriteSimpleResponse(w, false, "unable to update radio mode enabled")
+		return
+	}
+
+	if err := data.SetRadioModeEnabled(configValue.Value.(bool)); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "radio mode enabled status updated")
+}
+
+// SetAutoLadderEnabled will set if the stream output variants are picked from the inbound stream.
+func SetAutoLadderEnabled(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	configValue, success := getValueFromRequest(w, r)
+	if !success {
+		controllers.WriteSimpleResponse(w, false, "unable to update auto ladder enabled")
+		return
+	}
+
+	if err := data.SetAutoLadderEnabled(configValue.Value.(bool)); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	// Start again from every rung the CPU has room for.
+	transcoder.ResetAutoLadder()
+
+	controllers.WriteSimpleResponse(w, true, "auto ladder enabled status updated")
+}
+
 // SetExternalActions will set the 3rd party actions for the web interface.
 func SetExternalActions(w http.ResponseWriter, r *http.Request) {
 	type externalActionsRequest struct {
@@ -563,6 +842,183 @@ func SetExternalActions(w http.ResponseWriter, r *http.Request) {
 	controllers.WriteSimpleResponse(w, true, "external actions update")
 }
 
+// SetRestreamDestinations will set the external destinations the stream is forwarded to.
+func SetRestreamDestinations(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type restreamDestinationsRequest struct {
+		Value []models.RestreamDestination `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var destinations restreamDestinationsRequest
+	if err := decoder.Decode(&destinations); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update restream destinations with provided values")
+		return
+	}
+
+	for i, destination := range destinations.Value {
+		u, err := url.Parse(destination.URL)
+		if err != nil || (u.Scheme != "rtmp" && u.Scheme != "rtmps") || u.Host == "" {
+			controllers.WriteSimpleResponse(w, false, destination.Name+" must have a valid rtmp:// or rtmps:// url")
+			return
+		}
+
+		if destination.ID == "" {
+			destinations.Value[i].ID = shortid.MustGenerate()
+		}
+	}
+
+	if err := data.SetRestreamDestinations(destinations.Value); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	// Apply the changes to a stream that is currently live.
+	restream.Reload()
+
+	controllers.WriteSimpleResponse(w, true, "restream destinations updated")
+}
+
+// SetAudioTracks will set the alternate audio tracks published alongside the stream.
+func SetAudioTracks(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type audioTracksRequest struct {
+		Value []models.AudioTrack `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var tracks audioTracksRequest
+	if err := decoder.Decode(&tracks); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update audio tracks with provided values")
+		return
+	}
+
+	streamKeys := map[string]bool{data.GetStreamKey(): true, data.GetBackupStreamKey(): true}
+	for _, track := range tracks.Value {
+		if strings.TrimSpace(track.Name) == "" {
+			controllers.WriteSimpleResponse(w, false, "audio tracks must have a name")
+			return
+		}
+
+		if track.StreamKey == "" || streamKeys[track.StreamKey] || data.IsValidAdditionalStreamKey(track.StreamKey) {
+			controllers.WriteSimpleResponse(w, false, track.Name+" must have its own stream key")
+			return
+		}
+		streamKeys[track.StreamKey] = true
+	}
+
+	if err := data.SetAudioTracks(tracks.Value); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "audio tracks updated")
+}
+
+// SetCaptions will set if live captions are published alongside the stream.
+func SetCaptions(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type captionsRequest struct {
+		Value models.Captions `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var captions captionsRequest
+	if err := decoder.Decode(&captions); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update captions with provided values")
+		return
+	}
+
+	if err := data.SetCaptions(captions.Value); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "captions updated")
+}
+
+// SetRemoteTranscoder will set the transcoder worker the stream is transcoded by.
+func SetRemoteTranscoder(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type remoteTranscoderRequest struct {
+		Value models.RemoteTranscoder `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var remoteTranscoder remoteTranscoderRequest
+	if err := decoder.Decode(&remoteTranscoder); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update remote transcoder with provided values")
+		return
+	}
+
+	if remoteTranscoder.Value.Enabled {
+		if _, _, err := net.SplitHostPort(remoteTranscoder.Value.Address); err != nil {
+			controllers.WriteSimpleResponse(w, false, "remote transcoder must have a host:port address")
+			return
+		}
+
+		if remoteTranscoder.Value.Secret == "" {
+			controllers.WriteSimpleResponse(w, false, "remote transcoder must have the secret the worker was started with")
+			return
+		}
+	}
+
+	if err := data.SetRemoteTranscoder(remoteTranscoder.Value); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "remote transcoder updated")
+}
+
+// SetPullSource will set the remote source the server ingests from instead of waiting for a broadcaster.
+func SetPullSource(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	type pullSourceRequest struct {
+		Value models.PullSource `json:"value"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var source pullSourceRequest
+	if err := decoder.Decode(&source); err != nil {
+		controllers.WriteSimpleResponse(w, false, "unable to update pull source with provided values")
+		return
+	}
+
+	if source.Value.Enabled {
+		u, err := url.Parse(source.Value.URL)
+		if err != nil || u.Host == "" || !models.IsValidPullSourceScheme(u.Scheme) {
+			controllers.WriteSimpleResponse(w, false, "pull source must have a valid rtsp://, rtsps://, srt://, http:// or https:// url")
+			return
+		}
+	}
+
+	if err := data.SetPullSource(source.Value); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	// Restart ingest so the new source takes effect.
+	core.StartPullSource()
+
+	controllers.WriteSimpleResponse(w, true, "pull source updated")
+}
+
 // SetCustomStyles will set the CSS string we insert into the page.
 func SetCustomStyles(w http.ResponseWriter, r *http.Request) {
 	customStyles, success := getValueFromRequest(w, r)
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+)
+
+func setTestStreamLatencyLevel(value string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/config/video/streamlatencylevel", strings.NewReader(`{"value": `+value+`}`))
+	w := httptest.NewRecorder()
+	SetStreamLatencyLevel(w, r)
+
+	return w
+}
+
+func TestSetStreamLatencyLevel(t *testing.T) {
+	defer data.SetStreamLatencyLevel(2) //nolint
+	defer data.SetS3Config(models.S3{}) //nolint
+
+	if w := setTestStreamLatencyLevel("9"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown latency level to be rejected, got %d", w.Code)
+	}
+
+	if err := data.SetS3Config(models.S3{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := setTestStreamLatencyLevel("5"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected low-latency HLS to be rejected with external storage, got %d", w.Code)
+	}
+	if w := setTestStreamLatencyLevel("0"); w.Code != http.StatusOK {
+		t.Errorf("expected the lowest latency level to be accepted with external storage, got %d %s", w.Code, w.Body)
+	}
+
+	if err := data.SetS3Config(models.S3{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := setTestStreamLatencyLevel("5"); w.Code != http.StatusOK {
+		t.Errorf("expected low-latency HLS to be accepted without external storage, got %d %s", w.Code, w.Body)
+	}
+
+	// External storage can not be enabled while low-latency HLS is selected.
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/config/s3", strings.NewReader(`{"value": {"enabled": true, "endpoint": "https://s3.example.com", "accessKey": "key", "secret": "secret", "region": "region", "bucket": "bucket"}}`))
+	w := httptest.NewRecorder()
+	SetS3Configuration(w, r)
+	if w.Code != http.StatusBadRequest || data.GetS3Config().Enabled {
+		t.Errorf("expected external storage to be rejected with low-latency HLS, got %d", w.Code)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/owncast/owncast/core"
 
 	"github.com/owncast/owncast/core/rtmp"
+	"github.com/owncast/owncast/core/srt"
 )
 
 // DisconnectInboundConnection will force-disconnect an inbound stream.
@@ -17,5 +18,8 @@ func DisconnectInboundConnection(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rtmp.Disconnect()
+	srt.Disconnect()
+	core.DisconnectPullSource()
+	core.DisconnectPremiere()
 	controllers.WriteSimpleResponse(w, true, "inbound stream disconnected")
 }
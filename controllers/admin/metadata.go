@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/models"
+)
+
+// SendTimedMetadata will add ID3 metadata to the live stream.
+func SendTimedMetadata(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	var metadata models.TimedMetadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if err := transcoder.AddTimedMetadata(metadata); err != nil {
+		controllers.WriteSimpleResponse(w, false, err.Error())
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "metadata added")
+}
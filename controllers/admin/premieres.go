@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/webhooks"
+)
+
+// The largest premiere video that can be uploaded, in bytes.
+var maxPremiereUploadSize int64 = 10 << 30
+
+type createPremiereRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"startTime"`
+	File        string    `json:"file"`
+}
+
+type premiereFile struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"` // In bytes
+}
+
+// GetPremiereFiles will return the videos uploaded to be aired as premieres.
+func GetPremiereFiles(w http.ResponseWriter, r *http.Request) {
+	files := make([]premiereFile, 0)
+
+	entries, err := ioutil.ReadDir(config.PremiereStoragePath)
+	if err != nil && !os.IsNotExist(err) {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		files = append(files, premiereFile{
+			Name: entry.Name(),
+			Path: filepath.Join(config.PremiereStoragePath, entry.Name()),
+			Size: entry.Size(),
+		})
+	}
+
+	controllers.WriteResponse(w, files)
+}
+
+// UploadPremiereFile will save a video sent as the request body, named by
+// the name query parameter, to be aired as a premiere. An existing video is
+// never replaced.
+func UploadPremiereFile(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	name := filepath.Base(r.URL.Query().Get("name"))
+	if name == "" || strings.HasPrefix(name, ".") || name == string(filepath.Separator) {
+		controllers.BadRequestHandler(w, errors.New("must provide a valid file name"))
+		return
+	}
+
+	filePath := filepath.Join(config.PremiereStoragePath, name)
+	if _, err := os.Stat(filePath); err == nil {
+		controllers.BadRequestHandler(w, errors.New("a video named "+name+" already exists"))
+		return
+	}
+
+	if r.ContentLength > maxPremiereUploadSize {
+		controllers.BadRequestHandler(w, errors.New("the video is larger than the upload limit"))
+		return
+	}
+
+	if err := os.MkdirAll(config.PremiereStoragePath, 0700); err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	// The upload is only given its name once it is complete.
+	upload, err := ioutil.TempFile(config.PremiereStoragePath, ".upload-")
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+	defer os.Remove(upload.Name())
+
+	size, err := io.Copy(upload, http.MaxBytesReader(w, r.Body, maxPremiereUploadSize))
+	upload.Close()
+	if err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if err := core.VerifyPremiereFile(upload.Name()); err != nil {
+		controllers.BadRequestHandler(w, errors.New("the file is not a video that can be aired: "+err.Error()))
+		return
+	}
+
+	// Linking fails if another upload took the name in the meantime, where
+	// renaming would replace it.
+	if err := os.Link(upload.Name(), filePath); err != nil {
+		if os.IsExist(err) {
+			controllers.BadRequestHandler(w, errors.New("a video named "+name+" already exists"))
+			return
+		}
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteResponse(w, premiereFile{
+		Name: name,
+		Path: filePath,
+		Size: size,
+	})
+}
+
+// CreatePremiere will schedule a video file to be aired as the live stream.
+func CreatePremiere(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request createPremiereRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	request.Title = strings.TrimSpace(request.Title)
+	if request.Title == "" {
+		controllers.BadRequestHandler(w, errors.New("premiere must have a title"))
+		return
+	}
+
+	// A premiere without a start time is aired now.
+	now := time.Now()
+	if request.StartTime.IsZero() {
+		request.StartTime = now
+	} else if request.StartTime.Before(now.Add(-time.Minute)) {
+		controllers.BadRequestHandler(w, errors.New("premiere must not start in the past"))
+		return
+	}
+
+	if request.File == "" {
+		controllers.BadRequestHandler(w, errors.New("premiere must have a video file"))
+		return
+	}
+
+	if err := core.VerifyPremiereFile(request.File); err != nil {
+		controllers.BadRequestHandler(w, errors.New("the file is not a video that can be aired: "+err.Error()))
+		return
+	}
+
+	scheduledStream, err := data.InsertScheduledStream(request.Title, request.Description, request.StartTime, request.File)
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	go webhooks.SendStreamScheduledEvent(scheduledStream)
+
+	controllers.WriteResponse(w, scheduledStream)
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/config"
+)
+
+func uploadTestPremiereFile(name string, content string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/premieres/upload?name="+name, strings.NewReader(content))
+	w := httptest.NewRecorder()
+	UploadPremiereFile(w, r)
+
+	return w
+}
+
+func TestUploadPremiereFile(t *testing.T) {
+	storagePath := config.PremiereStoragePath
+	config.PremiereStoragePath = t.TempDir()
+	defer func() { config.PremiereStoragePath = storagePath }()
+
+	if w := uploadTestPremiereFile("video.mp4", "video"); w.Code != http.StatusOK {
+		t.Fatalf("expected the video to be uploaded, got %d %s", w.Code, w.Body)
+	}
+
+	// A video with the same name is not replaced.
+	if w := uploadTestPremiereFile("video.mp4", "another video"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a second video with the same name to be rejected, got %d", w.Code)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(config.PremiereStoragePath, "video.mp4")) // nolint
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "video" {
+		t.Errorf("expected the first video to be kept, got %q", content)
+	}
+}
+
+func TestUploadPremiereFileLimit(t *testing.T) {
+	storagePath := config.PremiereStoragePath
+	config.PremiereStoragePath = t.TempDir()
+	defer func() { config.PremiereStoragePath = storagePath }()
+
+	maxSize := maxPremiereUploadSize
+	maxPremiereUploadSize = 4
+	defer func() { maxPremiereUploadSize = maxSize }()
+
+	if w := uploadTestPremiereFile("large.mp4", "too large"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a video over the limit to be rejected, got %d", w.Code)
+	}
+
+	// A body without a length is cut off at the limit.
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/premieres/upload?name=large.mp4", strings.NewReader("too large"))
+	r.ContentLength = -1
+	w := httptest.NewRecorder()
+	UploadPremiereFile(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected a streamed video over the limit to be rejected, got %d", w.Code)
+	}
+
+	if _, err := os.Stat(filepath.Join(config.PremiereStoragePath, "large.mp4")); !os.IsNotExist(err) {
+		t.Error("expected a video over the limit to not be saved")
+	}
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type deleteRecordingRequest struct {
+	ID int `json:"id"`
+}
+
+// GetRecordings will return all the recorded broadcasts.
+func GetRecordings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	recordings, err := data.GetRecordings()
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteResponse(w, recordings)
+}
+
+// DownloadRecording will return a single recording as one MPEG-TS file.
+func DownloadRecording(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		controllers.BadRequestHandler(w, errors.New("must provide a valid recording id"))
+		return
+	}
+
+	recording, err := data.GetRecording(id)
+	if err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if recording.IsInProgress() {
+		controllers.BadRequestHandler(w, errors.New("recording is still in progress"))
+		return
+	}
+
+	segments, err := core.GetRecordingSegmentFiles(id)
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	// fMP4 recordings start with their initialization segment.
+	extension := ".ts"
+	if len(segments) > 0 && filepath.Ext(segments[0]) == ".mp4" {
+		extension = ".mp4"
+	}
+
+	w.Header().Set("Content-Type", utils.GetContentTypeForPath(extension))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"recording-%d%s\"", id, extension))
+
+	for _, segment := range segments {
+		f, err := os.Open(segment)
+		if err != nil {
+			log.Warnln("unable to read recorded segment", segment, err)
+			continue
+		}
+
+		if _, err := io.Copy(w, f); err != nil {
+			f.Close()
+			log.Debugln(err)
+			return
+		}
+		f.Close()
+	}
+}
+
+// DeleteRecording will delete a single recording and its files.
+func DeleteRecording(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != controllers.POST {
+		controllers.WriteSimpleResponse(w, false, r.Method+" not supported")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request deleteRecordingRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if err := core.DeleteRecording(request.ID); err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "deleted recording")
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/webhooks"
+)
+
+type createScheduledStreamRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"startTime"`
+}
+
+type deleteScheduledStreamRequest struct {
+	ID int `json:"id"`
+}
+
+// GetSchedule will return all the scheduled streams, including past ones.
+func GetSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := data.GetSchedule(time.Time{}, 0)
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteResponse(w, schedule)
+}
+
+// CreateScheduledStream will add an upcoming stream to the schedule.
+func CreateScheduledStream(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request createScheduledStreamRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	request.Title = strings.TrimSpace(request.Title)
+	if request.Title == "" {
+		controllers.BadRequestHandler(w, errors.New("scheduled stream must have a title"))
+		return
+	}
+
+	if !request.StartTime.After(time.Now()) {
+		controllers.BadRequestHandler(w, errors.New("scheduled stream must start in the future"))
+		return
+	}
+
+	scheduledStream, err := data.InsertScheduledStream(request.Title, request.Description, request.StartTime, "")
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	go webhooks.SendStreamScheduledEvent(scheduledStream)
+
+	controllers.WriteResponse(w, scheduledStream)
+}
+
+// DeleteScheduledStream will remove a stream from the schedule.
+func DeleteScheduledStream(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request deleteScheduledStreamRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if err := data.DeleteScheduledStream(request.ID); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "deleted scheduled stream")
+}
@@ -12,7 +12,8 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// GetServerConfig gets the config details of the server.
+// GetServerConfig gets the config details of the server. Accounts that are
+// not owners can view the config, but not the keys and secrets in it.
 func GetServerConfig(w http.ResponseWriter, r *http.Request) {
 	ffmpeg := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
 
@@ -43,12 +44,16 @@ func GetServerConfig(w http.ResponseWriter, r *http.Request) {
 			NSFW:             data.GetNSFW(),
 			CustomStyles:     data.GetCustomStyles(),
 		},
-		FFmpegPath:     ffmpeg,
-		StreamKey:      data.GetStreamKey(),
-		WebServerPort:  config.WebServerPort,
-		WebServerIP:    config.WebServerIP,
-		RTMPServerPort: data.GetRTMPPortNumber(),
-		ChatDisabled:   data.GetChatDisabled(),
+		FFmpegPath:      ffmpeg,
+		StreamKey:       data.GetStreamKey(),
+		BackupStreamKey: data.GetBackupStreamKey(),
+		WebServerPort:   config.WebServerPort,
+		WebServerIP:     config.WebServerIP,
+		RTMPServerPort:  data.GetRTMPPortNumber(),
+		SRTServerPort:   data.GetSRTPortNumber(),
+		SRTEnabled:      data.GetSRTEnabled(),
+		RTMPS:           data.GetRTMPSConfig(),
+		ChatDisabled:    data.GetChatDisabled(),
 		VideoSettings: videoSettings{
 			VideoQualityVariants: videoQualityVariants,
 			LatencyLevel:         data.GetStreamLatencyLevel().Level,
@@ -61,7 +66,21 @@ func GetServerConfig(w http.ResponseWriter, r *http.Request) {
 		ExternalActions:   data.GetExternalActions(),
 		SupportedCodecs:   transcoder.GetCodecs(ffmpeg),
 		VideoCodec:        data.GetVideoCodec(),
+		SegmentFormat:     data.GetSegmentFormat(),
+		DASHEnabled:       data.GetDASHEnabled(),
+		RadioModeEnabled:  data.GetRadioModeEnabled(),
+		AutoLadderEnabled: data.GetAutoLadderEnabled(),
+		AudioTracks:       data.GetAudioTracks(),
+		Captions:          data.GetCaptions(),
 		UsernameBlocklist: data.GetUsernameBlocklist(),
+		RecordingEnabled:  data.GetRecordingEnabled(),
+		Restreams:         data.GetRestreamDestinations(),
+		PullSource:        data.GetPullSource(),
+		RemoteTranscoder:  data.GetRemoteTranscoder(),
+	}
+
+	if !models.AdminRoleHasAccess(getAdminRole(r), models.AdminRoleOwner) {
+		response.redactSecrets()
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -71,20 +90,54 @@ func GetServerConfig(w http.ResponseWriter, r *http.Request) {
 }
 
 type serverConfigAdminResponse struct {
-	InstanceDetails   webConfigResponse       `json:"instanceDetails"`
-	FFmpegPath        string                  `json:"ffmpegPath"`
-	StreamKey         string                  `json:"streamKey"`
-	WebServerPort     int                     `json:"webServerPort"`
-	WebServerIP       string                  `json:"webServerIP"`
-	RTMPServerPort    int                     `json:"rtmpServerPort"`
-	S3                models.S3               `json:"s3"`
-	VideoSettings     videoSettings           `json:"videoSettings"`
-	YP                yp                      `json:"yp"`
-	ChatDisabled      bool                    `json:"chatDisabled"`
-	ExternalActions   []models.ExternalAction `json:"externalActions"`
-	SupportedCodecs   []string                `json:"supportedCodecs"`
-	VideoCodec        string                  `json:"videoCodec"`
-	UsernameBlocklist string                  `json:"usernameBlocklist"`
+	InstanceDetails   webConfigResponse            `json:"instanceDetails"`
+	FFmpegPath        string                       `json:"ffmpegPath"`
+	StreamKey         string                       `json:"streamKey"`
+	BackupStreamKey   string                       `json:"backupStreamKey"`
+	WebServerPort     int                          `json:"webServerPort"`
+	WebServerIP       string                       `json:"webServerIP"`
+	RTMPServerPort    int                          `json:"rtmpServerPort"`
+	SRTServerPort     int                          `json:"srtServerPort"`
+	SRTEnabled        bool                         `json:"srtEnabled"`
+	RTMPS             models.RTMPSConfig           `json:"rtmps"`
+	S3                models.S3                    `json:"s3"`
+	VideoSettings     videoSettings                `json:"videoSettings"`
+	YP                yp                           `json:"yp"`
+	ChatDisabled      bool                         `json:"chatDisabled"`
+	ExternalActions   []models.ExternalAction      `json:"externalActions"`
+	SupportedCodecs   []string                     `json:"supportedCodecs"`
+	VideoCodec        string                       `json:"videoCodec"`
+	SegmentFormat     string                       `json:"segmentFormat"`
+	DASHEnabled       bool                         `json:"dashEnabled"`
+	RadioModeEnabled  bool                         `json:"radioModeEnabled"`
+	AutoLadderEnabled bool                         `json:"autoLadderEnabled"`
+	AudioTracks       []models.AudioTrack          `json:"audioTracks"`
+	Captions          models.Captions              `json:"captions"`
+	UsernameBlocklist string                       `json:"usernameBlocklist"`
+	RecordingEnabled  bool                         `json:"recordingEnabled"`
+	Restreams         []models.RestreamDestination `json:"restreams"`
+	PullSource        models.PullSource            `json:"pullSource"`
+	RemoteTranscoder  models.RemoteTranscoder      `json:"remoteTranscoder"`
+}
+
+// redactSecrets will remove the keys and credentials from the config so it
+// can be viewed by accounts that can not change it.
+func (c *serverConfigAdminResponse) redactSecrets() {
+	c.StreamKey = ""
+	c.BackupStreamKey = ""
+	c.S3.AccessKey = ""
+	c.S3.Secret = ""
+	c.PullSource.URL = ""
+	c.RemoteTranscoder.Secret = ""
+
+	for i := range c.AudioTracks {
+		c.AudioTracks[i].StreamKey = ""
+	}
+
+	// Restream URLs include the stream key of the destination.
+	for i := range c.Restreams {
+		c.Restreams[i].URL = ""
+	}
 }
 
 type videoSettings struct {
@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/router/middleware"
+)
+
+func TestMain(m *testing.M) {
+	directory, err := ioutil.TempDir("", "owncast-admin")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := data.SetupPersistence(filepath.Join(directory, "test.db")); err != nil {
+		panic(err)
+	}
+
+	// The server config includes the encoders of the configured ffmpeg, and
+	// every file it is given is a video.
+	ffmpegPath := filepath.Join(directory, "ffmpeg")
+	if err := ioutil.WriteFile(ffmpegPath, []byte("#!/bin/sh\necho 'Stream #0:0: Video: h264, yuv420p, 1280x720, 30 fps' >&2\n"), 0700); err != nil { // nolint
+		panic(err)
+	}
+	if err := data.SetFfmpegPath(ffmpegPath); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(directory)
+	os.Exit(code)
+}
+
+func TestServerConfigForEachRole(t *testing.T) {
+	const streamKey = "test-server-config-stream-key"
+
+	if err := data.SetStreamKey(streamKey); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := middleware.RequireAdminAuth(models.AdminRoleStats, GetServerConfig)
+
+	for _, role := range []string{models.AdminRoleOwner, models.AdminRoleModerator, models.AdminRoleStats} {
+		username := "test-" + role
+		if err := data.InsertAdminUser(username, "test-password", role); err != nil {
+			t.Fatal(err)
+		}
+		defer data.DeleteAdminUser(username) //nolint
+
+		r := httptest.NewRequest(http.MethodGet, "/api/admin/serverconfig", nil)
+		r.SetBasicAuth(username, "test-password")
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected the %s role to view the server config, got %d", role, w.Code)
+			continue
+		}
+
+		var response serverConfigAdminResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		isOwner := role == models.AdminRoleOwner
+		if (response.StreamKey == streamKey) != isOwner {
+			t.Errorf("expected the stream key to only be included for owners, got %q for the %s role", response.StreamKey, role)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/serverconfig", nil)
+	r.SetBasicAuth("test-"+models.AdminRoleStats, "wrong-password")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a wrong password to be unauthorized, got %d", w.Code)
+	}
+}
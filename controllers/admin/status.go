@@ -6,6 +6,7 @@ import (
 
 	"github.com/owncast/owncast/core"
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/restream"
 	"github.com/owncast/owncast/models"
 	log "github.com/sirupsen/logrus"
 )
@@ -25,6 +26,8 @@ func Status(w http.ResponseWriter, r *http.Request) {
 		SessionPeakViewerCount: status.SessionMaxViewerCount,
 		VersionNumber:          status.VersionNumber,
 		StreamTitle:            data.GetStreamTitle(),
+		Restreams:              restream.GetStatus(),
+		Transcoder:             core.GetTranscoderHealth(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -43,4 +46,6 @@ type adminStatusResponse struct {
 	SessionPeakViewerCount int                      `json:"sessionPeakViewerCount"`
 	StreamTitle            string                   `json:"streamTitle"`
 	VersionNumber          string                   `json:"versionNumber"`
+	Restreams              []models.RestreamStatus  `json:"restreams"`
+	Transcoder             *models.TranscoderHealth `json:"transcoder"`
 }
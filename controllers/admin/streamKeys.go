@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+type deleteStreamKeyRequest struct {
+	Key string `json:"key"`
+}
+
+type createStreamKeyRequest struct {
+	Key    string     `json:"key"`
+	Label  string     `json:"label"`
+	Expiry *time.Time `json:"expiry"`
+}
+
+// CreateStreamKey will add an additional key that can be used to stream.
+func CreateStreamKey(w http.ResponseWriter, r *http.Request) {
+	if !requirePOST(w, r) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request createStreamKeyRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if request.Label == "" {
+		controllers.BadRequestHandler(w, errors.New("must provide a label"))
+		return
+	}
+
+	if request.Expiry != nil && request.Expiry.Before(time.Now()) {
+		controllers.BadRequestHandler(w, errors.New("expiry must be in the future"))
+		return
+	}
+
+	// Generate a key if one was not provided
+	key := request.Key
+	if key == "" {
+		generatedKey, err := utils.GenerateAccessToken()
+		if err != nil {
+			controllers.InternalErrorHandler(w, err)
+			return
+		}
+		key = generatedKey
+	} else if strings.Contains(key, "/") {
+		controllers.BadRequestHandler(w, errors.New("stream key can not contain a /"))
+		return
+	}
+
+	if key == data.GetStreamKey() {
+		controllers.BadRequestHandler(w, errors.New("stream key is already in use"))
+		return
+	}
+
+	if err := data.InsertStreamKey(key, request.Label, request.Expiry); err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	controllers.WriteResponse(w, models.StreamKey{
+		Key:       key,
+		Label:     request.Label,
+		Expiry:    request.Expiry,
+		Timestamp: time.Now(),
+		LastUsed:  nil,
+	})
+}
+
+// GetStreamKeys will return all the additional stream keys.
+func GetStreamKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	keys, err := data.GetAdditionalStreamKeys()
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteResponse(w, keys)
+}
+
+// DeleteStreamKey will revoke a single additional stream key. A stream
+// using the key is disconnected shortly after.
+func DeleteStreamKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != controllers.POST {
+		controllers.WriteSimpleResponse(w, false, r.Method+" not supported")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var request deleteStreamKeyRequest
+	if err := decoder.Decode(&request); err != nil {
+		controllers.BadRequestHandler(w, err)
+		return
+	}
+
+	if request.Key == "" {
+		controllers.BadRequestHandler(w, errors.New("must provide a key"))
+		return
+	}
+
+	if err := data.DeleteStreamKey(request.Key); err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteSimpleResponse(w, true, "deleted stream key")
+}
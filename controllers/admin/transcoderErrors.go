@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/owncast/owncast/controllers"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/models"
+)
+
+// GetTranscoderErrors will return the errors reported by the transcoder,
+// only for a single stream if its session is given. The session "current"
+// is the stream that is live.
+func GetTranscoderErrors(w http.ResponseWriter, r *http.Request) {
+	session := r.URL.Query().Get("session")
+	if session == "current" {
+		session = transcoder.GetTranscoderErrorSession()
+		if session == "" {
+			controllers.WriteResponse(w, []models.TranscoderError{})
+			return
+		}
+	}
+
+	transcoderErrors, err := data.GetTranscoderErrors(session)
+	if err != nil {
+		controllers.InternalErrorHandler(w, err)
+		return
+	}
+
+	controllers.WriteResponse(w, transcoderErrors)
+}
@@ -12,18 +12,19 @@ import (
 )
 
 type webConfigResponse struct {
-	Name             string                  `json:"name"`
-	Summary          string                  `json:"summary"`
-	Logo             string                  `json:"logo"`
-	Tags             []string                `json:"tags"`
-	Version          string                  `json:"version"`
-	NSFW             bool                    `json:"nsfw"`
-	ExtraPageContent string                  `json:"extraPageContent"`
-	StreamTitle      string                  `json:"streamTitle,omitempty"` // What's going on with the current stream
-	SocialHandles    []models.SocialHandle   `json:"socialHandles"`
-	ChatDisabled     bool                    `json:"chatDisabled"`
-	ExternalActions  []models.ExternalAction `json:"externalActions"`
-	CustomStyles     string                  `json:"customStyles"`
+	Name             string                   `json:"name"`
+	Summary          string                   `json:"summary"`
+	Logo             string                   `json:"logo"`
+	Tags             []string                 `json:"tags"`
+	Version          string                   `json:"version"`
+	NSFW             bool                     `json:"nsfw"`
+	ExtraPageContent string                   `json:"extraPageContent"`
+	StreamTitle      string                   `json:"streamTitle,omitempty"` // What's going on with the current stream
+	SocialHandles    []models.SocialHandle    `json:"socialHandles"`
+	ChatDisabled     bool                     `json:"chatDisabled"`
+	ExternalActions  []models.ExternalAction  `json:"externalActions"`
+	CustomStyles     string                   `json:"customStyles"`
+	Schedule         []models.ScheduledStream `json:"schedule"` // The upcoming streams
 }
 
 // GetWebConfig gets the status of the server.
@@ -57,6 +58,7 @@ func GetWebConfig(w http.ResponseWriter, r *http.Request) {
 		ChatDisabled:     data.GetChatDisabled(),
 		ExternalActions:  data.GetExternalActions(),
 		CustomStyles:     data.GetCustomStyles(),
+		Schedule:         getUpcomingScheduledStreams(upcomingScheduledStreamsLimit),
 	}
 
 	if err := json.NewEncoder(w).Encode(configuration); err != nil {
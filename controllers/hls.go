@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/router/middleware"
+	"github.com/owncast/owncast/utils"
+)
+
+// HandleHLSRequest will serve the HLS content. When low-latency HLS is being
+// used, playlist and partial segment requests are held until what they ask
+// for is available.
+func HandleHLSRequest(w http.ResponseWriter, r *http.Request) {
+	variantIndex, err := strconv.Atoi(utils.GetIndexFromFilePath(r.URL.Path))
+	if err != nil || !transcoder.IsLowLatencyHLSActive() {
+		IndexHandler(w, r)
+		return
+	}
+
+	switch path.Ext(r.URL.Path) {
+	case ".m3u8":
+		middleware.EnableCors(&w)
+		if responseCode := waitForLowLatencyPlaylist(variantIndex, r.URL.Query()); responseCode != 0 {
+			w.WriteHeader(responseCode)
+			return
+		}
+	case ".ts":
+		transcoder.WaitForLowLatencyPart(variantIndex, path.Base(r.URL.Path))
+	}
+
+	IndexHandler(w, r)
+}
+
+// waitForLowLatencyPlaylist will block a playlist reload request until the
+// segment or part it asks for is available, returning the response code if
+// it cannot be served.
+func waitForLowLatencyPlaylist(variantIndex int, query url.Values) int {
+	if query.Get("_HLS_msn") == "" {
+		if query.Get("_HLS_part") != "" {
+			return http.StatusBadRequest
+		}
+		return 0
+	}
+
+	msn, err := strconv.Atoi(query.Get("_HLS_msn"))
+	if err != nil || msn < 0 {
+		return http.StatusBadRequest
+	}
+
+	part := -1
+	if query.Get("_HLS_part") != "" {
+		if part, err = strconv.Atoi(query.Get("_HLS_part")); err != nil || part < 0 {
+			return http.StatusBadRequest
+		}
+	}
+
+	switch transcoder.WaitForLowLatencyPlaylist(variantIndex, msn, part) {
+	case nil:
+		return 0
+	case transcoder.ErrInvalidPlaylistRequest:
+		return http.StatusBadRequest
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
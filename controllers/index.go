@@ -57,10 +57,14 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if path.Ext(r.URL.Path) == ".m3u8" {
+	if path.Ext(r.URL.Path) == ".m3u8" || path.Ext(r.URL.Path) == ".mpd" {
 		middleware.DisableCache(w)
 	}
 
+	if contentType := utils.GetContentTypeForPath(r.URL.Path); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
 	// Set a cache control max-age header
 	middleware.SetCachingHeaders(w, r)
 
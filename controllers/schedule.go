@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/router/middleware"
+	"github.com/owncast/owncast/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// The number of upcoming streams returned with the web config.
+const upcomingScheduledStreamsLimit = 10
+
+// GetScheduleCalendar will return the upcoming streams as an iCalendar feed.
+func GetScheduleCalendar(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCors(&w)
+
+	schedule, err := data.GetSchedule(time.Now(), 0)
+	if err != nil {
+		InternalErrorHandler(w, err)
+		return
+	}
+
+	serverURL := data.GetServerURL()
+	host := r.Host
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	events := make([]utils.CalendarEvent, 0, len(schedule))
+	for _, scheduledStream := range schedule {
+		events = append(events, utils.CalendarEvent{
+			UID:         fmt.Sprintf("scheduled-stream-%d@%s", scheduledStream.ID, host),
+			Created:     scheduledStream.CreatedAt,
+			Start:       scheduledStream.StartTime,
+			Summary:     scheduledStream.Title,
+			Description: scheduledStream.Description,
+			URL:         serverURL,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=schedule.ics")
+	if _, err := w.Write([]byte(utils.GetICalendar(data.GetServerName(), events))); err != nil {
+		InternalErrorHandler(w, err)
+	}
+}
+
+// getUpcomingScheduledStreams will return the next streams on the schedule.
+// The video files of premieres are only shown to admins.
+func getUpcomingScheduledStreams(limit int) []models.ScheduledStream {
+	schedule, err := data.GetSchedule(time.Now(), limit)
+	if err != nil {
+		log.Errorln(err)
+	}
+
+	for i := range schedule {
+		schedule[i].Premiere = ""
+		schedule[i].PremiereStarted = nil
+	}
+
+	return schedule
+}
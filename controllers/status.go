@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/owncast/owncast/core"
+	"github.com/owncast/owncast/models"
 	"github.com/owncast/owncast/router/middleware"
 	"github.com/owncast/owncast/utils"
 )
@@ -23,6 +24,10 @@ func GetStatus(w http.ResponseWriter, r *http.Request) {
 		StreamTitle:        status.StreamTitle,
 	}
 
+	if upcoming := getUpcomingScheduledStreams(1); len(upcoming) > 0 {
+		response.NextScheduledStream = &upcoming[0]
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		InternalErrorHandler(w, err)
@@ -38,4 +43,6 @@ type webStatusResponse struct {
 
 	VersionNumber string `json:"versionNumber"`
 	StreamTitle   string `json:"streamTitle"`
+
+	NextScheduledStream *models.ScheduledStream `json:"nextScheduledStream,omitempty"`
 }
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owncast/owncast/core"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/router/middleware"
+	"github.com/owncast/owncast/utils"
+)
+
+type vodResponse struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	StartTime time.Time `json:"startTime"`
+	Duration  float64   `json:"duration"`
+	URL       string    `json:"url"`
+}
+
+// GetVODs will return the recorded broadcasts that can be watched.
+func GetVODs(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCors(&w)
+
+	recordings, err := data.GetRecordings()
+	if err != nil {
+		InternalErrorHandler(w, err)
+		return
+	}
+
+	vods := make([]vodResponse, 0)
+	for _, recording := range recordings {
+		if recording.IsInProgress() {
+			continue
+		}
+
+		if _, err := core.GetVODPlaylistPath(recording.ID); err != nil {
+			continue
+		}
+
+		vods = append(vods, vodResponse{
+			ID:        recording.ID,
+			Title:     recording.Title,
+			StartTime: recording.StartTime,
+			Duration:  recording.Duration,
+			URL:       fmt.Sprintf("/api/vod/%d/stream.m3u8", recording.ID),
+		})
+	}
+
+	WriteResponse(w, vods)
+}
+
+// ServeVOD will return the playlist or a segment of a recorded broadcast.
+func ServeVOD(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCors(&w)
+
+	// Requests are in the form of /api/vod/{id}/{file}
+	components := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/vod/"), "/")
+	if len(components) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(components[0])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var filePath string
+	if components[1] == "stream.m3u8" {
+		filePath, err = core.GetVODPlaylistPath(id)
+	} else {
+		filePath, err = core.GetVODSegmentPath(id, components[1])
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", utils.GetContentTypeForPath(filePath))
+	middleware.SetCachingHeaders(w, r)
+	http.ServeFile(w, r, filePath)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/owncast/owncast/core/chat"
 	"github.com/owncast/owncast/core/data"
 	"github.com/owncast/owncast/core/rtmp"
+	"github.com/owncast/owncast/core/srt"
+	"github.com/owncast/owncast/core/storageproviders"
 	"github.com/owncast/owncast/core/transcoder"
 	"github.com/owncast/owncast/models"
 	"github.com/owncast/owncast/utils"
@@ -23,6 +26,8 @@ var (
 	_transcoder  *transcoder.Transcoder
 	_yp          *yp.YP
 	_broadcaster *models.Broadcaster
+
+	_broadcasterLock sync.Mutex
 )
 
 var handler transcoder.HLSHandler
@@ -55,6 +60,8 @@ func Start() error {
 
 	fileWriter.SetupFileWriterReceiverService(&handler)
 
+	closeInterruptedRecordings()
+
 	if err := createInitialOfflineState(); err != nil {
 		log.Error("failed to create the initial offline state")
 		return err
@@ -65,11 +72,23 @@ func Start() error {
 	chat.Setup(ChatListenerImpl{})
 
 	// start the rtmp server
-	go rtmp.Start(setStreamAsConnected, setBroadcaster)
+	go rtmp.Start(setRTMPStreamAsConnected, setBroadcaster, isInboundStreamActive, setAudioTrackAsConnected)
 
 	rtmpPort := data.GetRTMPPortNumber()
 	log.Infof("RTMP is accepting inbound streams on port %d.", rtmpPort)
 
+	// start the srt server, if enabled
+	if data.GetSRTEnabled() {
+		go srt.Start(setSRTStreamAsConnected, setBroadcaster, isInboundStreamActive)
+
+		srtPort := data.GetSRTPortNumber()
+		log.Infof("SRT is accepting inbound streams on port %d.", srtPort)
+	}
+
+	StartPullSource()
+
+	startPremiereScheduler()
+
 	return nil
 }
 
@@ -116,6 +135,7 @@ func resetDirectories() {
 	// Wipe the public, web-accessible hls data directory
 	utils.CleanupDirectory(config.PublicHLSStoragePath)
 	utils.CleanupDirectory(config.PrivateHLSStoragePath)
+	storageproviders.ResetUploadedInitSegments()
 
 	// Remove the previous thumbnail
 	logo := data.GetLogoPath()
@@ -0,0 +1,286 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/owncast/owncast/models"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultAdminUsername is the account created for a new server.
+const DefaultAdminUsername = "admin"
+
+// verifiedAdminCredentials is a password that was checked against an
+// account's password hash, so the same request doesn't need to run bcrypt again.
+type verifiedAdminCredentials struct {
+	passwordHash   string
+	passwordDigest []byte
+}
+
+var (
+	_verifiedAdminCredentials     = make(map[string]verifiedAdminCredentials)
+	_verifiedAdminCredentialsLock sync.Mutex
+	_verifiedAdminCredentialsKey  = make([]byte, 32)
+
+	// Compared against when the username doesn't exist so the response
+	// takes as long as it would for a wrong password.
+	_dummyAdminPasswordHash     []byte
+	_dummyAdminPasswordHashOnce sync.Once
+)
+
+func init() {
+	if _, err := rand.Read(_verifiedAdminCredentialsKey); err != nil {
+		log.Fatalln("unable to generate a key for admin credentials", err)
+	}
+}
+
+func createAdminUsersTable() {
+	log.Traceln("Creating admin_users table...")
+
+	createTableSQL := `CREATE TABLE IF NOT EXISTS admin_users (
+		"username" string NOT NULL PRIMARY KEY,
+		"password_hash" string NOT NULL,
+		"timestamp" DATETIME DEFAULT CURRENT_TIMESTAMP,
+		"role" string NOT NULL DEFAULT 'OWNER'
+	);`
+
+	stmt, err := _db.Prepare(createTableSQL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// SetAdminUser will create an owner account or reset the password of an existing account.
+func SetAdminUser(username string, password string) error {
+	return setAdminUser(_db, username, password)
+}
+
+func setAdminUser(db *sql.DB, username string, password string) error {
+	hash, err := hashAdminPassword(username, password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint
+
+	stmt, err := tx.Prepare("INSERT INTO admin_users(username, password_hash) values(?, ?) ON CONFLICT(username) DO UPDATE SET password_hash = excluded.password_hash")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(username, hash); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertAdminUser will add a new admin account with the given role.
+func InsertAdminUser(username string, password string, role string) error {
+	log.Println("Adding new admin user:", username)
+
+	if !models.IsValidAdminRole(role) {
+		return errors.New("invalid role " + role)
+	}
+
+	hash, err := hashAdminPassword(username, password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint
+
+	stmt, err := tx.Prepare("INSERT INTO admin_users(username, password_hash, role) values(?, ?, ?)")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(username, hash, role); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteAdminUser will delete an admin account, as long as it is not the last owner.
+func DeleteAdminUser(username string) error {
+	log.Println("Deleting admin user:", username)
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var owners int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM admin_users WHERE role = ? AND username != ?", models.AdminRoleOwner, username).Scan(&owners); err != nil {
+		tx.Rollback() //nolint
+		return err
+	}
+
+	if owners == 0 {
+		tx.Rollback() //nolint
+		return errors.New("at least one owner account is required")
+	}
+
+	stmt, err := tx.Prepare("DELETE FROM admin_users WHERE username = ?")
+	if err != nil {
+		tx.Rollback() //nolint
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(username)
+	if err != nil {
+		tx.Rollback() //nolint
+		return err
+	}
+
+	if rowsDeleted, _ := result.RowsAffected(); rowsDeleted == 0 {
+		tx.Rollback() //nolint
+		return errors.New(username + " not found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	_verifiedAdminCredentialsLock.Lock()
+	delete(_verifiedAdminCredentials, username)
+	_verifiedAdminCredentialsLock.Unlock()
+
+	return nil
+}
+
+// GetAdminUsers will return all the admin accounts.
+func GetAdminUsers() ([]models.AdminUser, error) { //nolint
+	users := make([]models.AdminUser, 0)
+
+	rows, err := _db.Query("SELECT username, role, timestamp FROM admin_users ORDER BY username")
+	if err != nil {
+		return users, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.AdminUser
+		if err := rows.Scan(&user.Username, &user.Role, &user.Timestamp); err != nil {
+			log.Error("There is a problem reading the database.", err)
+			return users, err
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return users, err
+	}
+
+	return users, nil
+}
+
+// GetAdminRoleForCredentials will return the role of the admin account if the
+// username and password are valid.
+func GetAdminRoleForCredentials(username string, password string) (string, bool) {
+	var hash string
+	var role string
+	row := _db.QueryRow("SELECT password_hash, role FROM admin_users WHERE username = ?", username)
+	if err := row.Scan(&hash, &role); err != nil {
+		if err != sql.ErrNoRows {
+			log.Errorln(err)
+		}
+		bcrypt.CompareHashAndPassword(getDummyAdminPasswordHash(), []byte(password)) //nolint
+		return "", false
+	}
+
+	digest := getAdminPasswordDigest(username, password)
+
+	_verifiedAdminCredentialsLock.Lock()
+	verified, ok := _verifiedAdminCredentials[username]
+	_verifiedAdminCredentialsLock.Unlock()
+
+	// A changed password hash means the password was reset since it was verified.
+	if ok && verified.passwordHash == hash && hmac.Equal(verified.passwordDigest, digest) {
+		return role, true
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return "", false
+	}
+
+	_verifiedAdminCredentialsLock.Lock()
+	_verifiedAdminCredentials[username] = verifiedAdminCredentials{
+		passwordHash:   hash,
+		passwordDigest: digest,
+	}
+	_verifiedAdminCredentialsLock.Unlock()
+
+	return role, true
+}
+
+func getAdminPasswordDigest(username string, password string) []byte {
+	mac := hmac.New(sha256.New, _verifiedAdminCredentialsKey)
+	mac.Write([]byte(username + "\x00" + password)) //nolint
+	return mac.Sum(nil)
+}
+
+func getDummyAdminPasswordHash() []byte {
+	_dummyAdminPasswordHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword(_verifiedAdminCredentialsKey, bcrypt.DefaultCost)
+		if err != nil {
+			log.Errorln("unable to generate a dummy admin password hash", err)
+		}
+		_dummyAdminPasswordHash = hash
+	})
+
+	return _dummyAdminPasswordHash
+}
+
+// HasAdminUsers will return if any admin accounts exist.
+func HasAdminUsers() bool {
+	var count int
+	if err := _db.QueryRow("SELECT COUNT(*) FROM admin_users").Scan(&count); err != nil {
+		log.Errorln(err)
+		return false
+	}
+
+	return count > 0
+}
+
+func hashAdminPassword(username string, password string) (string, error) {
+	if username == "" || password == "" {
+		return "", errors.New("username and password are required")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
@@ -24,6 +24,8 @@ const serverURLKey = "server_url"
 const httpPortNumberKey = "http_port_number"
 const httpListenAddressKey = "http_listen_address"
 const rtmpPortNumberKey = "rtmp_port_number"
+const srtPortNumberKey = "srt_port_number"
+const srtEnabledKey = "srt_enabled"
 const serverMetadataTagsKey = "server_metadata_tags"
 const directoryEnabledKey = "directory_enabled"
 const directoryRegistrationKeyKey = "directory_registration_key"
@@ -42,6 +44,18 @@ const externalActionsKey = "external_actions"
 const customStylesKey = "custom_styles"
 const videoCodecKey = "video_codec"
 const blockedUsernamesKey = "blocked_usernames"
+const recordingEnabledKey = "recording_enabled"
+const restreamDestinationsKey = "restream_destinations"
+const pullSourceKey = "pull_source"
+const rtmpsConfigKey = "rtmps_config"
+const backupStreamKeyKey = "backup_stream_key"
+const segmentFormatKey = "segment_format"
+const dashEnabledKey = "dash_enabled"
+const radioModeEnabledKey = "radio_mode_enabled"
+const audioTracksKey = "audio_tracks"
+const captionsKey = "captions"
+const autoLadderEnabledKey = "auto_ladder_enabled"
+const remoteTranscoderKey = "remote_transcoder"
 
 // GetExtraPageBodyContent will return the user-supplied body content.
 func GetExtraPageBodyContent() string {
@@ -90,6 +104,22 @@ func SetStreamKey(key string) error {
 	return _datastore.SetString(streamKeyKey, key)
 }
 
+// GetBackupStreamKey will return the key a backup encoder connects with.
+func GetBackupStreamKey() string {
+	key, err := _datastore.GetString(backupStreamKeyKey)
+	if err != nil {
+		return ""
+	}
+
+	return key
+}
+
+// SetBackupStreamKey will set the key a backup encoder connects with.
+// An empty key disables the backup.
+func SetBackupStreamKey(key string) error {
+	return _datastore.SetString(backupStreamKeyKey, key)
+}
+
 // GetLogoPath will return the path for the logo, relative to webroot.
 func GetLogoPath() string {
 	logo, err := _datastore.GetString(logoPathKey)
@@ -227,6 +257,41 @@ func SetRTMPPortNumber(port float64) error {
 	return _datastore.SetNumber(rtmpPortNumberKey, port)
 }
 
+// GetSRTPortNumber will return the server SRT port.
+func GetSRTPortNumber() int {
+	port, err := _datastore.GetNumber(srtPortNumberKey)
+	if err != nil {
+		log.Traceln(srtPortNumberKey, err)
+		return config.GetDefaults().SRTServerPort
+	}
+
+	if port == 0 {
+		return config.GetDefaults().SRTServerPort
+	}
+
+	return int(port)
+}
+
+// SetSRTPortNumber will set the server SRT port.
+func SetSRTPortNumber(port float64) error {
+	return _datastore.SetNumber(srtPortNumberKey, port)
+}
+
+// GetSRTEnabled will return if the SRT server should accept inbound streams.
+func GetSRTEnabled() bool {
+	enabled, err := _datastore.GetBool(srtEnabledKey)
+	if err != nil {
+		return false
+	}
+
+	return enabled
+}
+
+// SetSRTEnabled will set if the SRT server should accept inbound streams.
+func SetSRTEnabled(enabled bool) error {
+	return _datastore.SetBool(srtEnabledKey, enabled)
+}
+
 // GetServerMetadataTags will return the metadata tags.
 func GetServerMetadataTags() []string {
 	tagsString, err := _datastore.GetString(serverMetadataTagsKey)
@@ -424,7 +489,7 @@ func GetStreamLatencyLevel() models.LatencyLevel {
 	level, err := _datastore.GetNumber(videoLatencyLevel)
 	if err != nil {
 		level = 2 // default
-	} else if level > 4 {
+	} else if _, ok := models.GetLatencyConfigs()[int(level)]; !ok {
 		level = 4 // highest
 	}
 
@@ -526,6 +591,211 @@ func GetVideoCodec() string {
 	return codec
 }
 
+// SetSegmentFormat will set the container format of HLS segments.
+func SetSegmentFormat(format string) error {
+	return _datastore.SetString(segmentFormatKey, format)
+}
+
+// GetSegmentFormat will return the container format of HLS segments.
+func GetSegmentFormat() string {
+	format, err := _datastore.GetString(segmentFormatKey)
+	if err != nil || !models.IsValidSegmentFormat(format) {
+		return models.SegmentFormatMPEGTS // Default value
+	}
+
+	return format
+}
+
+// SetDASHEnabled will set if a MPEG-DASH manifest should be written alongside HLS.
+func SetDASHEnabled(enabled bool) error {
+	return _datastore.SetBool(dashEnabledKey, enabled)
+}
+
+// GetDASHEnabled will return if a MPEG-DASH manifest should be written alongside HLS.
+func GetDASHEnabled() bool {
+	enabled, err := _datastore.GetBool(dashEnabledKey)
+	if err != nil {
+		return false
+	}
+
+	return enabled
+}
+
+// SetRadioModeEnabled will set if the stream is only audio.
+func SetRadioModeEnabled(enabled bool) error {
+	return _datastore.SetBool(radioModeEnabledKey, enabled)
+}
+
+// GetRadioModeEnabled will return if the stream is only audio.
+func GetRadioModeEnabled() bool {
+	enabled, err := _datastore.GetBool(radioModeEnabledKey)
+	if err != nil {
+		return false
+	}
+
+	return enabled
+}
+
+// SetAutoLadderEnabled will set if the stream output variants are picked from the inbound stream.
+func SetAutoLadderEnabled(enabled bool) error {
+	return _datastore.SetBool(autoLadderEnabledKey, enabled)
+}
+
+// GetAutoLadderEnabled will return if the stream output variants are picked from the inbound stream.
+func GetAutoLadderEnabled() bool {
+	enabled, err := _datastore.GetBool(autoLadderEnabledKey)
+	if err != nil {
+		return false
+	}
+
+	return enabled
+}
+
+// SetRecordingEnabled will set if broadcasts should be archived.
+func SetRecordingEnabled(enabled bool) error {
+	return _datastore.SetBool(recordingEnabledKey, enabled)
+}
+
+// GetRecordingEnabled will return if broadcasts should be archived.
+func GetRecordingEnabled() bool {
+	enabled, err := _datastore.GetBool(recordingEnabledKey)
+	if err != nil {
+		return false
+	}
+
+	return enabled
+}
+
+// GetRestreamDestinations will return the external destinations the stream is forwarded to.
+func GetRestreamDestinations() []models.RestreamDestination {
+	configEntry, err := _datastore.Get(restreamDestinationsKey)
+	if err != nil {
+		return []models.RestreamDestination{}
+	}
+
+	var destinations []models.RestreamDestination
+	if err := configEntry.getObject(&destinations); err != nil {
+		return []models.RestreamDestination{}
+	}
+
+	return destinations
+}
+
+// SetRestreamDestinations will save the external destinations the stream is forwarded to.
+func SetRestreamDestinations(destinations []models.RestreamDestination) error {
+	var configEntry = ConfigEntry{Key: restreamDestinationsKey, Value: destinations}
+	return _datastore.Save(configEntry)
+}
+
+// GetAudioTracks will return the alternate audio tracks of the stream.
+func GetAudioTracks() []models.AudioTrack {
+	configEntry, err := _datastore.Get(audioTracksKey)
+	if err != nil {
+		return []models.AudioTrack{}
+	}
+
+	var tracks []models.AudioTrack
+	if err := configEntry.getObject(&tracks); err != nil {
+		return []models.AudioTrack{}
+	}
+
+	return tracks
+}
+
+// SetAudioTracks will save the alternate audio tracks of the stream.
+func SetAudioTracks(tracks []models.AudioTrack) error {
+	var configEntry = ConfigEntry{Key: audioTracksKey, Value: tracks}
+	return _datastore.Save(configEntry)
+}
+
+// GetCaptions will return the settings of the live captions published alongside the stream.
+func GetCaptions() models.Captions {
+	configEntry, err := _datastore.Get(captionsKey)
+	if err != nil {
+		return models.Captions{}
+	}
+
+	var captions models.Captions
+	if err := configEntry.getObject(&captions); err != nil {
+		return models.Captions{}
+	}
+
+	return captions
+}
+
+// SetCaptions will save the settings of the live captions published alongside the stream.
+func SetCaptions(captions models.Captions) error {
+	var configEntry = ConfigEntry{Key: captionsKey, Value: captions}
+	return _datastore.Save(configEntry)
+}
+
+// GetPullSource will return the remote source to ingest from.
+func GetPullSource() models.PullSource {
+	configEntry, err := _datastore.Get(pullSourceKey)
+	if err != nil {
+		return models.PullSource{}
+	}
+
+	var source models.PullSource
+	if err := configEntry.getObject(&source); err != nil {
+		return models.PullSource{}
+	}
+
+	return source
+}
+
+// SetPullSource will save the remote source to ingest from.
+func SetPullSource(source models.PullSource) error {
+	var configEntry = ConfigEntry{Key: pullSourceKey, Value: source}
+	return _datastore.Save(configEntry)
+}
+
+// GetRemoteTranscoder will return the transcoder worker the stream is transcoded by.
+func GetRemoteTranscoder() models.RemoteTranscoder {
+	configEntry, err := _datastore.Get(remoteTranscoderKey)
+	if err != nil {
+		return models.RemoteTranscoder{}
+	}
+
+	var remoteTranscoder models.RemoteTranscoder
+	if err := configEntry.getObject(&remoteTranscoder); err != nil {
+		return models.RemoteTranscoder{}
+	}
+
+	return remoteTranscoder
+}
+
+// SetRemoteTranscoder will save the transcoder worker the stream is transcoded by.
+func SetRemoteTranscoder(remoteTranscoder models.RemoteTranscoder) error {
+	var configEntry = ConfigEntry{Key: remoteTranscoderKey, Value: remoteTranscoder}
+	return _datastore.Save(configEntry)
+}
+
+// GetRTMPSConfig will return the RTMPS listener configuration.
+func GetRTMPSConfig() models.RTMPSConfig {
+	configEntry, err := _datastore.Get(rtmpsConfigKey)
+	if err != nil {
+		return models.RTMPSConfig{}
+	}
+
+	var rtmpsConfig models.RTMPSConfig
+	if err := configEntry.getObject(&rtmpsConfig); err != nil {
+		return models.RTMPSConfig{}
+	}
+
+	if rtmpsConfig.Port == 0 {
+		rtmpsConfig.Port = config.GetDefaults().RTMPSServerPort
+	}
+
+	return rtmpsConfig
+}
+
+// SetRTMPSConfig will save the RTMPS listener configuration.
+func SetRTMPSConfig(rtmpsConfig models.RTMPSConfig) error {
+	var configEntry = ConfigEntry{Key: rtmpsConfigKey, Value: rtmpsConfig}
+	return _datastore.Save(configEntry)
+}
+
 // VerifySettings will perform a sanity check for specific settings values.
 func VerifySettings() error {
 	if GetStreamKey() == "" {
@@ -563,6 +833,11 @@ func FindHighestVideoQualityIndex(qualities []models.StreamOutputVariant) int {
 	}
 
 	sort.Slice(indexedQualities, func(a, b int) bool {
+		// Variants without video are only used when there is nothing else.
+		if indexedQualities[a].quality.IsAudioOnly != indexedQualities[b].quality.IsAudioOnly {
+			return !indexedQualities[a].quality.IsAudioOnly
+		}
+
 		if indexedQualities[a].quality.IsVideoPassthrough && !indexedQualities[b].quality.IsVideoPassthrough {
 			return true
 		}
@@ -17,7 +17,7 @@ import (
 )
 
 const (
-	schemaVersion = 0
+	schemaVersion = 3
 )
 
 var _db *sql.DB
@@ -90,6 +90,11 @@ func SetupPersistence(file string) error {
 
 	createWebhooksTable()
 	createAccessTokensTable()
+	createRecordingsTable()
+	createStreamKeysTable()
+	createAdminUsersTable()
+	createTranscoderErrorsTable()
+	createScheduleTable()
 
 	_datastore = &Datastore{}
 	_datastore.Setup()
@@ -113,6 +118,19 @@ func migrateDatabase(db *sql.DB, from, to int) error {
 		switch v {
 		case 0:
 			log.Printf("Migration step from %d to %d\n", v, v+1)
+			if err := migrateToSchema1(db); err != nil {
+				return err
+			}
+		case 1:
+			log.Printf("Migration step from %d to %d\n", v, v+1)
+			if err := migrateToSchema2(db); err != nil {
+				return err
+			}
+		case 2:
+			log.Printf("Migration step from %d to %d\n", v, v+1)
+			if err := migrateToSchema3(db); err != nil {
+				return err
+			}
 		default:
 			panic("missing database migration step")
 		}
@@ -125,3 +143,56 @@ func migrateDatabase(db *sql.DB, from, to int) error {
 
 	return nil
 }
+
+// migrateToSchema1 will create an admin account using the current stream key as its
+// password, as the stream key previously doubled as the admin password.
+func migrateToSchema1(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS admin_users (
+		"username" string NOT NULL PRIMARY KEY,
+		"password_hash" string NOT NULL,
+		"timestamp" DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`); err != nil {
+		return err
+	}
+
+	password := config.GetDefaults().StreamKey
+
+	var value []byte
+	err := db.QueryRow("SELECT value FROM datastore WHERE key = ?", streamKeyKey).Scan(&value)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	} else if err == nil {
+		entry := ConfigEntry{Key: streamKeyKey, Value: value}
+		if password, err = entry.getString(); err != nil {
+			return err
+		}
+	}
+
+	return setAdminUser(db, DefaultAdminUsername, password)
+}
+
+// migrateToSchema2 will add roles to admin accounts, keeping existing accounts as owners.
+func migrateToSchema2(db *sql.DB) error {
+	_, err := db.Exec(`ALTER TABLE admin_users ADD COLUMN "role" string NOT NULL DEFAULT 'OWNER'`)
+	return err
+}
+
+// migrateToSchema3 will add premieres to the schedule.
+func migrateToSchema3(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schedule (
+		"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+		"title" TEXT NOT NULL,
+		"description" TEXT,
+		"start_time" DATETIME NOT NULL,
+		"created_at" DATETIME NOT NULL
+	);`); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`ALTER TABLE schedule ADD COLUMN "premiere" TEXT`); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`ALTER TABLE schedule ADD COLUMN "premiere_started" DATETIME`)
+	return err
+}
@@ -3,6 +3,9 @@ package data
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/owncast/owncast/models"
 )
 
 func TestMain(m *testing.M) {
@@ -112,3 +115,224 @@ type TestStruct struct {
 	TestSlice       []string
 	privateProperty string
 }
+
+func TestAdditionalStreamKeys(t *testing.T) {
+	const validKey = "test-valid-stream-key"
+	const expiredKey = "test-expired-stream-key"
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	if err := InsertStreamKey(validKey, "valid", &future); err != nil {
+		panic(err)
+	}
+	if err := InsertStreamKey(expiredKey, "expired", &past); err != nil {
+		panic(err)
+	}
+	defer DeleteStreamKey(validKey)   //nolint
+	defer DeleteStreamKey(expiredKey) //nolint
+
+	if !IsValidAdditionalStreamKey(validKey) {
+		t.Error("expected", validKey, "to be valid")
+	}
+
+	if IsValidAdditionalStreamKey(expiredKey) {
+		t.Error("expected", expiredKey, "to be expired")
+	}
+
+	if IsValidAdditionalStreamKey("test-unknown-stream-key") {
+		t.Error("expected an unknown stream key to be invalid")
+	}
+}
+
+func TestAdminCredentials(t *testing.T) {
+	const username = "test-admin"
+	const password = "test-password"
+
+	if err := InsertAdminUser(username, "old-password", models.AdminRoleModerator); err != nil {
+		panic(err)
+	}
+	defer DeleteAdminUser(username) //nolint
+
+	if err := SetAdminUser(username, password); err != nil {
+		panic(err)
+	}
+
+	if role, ok := GetAdminRoleForCredentials(username, password); !ok || role != models.AdminRoleModerator {
+		t.Error("expected the updated password to be accepted with the", models.AdminRoleModerator, "role but got", role)
+	}
+
+	if _, ok := GetAdminRoleForCredentials(username, "old-password"); ok {
+		t.Error("expected the previous password to be rejected")
+	}
+
+	if _, ok := GetAdminRoleForCredentials("test-unknown-admin", password); ok {
+		t.Error("expected an unknown admin account to be rejected")
+	}
+}
+
+func TestInitialAdminUser(t *testing.T) {
+	createInitialAdminUser()
+
+	if !HasAdminUsers() {
+		t.Fatal("expected the initial admin account to be created")
+	}
+
+	if _, ok := GetAdminRoleForCredentials(DefaultAdminUsername, GetStreamKey()); ok {
+		t.Error("expected the stream key not to be the initial admin password")
+	}
+}
+
+func TestVerifiedAdminCredentials(t *testing.T) {
+	const username = "test-verified-admin"
+	const password = "test-password"
+
+	if err := InsertAdminUser(username, password, models.AdminRoleStats); err != nil {
+		panic(err)
+	}
+	defer DeleteAdminUser(username) //nolint
+
+	for i := 0; i < 2; i++ {
+		if role, ok := GetAdminRoleForCredentials(username, password); !ok || role != models.AdminRoleStats {
+			t.Error("expected the password to be accepted with the", models.AdminRoleStats, "role but got", role)
+		}
+	}
+
+	if _, ok := GetAdminRoleForCredentials(username, "wrong-password"); ok {
+		t.Error("expected a wrong password to be rejected once the account was verified")
+	}
+
+	if err := SetAdminUser(username, "new-password"); err != nil {
+		panic(err)
+	}
+
+	if _, ok := GetAdminRoleForCredentials(username, password); ok {
+		t.Error("expected the verified password to be rejected once it was reset")
+	}
+
+	if _, ok := GetAdminRoleForCredentials(username, "new-password"); !ok {
+		t.Error("expected the new password to be accepted")
+	}
+}
+
+func TestTranscoderErrors(t *testing.T) {
+	const session = "test-transcoder-session"
+
+	seen := time.Now()
+	id, err := InsertTranscoderError(models.TranscoderError{
+		Session:   session,
+		Code:      "ENCODER_UNAVAILABLE",
+		Severity:  models.TranscoderErrorSeverityCritical,
+		Message:   "Unknown encoder 'h264_nvenc'",
+		Count:     1,
+		FirstSeen: seen,
+		LastSeen:  seen,
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer DeleteTranscoderErrorsBefore(time.Now().Add(time.Hour)) //nolint
+
+	if err := SetTranscoderErrorRepeated(id, 3, seen.Add(time.Second)); err != nil {
+		panic(err)
+	}
+
+	transcoderErrors, err := GetTranscoderErrors(session)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(transcoderErrors) != 1 {
+		t.Fatalf("expected 1 transcoder error, got %d", len(transcoderErrors))
+	}
+	if transcoderErrors[0].Code != "ENCODER_UNAVAILABLE" || transcoderErrors[0].Count != 3 {
+		t.Errorf("expected the repeated error to be counted, got %+v", transcoderErrors[0])
+	}
+
+	if other, _ := GetTranscoderErrors("test-other-session"); len(other) != 0 {
+		t.Errorf("expected no errors for another session, got %d", len(other))
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	later, err := InsertScheduledStream("test later stream", "", start.Add(2*time.Hour), "")
+	if err != nil {
+		panic(err)
+	}
+	defer DeleteScheduledStream(later.ID) //nolint
+
+	sooner, err := InsertScheduledStream("test sooner stream", "with a description", start, "")
+	if err != nil {
+		panic(err)
+	}
+	defer DeleteScheduledStream(sooner.ID) //nolint
+
+	schedule, err := GetSchedule(start.Add(-time.Minute), 0)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(schedule) != 2 || schedule[0].ID != sooner.ID || schedule[1].ID != later.ID {
+		t.Fatalf("expected the scheduled streams soonest first, got %+v", schedule)
+	}
+	if schedule[0].Description != "with a description" || !schedule[0].StartTime.Equal(start) {
+		t.Errorf("unexpected scheduled stream %+v", schedule[0])
+	}
+
+	if next, _ := GetSchedule(start.Add(-time.Minute), 1); len(next) != 1 || next[0].ID != sooner.ID {
+		t.Errorf("expected only the next scheduled stream, got %+v", next)
+	}
+
+	if near := GetScheduledStreamNear(start.Add(-10*time.Minute), time.Hour); near == nil || near.ID != sooner.ID {
+		t.Errorf("expected the sooner stream to be near its start time, got %+v", near)
+	}
+
+	if near := GetScheduledStreamNear(start.Add(-3*time.Hour), time.Hour); near != nil {
+		t.Errorf("expected no scheduled stream outside the window, got %+v", near)
+	}
+
+	if err := DeleteScheduledStream(sooner.ID); err != nil {
+		panic(err)
+	}
+	if err := DeleteScheduledStream(sooner.ID); err == nil {
+		t.Error("expected deleting a removed scheduled stream to fail")
+	}
+}
+
+func TestDuePremieres(t *testing.T) {
+	now := time.Now()
+
+	premiere, err := InsertScheduledStream("test premiere", "", now.Add(-time.Minute), "data/premieres/test.mp4")
+	if err != nil {
+		panic(err)
+	}
+	defer DeleteScheduledStream(premiere.ID) //nolint
+
+	live, err := InsertScheduledStream("test live stream", "", now.Add(-time.Minute), "")
+	if err != nil {
+		panic(err)
+	}
+	defer DeleteScheduledStream(live.ID) //nolint
+
+	due, err := GetDuePremieres(now, time.Hour)
+	if err != nil {
+		panic(err)
+	}
+	if len(due) != 1 || due[0].ID != premiere.ID || due[0].Premiere != "data/premieres/test.mp4" {
+		t.Fatalf("expected only the premiere to be due, got %+v", due)
+	}
+
+	if late, _ := GetDuePremieres(now.Add(2*time.Hour), time.Hour); len(late) != 0 {
+		t.Errorf("expected a premiere past the window not to be due, got %+v", late)
+	}
+
+	if err := SetPremiereStarted(premiere.ID, now); err != nil {
+		panic(err)
+	}
+
+	if due, _ := GetDuePremieres(now, time.Hour); len(due) != 0 {
+		t.Errorf("expected an aired premiere not to be due, got %+v", due)
+	}
+}
@@ -1,8 +1,11 @@
 package data
 
 import (
+	log "github.com/sirupsen/logrus"
+
 	"github.com/owncast/owncast/config"
 	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
 )
 
 // HasPopulatedDefaults will determine if the defaults have been inserted into the database.
@@ -18,6 +21,10 @@ func HasPopulatedDefaults() bool {
 func PopulateDefaults() {
 	defaults := config.GetDefaults()
 
+	if !HasAdminUsers() {
+		createInitialAdminUser()
+	}
+
 	if HasPopulatedDefaults() {
 		return
 	}
@@ -42,3 +49,20 @@ func PopulateDefaults() {
 	_datastore.warmCache()
 	_ = _datastore.SetBool("HAS_POPULATED_DEFAULTS", true)
 }
+
+// createInitialAdminUser will create the owner account of a new server with a
+// random password that is only shown once, in the log.
+func createInitialAdminUser() {
+	password, err := utils.GenerateAccessToken()
+	if err != nil {
+		log.Errorln("unable to generate an admin password", err)
+		return
+	}
+
+	if err := SetAdminUser(DefaultAdminUsername, password); err != nil {
+		log.Errorln("unable to create the admin account", err)
+		return
+	}
+
+	log.Warnf("Created the admin account %q with the password %s. It will not be shown again. Use -adminpassword to reset it.", DefaultAdminUsername, password)
+}
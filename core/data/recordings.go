@@ -0,0 +1,159 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/owncast/owncast/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func createRecordingsTable() {
+	log.Traceln("Creating recordings table...")
+
+	createTableSQL := `CREATE TABLE IF NOT EXISTS recordings (
+		"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+		"title" TEXT,
+		"start_time" DATETIME NOT NULL,
+		"end_time" DATETIME,
+		"duration" REAL DEFAULT 0,
+		"size" INTEGER DEFAULT 0
+	);`
+
+	stmt, err := _db.Prepare(createTableSQL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// InsertRecording will add a new, in progress, recording to the database.
+func InsertRecording(title string, startTime time.Time) (int, error) {
+	log.Traceln("Adding new recording:", title)
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare("INSERT INTO recordings(title, start_time) values(?, ?)")
+
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	insertResult, err := stmt.Exec(title, startTime)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	newID, err := insertResult.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(newID), err
+}
+
+// SetRecordingCompleted will mark a recording as finished and save its final details.
+func SetRecordingCompleted(id int, endTime time.Time, duration float64, size int64) error {
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("UPDATE recordings SET end_time = ?, duration = ?, size = ? WHERE id = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(endTime, duration, size, id); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteRecording will delete a recording from the database.
+func DeleteRecording(id int) error {
+	log.Println("Deleting recording:", id)
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("DELETE FROM recordings WHERE id = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(id)
+	if err != nil {
+		return err
+	}
+
+	if rowsDeleted, _ := result.RowsAffected(); rowsDeleted == 0 {
+		tx.Rollback() //nolint
+		return errors.New(fmt.Sprint(id) + " not found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRecording will return a single recording.
+func GetRecording(id int) (models.Recording, error) {
+	row := _db.QueryRow("SELECT id, title, start_time, end_time, duration, size FROM recordings WHERE id = ?", id)
+
+	var recording models.Recording
+	if err := row.Scan(&recording.ID, &recording.Title, &recording.StartTime, &recording.EndTime, &recording.Duration, &recording.Size); err != nil {
+		return recording, err
+	}
+
+	return recording, nil
+}
+
+// GetRecordings will return all recordings, newest first.
+func GetRecordings() ([]models.Recording, error) { //nolint
+	recordings := make([]models.Recording, 0)
+
+	rows, err := _db.Query("SELECT id, title, start_time, end_time, duration, size FROM recordings ORDER BY start_time DESC")
+	if err != nil {
+		return recordings, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recording models.Recording
+		if err := rows.Scan(&recording.ID, &recording.Title, &recording.StartTime, &recording.EndTime, &recording.Duration, &recording.Size); err != nil {
+			log.Error("There is a problem reading the database.", err)
+			return recordings, err
+		}
+
+		recordings = append(recordings, recording)
+	}
+
+	if err := rows.Err(); err != nil {
+		return recordings, err
+	}
+
+	return recordings, nil
+}
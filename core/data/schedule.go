@@ -0,0 +1,203 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/owncast/owncast/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func createScheduleTable() {
+	log.Traceln("Creating schedule table...")
+
+	createTableSQL := `CREATE TABLE IF NOT EXISTS schedule (
+		"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+		"title" TEXT NOT NULL,
+		"description" TEXT,
+		"start_time" DATETIME NOT NULL,
+		"created_at" DATETIME NOT NULL,
+		"premiere" TEXT,
+		"premiere_started" DATETIME
+	);`
+
+	stmt, err := _db.Prepare(createTableSQL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// InsertScheduledStream will add an upcoming broadcast to the schedule. The
+// video file is aired at the start time if a premiere is given.
+func InsertScheduledStream(title string, description string, startTime time.Time, premiere string) (models.ScheduledStream, error) {
+	scheduledStream := models.ScheduledStream{
+		Title:       title,
+		Description: description,
+		StartTime:   startTime.UTC(),
+		CreatedAt:   time.Now().UTC(),
+		Premiere:    premiere,
+	}
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return scheduledStream, err
+	}
+	stmt, err := tx.Prepare("INSERT INTO schedule(title, description, start_time, created_at, premiere) values(?, ?, ?, ?, ?)")
+
+	if err != nil {
+		return scheduledStream, err
+	}
+	defer stmt.Close()
+
+	insertResult, err := stmt.Exec(scheduledStream.Title, scheduledStream.Description, scheduledStream.StartTime, scheduledStream.CreatedAt, scheduledStream.Premiere)
+	if err != nil {
+		return scheduledStream, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return scheduledStream, err
+	}
+
+	newID, err := insertResult.LastInsertId()
+	if err != nil {
+		return scheduledStream, err
+	}
+	scheduledStream.ID = int(newID)
+
+	return scheduledStream, nil
+}
+
+// DeleteScheduledStream will remove a broadcast from the schedule.
+func DeleteScheduledStream(id int) error {
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("DELETE FROM schedule WHERE id = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(id)
+	if err != nil {
+		return err
+	}
+
+	if rowsDeleted, _ := result.RowsAffected(); rowsDeleted == 0 {
+		tx.Rollback() //nolint
+		return errors.New(fmt.Sprint(id) + " not found")
+	}
+
+	return tx.Commit()
+}
+
+const scheduleColumns = "id, title, description, start_time, created_at, premiere, premiere_started"
+
+// SetPremiereStarted will save when a premiere was aired, so it is only aired once.
+func SetPremiereStarted(id int, started time.Time) error {
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("UPDATE schedule SET premiere_started = ? WHERE id = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(started.UTC(), id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDuePremieres will return the premieres that have not been aired and
+// were to start within the window before a time, soonest first.
+func GetDuePremieres(t time.Time, window time.Duration) ([]models.ScheduledStream, error) {
+	query := "SELECT " + scheduleColumns + " FROM schedule WHERE premiere <> '' AND premiere_started IS NULL AND start_time >= ? AND start_time <= ? ORDER BY start_time ASC"
+	return getScheduledStreams(query, t.Add(-window).UTC(), t.UTC())
+}
+
+// GetSchedule will return the broadcasts that start after a time, soonest
+// first. All of them are returned if limit is 0.
+func GetSchedule(after time.Time, limit int) ([]models.ScheduledStream, error) {
+	query := "SELECT " + scheduleColumns + " FROM schedule WHERE start_time >= ? ORDER BY start_time ASC"
+	args := []interface{}{after.UTC()}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	return getScheduledStreams(query, args...)
+}
+
+// GetScheduledStreamNear will return the broadcast scheduled closest to a
+// time, if one starts within the window either side of it.
+func GetScheduledStreamNear(t time.Time, window time.Duration) *models.ScheduledStream {
+	query := "SELECT " + scheduleColumns + " FROM schedule WHERE start_time >= ? AND start_time <= ?"
+	scheduledStreams, err := getScheduledStreams(query, t.Add(-window).UTC(), t.Add(window).UTC())
+	if err != nil {
+		log.Errorln(err)
+		return nil
+	}
+
+	var closest *models.ScheduledStream
+	for i, scheduledStream := range scheduledStreams {
+		if closest == nil || absDuration(scheduledStream.StartTime.Sub(t)) < absDuration(closest.StartTime.Sub(t)) {
+			closest = &scheduledStreams[i]
+		}
+	}
+
+	return closest
+}
+
+func getScheduledStreams(query string, args ...interface{}) ([]models.ScheduledStream, error) {
+	scheduledStreams := make([]models.ScheduledStream, 0)
+
+	rows, err := _db.Query(query, args...)
+	if err != nil {
+		return scheduledStreams, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var scheduledStream models.ScheduledStream
+		var description *string
+		var premiere *string
+		if err := rows.Scan(&scheduledStream.ID, &scheduledStream.Title, &description, &scheduledStream.StartTime, &scheduledStream.CreatedAt, &premiere, &scheduledStream.PremiereStarted); err != nil {
+			log.Error("There is a problem reading the database.", err)
+			return scheduledStreams, err
+		}
+
+		if description != nil {
+			scheduledStream.Description = *description
+		}
+		if premiere != nil {
+			scheduledStream.Premiere = *premiere
+		}
+
+		scheduledStreams = append(scheduledStreams, scheduledStream)
+	}
+
+	if err := rows.Err(); err != nil {
+		return scheduledStreams, err
+	}
+
+	return scheduledStreams, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"errors"
+	"time"
+
+	"github.com/owncast/owncast/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func createStreamKeysTable() {
+	log.Traceln("Creating stream_keys table...")
+
+	createTableSQL := `CREATE TABLE IF NOT EXISTS stream_keys (
+		"key" string NOT NULL PRIMARY KEY,
+		"label" string,
+		"expiry" DATETIME,
+		"timestamp" DATETIME DEFAULT CURRENT_TIMESTAMP,
+		"last_used" DATETIME
+	);`
+
+	stmt, err := _db.Prepare(createTableSQL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// InsertStreamKey will add a new stream key to the database.
+func InsertStreamKey(key string, label string, expiry *time.Time) error {
+	log.Println("Adding new stream key:", label)
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO stream_keys(key, label, expiry, timestamp) values(?, ?, ?, ?)")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(key, label, expiry, time.Now()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteStreamKey will delete a stream key from the database.
+func DeleteStreamKey(key string) error {
+	log.Println("Deleting stream key")
+
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("DELETE FROM stream_keys WHERE key = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(key)
+	if err != nil {
+		return err
+	}
+
+	if rowsDeleted, _ := result.RowsAffected(); rowsDeleted == 0 {
+		tx.Rollback() //nolint
+		return errors.New("stream key not found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAdditionalStreamKey will return a single additional stream key.
+func GetAdditionalStreamKey(key string) (models.StreamKey, error) {
+	row := _db.QueryRow("SELECT key, label, expiry, timestamp, last_used FROM stream_keys WHERE key = ?", key)
+
+	var streamKey models.StreamKey
+	if err := row.Scan(&streamKey.Key, &streamKey.Label, &streamKey.Expiry, &streamKey.Timestamp, &streamKey.LastUsed); err != nil {
+		return streamKey, err
+	}
+
+	return streamKey, nil
+}
+
+// GetAdditionalStreamKeys will return all the additional stream keys.
+func GetAdditionalStreamKeys() ([]models.StreamKey, error) { //nolint
+	keys := make([]models.StreamKey, 0)
+
+	rows, err := _db.Query("SELECT key, label, expiry, timestamp, last_used FROM stream_keys ORDER BY timestamp")
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var streamKey models.StreamKey
+		if err := rows.Scan(&streamKey.Key, &streamKey.Label, &streamKey.Expiry, &streamKey.Timestamp, &streamKey.LastUsed); err != nil {
+			log.Error("There is a problem reading the database.", err)
+			return keys, err
+		}
+
+		keys = append(keys, streamKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return keys, err
+	}
+
+	return keys, nil
+}
+
+// IsValidAdditionalStreamKey will return if a key exists and has not expired.
+func IsValidAdditionalStreamKey(key string) bool {
+	streamKey, err := GetAdditionalStreamKey(key)
+	if err != nil {
+		return false
+	}
+
+	return !streamKey.IsExpired()
+}
+
+// SetStreamKeyAsUsed will update the last used timestamp for a stream key.
+func SetStreamKeyAsUsed(key string) error {
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("UPDATE stream_keys SET last_used = ? WHERE key = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(time.Now(), key); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
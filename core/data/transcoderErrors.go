@@ -0,0 +1,125 @@
+package data
+
+import (
+	"time"
+
+	"github.com/owncast/owncast/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func createTranscoderErrorsTable() {
+	log.Traceln("Creating transcoder errors table...")
+
+	createTableSQL := `CREATE TABLE IF NOT EXISTS transcoder_errors (
+		"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+		"session" TEXT NOT NULL,
+		"code" TEXT NOT NULL,
+		"severity" TEXT NOT NULL,
+		"message" TEXT,
+		"suggested_fix" TEXT,
+		"count" INTEGER DEFAULT 1,
+		"first_seen" DATETIME NOT NULL,
+		"last_seen" DATETIME NOT NULL
+	);`
+
+	stmt, err := _db.Prepare(createTableSQL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// InsertTranscoderError will add an error reported by the transcoder to the database.
+func InsertTranscoderError(transcoderError models.TranscoderError) (int, error) {
+	tx, err := _db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare("INSERT INTO transcoder_errors(session, code, severity, message, suggested_fix, count, first_seen, last_seen) values(?, ?, ?, ?, ?, ?, ?, ?)")
+
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	insertResult, err := stmt.Exec(transcoderError.Session, transcoderError.Code, transcoderError.Severity, transcoderError.Message, transcoderError.SuggestedFix, transcoderError.Count, transcoderError.FirstSeen, transcoderError.LastSeen)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	newID, err := insertResult.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(newID), err
+}
+
+// SetTranscoderErrorRepeated will save how many times an error was reported, and when it was last.
+func SetTranscoderErrorRepeated(id int, count int, lastSeen time.Time) error {
+	tx, err := _db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("UPDATE transcoder_errors SET count = ?, last_seen = ? WHERE id = ?")
+
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(count, lastSeen, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTranscoderErrorsBefore will delete the errors last reported before a time.
+func DeleteTranscoderErrorsBefore(before time.Time) error {
+	_, err := _db.Exec("DELETE FROM transcoder_errors WHERE last_seen < ?", before)
+	return err
+}
+
+// GetTranscoderErrors will return the errors reported by the transcoder,
+// newest first. Only the errors of a session are returned if one is given.
+func GetTranscoderErrors(session string) ([]models.TranscoderError, error) {
+	transcoderErrors := make([]models.TranscoderError, 0)
+
+	query := "SELECT id, session, code, severity, message, suggested_fix, count, first_seen, last_seen FROM transcoder_errors"
+	args := []interface{}{}
+	if session != "" {
+		query += " WHERE session = ?"
+		args = append(args, session)
+	}
+	query += " ORDER BY last_seen DESC"
+
+	rows, err := _db.Query(query, args...)
+	if err != nil {
+		return transcoderErrors, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transcoderError models.TranscoderError
+		if err := rows.Scan(&transcoderError.ID, &transcoderError.Session, &transcoderError.Code, &transcoderError.Severity, &transcoderError.Message, &transcoderError.SuggestedFix, &transcoderError.Count, &transcoderError.FirstSeen, &transcoderError.LastSeen); err != nil {
+			log.Error("There is a problem reading the database.", err)
+			return transcoderErrors, err
+		}
+
+		transcoderErrors = append(transcoderErrors, transcoderError)
+	}
+
+	if err := rows.Err(); err != nil {
+		return transcoderErrors, err
+	}
+
+	return transcoderErrors, nil
+}
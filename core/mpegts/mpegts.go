@@ -0,0 +1,115 @@
+package mpegts
+
+const (
+	// PacketSize is the size of every transport stream packet.
+	PacketSize = 188
+
+	// SyncByte starts every transport stream packet.
+	SyncByte = 0x47
+)
+
+// ElementaryStream is a stream listed in a program map table.
+type ElementaryStream struct {
+	StreamType byte
+	PID        int
+}
+
+// GetPID will return the pid of a packet.
+func GetPID(pkt []byte) int {
+	return int(pkt[1]&0x1F)<<8 | int(pkt[2])
+}
+
+// IsUnitStart will return if a packet starts a table section or a PES packet.
+func IsUnitStart(pkt []byte) bool {
+	return pkt[1]&0x40 != 0
+}
+
+// GetPayload will return the payload of a packet after its adaptation field.
+func GetPayload(pkt []byte) []byte {
+	payload := pkt[4:]
+
+	switch (pkt[3] >> 4) & 0x3 {
+	case 0x2:
+		return nil
+	case 0x3:
+		adaptationLength := int(payload[0])
+		if adaptationLength+1 >= len(payload) {
+			return nil
+		}
+		payload = payload[adaptationLength+1:]
+	}
+
+	return payload
+}
+
+// GetSection will return the start of the table section carried by a packet.
+func GetSection(pkt []byte) []byte {
+	payload := GetPayload(pkt)
+	if payload == nil {
+		return nil
+	}
+
+	pointer := int(payload[0])
+	if pointer+1 >= len(payload) {
+		return nil
+	}
+
+	return payload[pointer+1:]
+}
+
+// GetSectionEnd will return the end of the data in a table section, before
+// its CRC.
+func GetSectionEnd(section []byte) int {
+	if len(section) < 3 {
+		return 0
+	}
+
+	// The section length does not include the header and ends with a CRC.
+	end := 3 + (int(section[1]&0x0F)<<8 | int(section[2])) - 4
+	if end > len(section) {
+		end = len(section)
+	}
+
+	return end
+}
+
+// GetPMTPID will return the pid of the first program's map table from a
+// program association table, or -1 if there is none.
+func GetPMTPID(section []byte) int {
+	if len(section) < 8 || section[0] != 0x00 {
+		return -1
+	}
+
+	end := GetSectionEnd(section)
+	for i := 8; i+4 <= end; i += 4 {
+		programNumber := int(section[i])<<8 | int(section[i+1])
+		if programNumber != 0 {
+			return int(section[i+2]&0x1F)<<8 | int(section[i+3])
+		}
+	}
+
+	return -1
+}
+
+// GetElementaryStreams will return the streams listed in a program map
+// table, and false if the section is not a program map table.
+func GetElementaryStreams(section []byte) ([]ElementaryStream, bool) {
+	if len(section) < 12 || section[0] != 0x02 {
+		return nil, false
+	}
+
+	var streams []ElementaryStream
+	end := GetSectionEnd(section)
+	programInfoLength := int(section[10]&0x0F)<<8 | int(section[11])
+	for i := 12 + programInfoLength; i+5 <= end; {
+		streams = append(streams, ElementaryStream{
+			StreamType: section[i],
+			PID:        int(section[i+1]&0x1F)<<8 | int(section[i+2]),
+		})
+
+		esInfoLength := int(section[i+3]&0x0F)<<8 | int(section[i+4])
+		i += 5 + esInfoLength
+	}
+
+	return streams, true
+}
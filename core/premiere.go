@@ -0,0 +1,139 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+const (
+	// How often the schedule is checked for premieres to air.
+	premiereCheckInterval = 10 * time.Second
+
+	// How late a premiere is still aired, such as when another stream was
+	// live at its start time.
+	maxPremiereDelay = time.Hour
+)
+
+var (
+	// If the current stream is a premiere.
+	_premiereStreaming     bool
+	_premiereStreamingLock sync.Mutex
+)
+
+// startPremiereScheduler will air the premieres on the schedule at their
+// start times.
+func startPremiereScheduler() {
+	go func() {
+		for range time.NewTicker(premiereCheckInterval).C {
+			airDuePremiere()
+		}
+	}()
+}
+
+// airDuePremiere will air the next premiere that is due, unless a stream is
+// already live.
+func airDuePremiere() {
+	// A broadcaster takes priority. The premiere is aired after their stream
+	// if it ends in time.
+	if isInboundStreamActive() {
+		return
+	}
+
+	premieres, err := data.GetDuePremieres(time.Now(), maxPremiereDelay)
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	if len(premieres) == 0 {
+		return
+	}
+
+	// A premiere is only tried once, so one that can't be aired is not
+	// tried again every time the schedule is checked.
+	premiere := premieres[0]
+	if err := data.SetPremiereStarted(premiere.ID, time.Now()); err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	if err := startPremiere(premiere); err != nil {
+		log.Errorln("Unable to air the premiere", premiere.Title, err)
+	}
+}
+
+// startPremiere will air the video file of a premiere as the live stream.
+func startPremiere(premiere models.ScheduledStream) error {
+	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
+	details, err := transcoder.ProbeInput(ffmpegPath, premiere.Premiere)
+	if err != nil {
+		return err
+	}
+
+	if err := data.SetStreamTitle(premiere.Title); err != nil {
+		log.Errorln(err)
+	}
+
+	log.Infoln("Airing the premiere", premiere.Title)
+	setBroadcaster(models.Broadcaster{
+		RemoteAddr:    "premiere:" + filepath.Base(premiere.Premiere),
+		StreamDetails: details,
+		Time:          time.Now(),
+	})
+
+	setPremiereStreaming(true)
+	startStream(func(t *transcoder.Transcoder) {
+		t.SetPremiereInput(premiere.Premiere)
+	}, func() {
+		setPremiereStreaming(false)
+	})
+
+	return nil
+}
+
+// DisconnectPremiere will end the current stream if it is a premiere.
+func DisconnectPremiere() {
+	if isPremiereStreaming() && _transcoder != nil {
+		_transcoder.Stop()
+	}
+}
+
+func setPremiereStreaming(streaming bool) {
+	_premiereStreamingLock.Lock()
+	defer _premiereStreamingLock.Unlock()
+
+	_premiereStreaming = streaming
+}
+
+func isPremiereStreaming() bool {
+	_premiereStreamingLock.Lock()
+	defer _premiereStreamingLock.Unlock()
+
+	return _premiereStreaming
+}
+
+// VerifyPremiereFile will return an error if a file can't be aired as a premiere.
+func VerifyPremiereFile(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return errors.New(filePath + " is not a file")
+	}
+
+	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
+	_, err = transcoder.ProbeInput(ffmpegPath, filePath)
+	return err
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+const defaultPullSourceReconnectDelay = 10
+
+var (
+	_pullSourceStop chan struct{}
+	_pullSourceLock sync.Mutex
+
+	// If the current stream is from the pull source.
+	_pullSourceStreaming     bool
+	_pullSourceStreamingLock sync.Mutex
+)
+
+// StartPullSource will begin ingesting from the configured pull source,
+// replacing any pull source that is currently running.
+func StartPullSource() {
+	StopPullSource()
+
+	source := data.GetPullSource()
+	if !source.Enabled || source.URL == "" {
+		return
+	}
+
+	_pullSourceLock.Lock()
+	defer _pullSourceLock.Unlock()
+
+	stop := make(chan struct{})
+	_pullSourceStop = stop
+	go runPullSource(source, stop)
+}
+
+// StopPullSource will stop ingesting from the pull source and end its stream.
+func StopPullSource() {
+	_pullSourceLock.Lock()
+	if _pullSourceStop == nil {
+		_pullSourceLock.Unlock()
+		return
+	}
+
+	close(_pullSourceStop)
+	_pullSourceStop = nil
+	_pullSourceLock.Unlock()
+
+	DisconnectPullSource()
+}
+
+func runPullSource(source models.PullSource, stop chan struct{}) {
+	delay := source.ReconnectDelay
+	if delay <= 0 {
+		delay = defaultPullSourceReconnectDelay
+	}
+
+	for {
+		// A broadcaster pushing a stream takes priority.
+		if !isInboundStreamActive() {
+			if completed, err := connectPullSource(source); err != nil {
+				log.Debugln("unable to connect to pull source:", err)
+			} else {
+				select {
+				case <-completed:
+					log.Infoln("Pull source disconnected.")
+				case <-stop:
+					// The stream may have started after StopPullSource ended the previous one.
+					DisconnectPullSource()
+					return
+				}
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Duration(delay) * time.Second):
+		}
+	}
+}
+
+// connectPullSource will probe the source and, if it is available, start the stream.
+// The returned channel is closed once the stream has ended.
+func connectPullSource(source models.PullSource) (chan struct{}, error) {
+	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
+	details, err := transcoder.ProbeInput(ffmpegPath, source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteAddr := source.URL
+	if u, err := url.Parse(source.URL); err == nil {
+		remoteAddr = u.Host
+	}
+
+	log.Infoln("Pull source connected.")
+	setBroadcaster(models.Broadcaster{
+		RemoteAddr:    remoteAddr,
+		StreamDetails: details,
+		Time:          time.Now(),
+	})
+
+	setScheduledStreamTitle()
+
+	completed := make(chan struct{})
+	setPullSourceStreaming(true)
+	startStream(func(t *transcoder.Transcoder) {
+		t.SetPullInput(source.URL)
+	}, func() {
+		setPullSourceStreaming(false)
+		close(completed)
+	})
+
+	return completed, nil
+}
+
+// DisconnectPullSource will end the current stream if it is from the pull source.
+// The source will be connected to again after the reconnect delay.
+func DisconnectPullSource() {
+	if isPullSourceStreaming() && _transcoder != nil {
+		_transcoder.Stop()
+	}
+}
+
+func setPullSourceStreaming(streaming bool) {
+	_pullSourceStreamingLock.Lock()
+	defer _pullSourceStreamingLock.Unlock()
+
+	_pullSourceStreaming = streaming
+}
+
+func isPullSourceStreaming() bool {
+	_pullSourceStreamingLock.Lock()
+	defer _pullSourceStreamingLock.Unlock()
+
+	return _pullSourceStreaming
+}
+
+// isInboundStreamActive will return if a stream is being ingested from any source.
+func isInboundStreamActive() bool {
+	return (_stats != nil && _stats.StreamConnected) || isStreamStarting()
+}
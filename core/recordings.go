@@ -0,0 +1,286 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafov/m3u8"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/playlist"
+	"github.com/owncast/owncast/core/transcoder"
+	"github.com/owncast/owncast/utils"
+)
+
+// vodPlaylistFilename is the published playlist pointing at the recording's
+// segments wherever the storage provider placed them.
+const vodPlaylistFilename = "vod.m3u8"
+
+var (
+	_currentRecordingID   int
+	_currentRecordingLock sync.Mutex
+)
+
+// _recordingsLock serializes publishing finished recordings with deleting them.
+var _recordingsLock sync.Mutex
+
+// startRecording will begin archiving the highest quality variant of the current broadcast.
+func startRecording() {
+	if !data.GetRecordingEnabled() {
+		return
+	}
+
+	id, err := data.InsertRecording(data.GetStreamTitle(), time.Now())
+	if err != nil {
+		log.Errorln("unable to create recording", err)
+		return
+	}
+
+	variantIndex := data.FindHighestVideoQualityIndex(_currentBroadcast.OutputSettings)
+	recorder, err := transcoder.NewHLSRecorder(getRecordingDirectory(id), variantIndex)
+	if err != nil {
+		log.Errorln("unable to start recording", err)
+		return
+	}
+
+	_currentRecordingLock.Lock()
+	defer _currentRecordingLock.Unlock()
+
+	_currentRecordingID = id
+	handler.SetRecorder(recorder)
+}
+
+// stopRecording will finalize the recording of the current broadcast, if one exists.
+func stopRecording() {
+	_currentRecordingLock.Lock()
+	id := _currentRecordingID
+	_currentRecordingLock.Unlock()
+
+	stopRecordingIfCurrent(id)
+}
+
+// stopRecordingIfCurrent will finalize the recording with id, if it is the
+// one in progress.
+func stopRecordingIfCurrent(id int) {
+	_currentRecordingLock.Lock()
+	recorder := handler.GetRecorder()
+	if recorder == nil || id != _currentRecordingID {
+		_currentRecordingLock.Unlock()
+		return
+	}
+
+	handler.SetRecorder(nil)
+	_currentRecordingID = 0
+	_currentRecordingLock.Unlock()
+
+	duration, size, err := recorder.Finish()
+	if err != nil {
+		log.Errorln("unable to finish recording", err)
+	}
+
+	if err := data.SetRecordingCompleted(id, time.Now(), duration, size); err != nil {
+		log.Errorln("unable to save recording", err)
+	}
+
+	go func() {
+		if err := publishRecording(id); err != nil {
+			log.Errorln("unable to publish recording", id, err)
+		}
+	}()
+}
+
+// closeInterruptedRecordings will end the recordings that were still in
+// progress when the server last stopped, keeping the segments recorded
+// before it did.
+func closeInterruptedRecordings() {
+	recordings, err := data.GetRecordings()
+	if err != nil {
+		log.Errorln("unable to read recordings", err)
+		return
+	}
+
+	for _, recording := range recordings {
+		if !recording.IsInProgress() {
+			continue
+		}
+
+		log.Warnln("Ending recording", recording.ID, "that was interrupted when the server stopped.")
+
+		directory := getRecordingDirectory(recording.ID)
+		endTime := recording.StartTime
+		var duration float64
+
+		recordingPlaylist, err := readRecordingPlaylist(directory)
+		if err == nil {
+			for _, segment := range recordingPlaylist.Segments {
+				if segment != nil {
+					duration += segment.Duration
+				}
+			}
+
+			if info, err := os.Stat(filepath.Join(directory, transcoder.RecordingPlaylistFilename)); err == nil {
+				endTime = info.ModTime()
+			}
+		}
+
+		size, _ := utils.GetDirectorySize(directory)
+
+		if err := data.SetRecordingCompleted(recording.ID, endTime, duration, size); err != nil {
+			log.Errorln("unable to save recording", recording.ID, err)
+			continue
+		}
+
+		// Nothing was recorded before the server stopped.
+		if recordingPlaylist == nil {
+			continue
+		}
+
+		go func(id int) {
+			if err := publishRecording(id); err != nil {
+				log.Errorln("unable to publish recording", id, err)
+			}
+		}(recording.ID)
+	}
+}
+
+// readRecordingPlaylist will return the playlist of the segments recorded
+// in directory.
+func readRecordingPlaylist(directory string) (*m3u8.MediaPlaylist, error) {
+	f, err := os.Open(filepath.Join(directory, transcoder.RecordingPlaylistFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil {
+		return nil, err
+	}
+	if listType != m3u8.MEDIA {
+		return nil, errors.New("recording playlist is not a media playlist")
+	}
+
+	return p.(*m3u8.MediaPlaylist), nil
+}
+
+// publishRecording will save the segments of a finished recording using the
+// storage provider and write a VOD playlist referencing them.
+func publishRecording(id int) error {
+	_recordingsLock.Lock()
+	defer _recordingsLock.Unlock()
+
+	// The recording may have been deleted before it could be published.
+	if _, err := data.GetRecording(id); err != nil {
+		log.Debugln("not publishing removed recording", id)
+		return nil
+	}
+
+	directory := getRecordingDirectory(id)
+
+	recordingPlaylist, err := readRecordingPlaylist(directory)
+	if err != nil {
+		return err
+	}
+
+	capacity := recordingPlaylist.Count()
+	if capacity == 0 {
+		capacity = 1
+	}
+
+	vodPlaylist, err := m3u8.NewMediaPlaylist(0, capacity)
+	if err != nil {
+		return err
+	}
+
+	if recordingPlaylist.Map != nil {
+		uri, err := _storage.SaveVODSegment(filepath.Join(directory, filepath.Base(recordingPlaylist.Map.URI)), id)
+		if err != nil {
+			return err
+		}
+		vodPlaylist.SetDefaultMap(uri, 0, 0)
+	}
+
+	for _, segment := range recordingPlaylist.Segments {
+		if segment == nil {
+			continue
+		}
+
+		uri, err := _storage.SaveVODSegment(filepath.Join(directory, filepath.Base(segment.URI)), id)
+		if err != nil {
+			return err
+		}
+
+		if err := vodPlaylist.Append(uri, segment.Duration, ""); err != nil {
+			return err
+		}
+	}
+
+	vodPlaylist.MediaType = m3u8.VOD
+	vodPlaylist.Close()
+
+	return playlist.WritePlaylist(vodPlaylist.String(), filepath.Join(directory, vodPlaylistFilename))
+}
+
+// GetVODPlaylistPath will return the local path of a published recording's VOD playlist.
+func GetVODPlaylistPath(id int) (string, error) {
+	playlistPath := filepath.Join(getRecordingDirectory(id), vodPlaylistFilename)
+	if _, err := os.Stat(playlistPath); err != nil {
+		return "", err
+	}
+
+	return playlistPath, nil
+}
+
+// GetVODSegmentPath will return the local path of a single segment of a recording.
+func GetVODSegmentPath(id int, segment string) (string, error) {
+	isSegment := utils.IsVideoSegment(segment) || filepath.Ext(segment) == ".mp4"
+	if !isSegment || segment != filepath.Base(segment) {
+		return "", errors.New("invalid segment " + segment)
+	}
+
+	segmentPath := filepath.Join(getRecordingDirectory(id), segment)
+	if _, err := os.Stat(segmentPath); err != nil {
+		return "", err
+	}
+
+	return segmentPath, nil
+}
+
+// GetRecordingSegmentFiles will return the ordered list of video segments for a recording.
+func GetRecordingSegmentFiles(id int) ([]string, error) {
+	if _, err := data.GetRecording(id); err != nil {
+		return nil, err
+	}
+
+	return transcoder.GetRecordedSegmentFiles(getRecordingDirectory(id))
+}
+
+// DeleteRecording will remove a recording and all its files, including
+// any segments saved by the storage provider.
+func DeleteRecording(id int) error {
+	stopRecordingIfCurrent(id)
+
+	_recordingsLock.Lock()
+	defer _recordingsLock.Unlock()
+
+	if err := data.DeleteRecording(id); err != nil {
+		return err
+	}
+
+	if err := _storage.DeleteVODSegments(id); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(getRecordingDirectory(id))
+}
+
+func getRecordingDirectory(id int) string {
+	return filepath.Join(config.RecordingsStoragePath, strconv.Itoa(id))
+}
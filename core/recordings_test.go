@@ -0,0 +1,189 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/transcoder"
+)
+
+type testVODStorage struct {
+	saved   map[int][]string
+	deleted []int
+}
+
+func (s *testVODStorage) Setup() error { return nil }
+func (s *testVODStorage) Save(filePath string, retryCount int) (string, error) {
+	return filePath, nil
+}
+func (s *testVODStorage) SaveVODSegment(localFilePath string, recordingID int) (string, error) {
+	s.saved[recordingID] = append(s.saved[recordingID], filepath.Base(localFilePath))
+	return "https://vod.example.com/" + filepath.Base(localFilePath), nil
+}
+func (s *testVODStorage) DeleteVODSegments(recordingID int) error {
+	s.deleted = append(s.deleted, recordingID)
+	return nil
+}
+func (s *testVODStorage) SegmentWritten(localFilePath string)         {}
+func (s *testVODStorage) VariantPlaylistWritten(localFilePath string) {}
+func (s *testVODStorage) MasterPlaylistWritten(localFilePath string)  {}
+func (s *testVODStorage) DASHManifestWritten(localFilePath string)    {}
+
+func TestMain(m *testing.M) {
+	directory, err := ioutil.TempDir("", "owncast-core")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := data.SetupPersistence(filepath.Join(directory, "test.db")); err != nil {
+		panic(err)
+	}
+	config.RecordingsStoragePath = filepath.Join(directory, "recordings")
+
+	code := m.Run()
+	os.RemoveAll(directory)
+	os.Exit(code)
+}
+
+func writeTestRecording(t *testing.T, segments ...string) int {
+	id, err := data.InsertRecording("test recording", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := getRecordingDirectory(id)
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:0\n"
+	for _, segment := range segments {
+		if err := ioutil.WriteFile(filepath.Join(directory, segment), []byte(segment), 0600); err != nil {
+			t.Fatal(err)
+		}
+		playlist += "#EXTINF:4.000,\n" + segment + "\n"
+	}
+	playlist += "#EXT-X-ENDLIST\n"
+
+	if err := ioutil.WriteFile(filepath.Join(directory, transcoder.RecordingPlaylistFilename), []byte(playlist), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func TestPublishRecording(t *testing.T) {
+	storage := &testVODStorage{saved: map[int][]string{}}
+	_storage = storage
+
+	id := writeTestRecording(t, "stream-1.ts", "stream-2.ts")
+	if err := publishRecording(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.saved[id]) != 2 {
+		t.Errorf("expected 2 segments to be saved, got %v", storage.saved[id])
+	}
+
+	playlistPath, err := GetVODPlaylistPath(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	playlist, err := ioutil.ReadFile(playlistPath) // nolint
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(playlist), "https://vod.example.com/stream-2.ts") {
+		t.Errorf("expected the VOD playlist to reference the saved segments, got %s", playlist)
+	}
+	if !strings.Contains(string(playlist), "#EXT-X-ENDLIST") {
+		t.Error("expected the VOD playlist to be complete")
+	}
+
+	if _, err := GetVODSegmentPath(id, "stream-1.ts"); err != nil {
+		t.Error(err)
+	}
+	if _, err := GetVODSegmentPath(id, "../stream-1.ts"); err == nil {
+		t.Error("expected a segment outside of the recording to be rejected")
+	}
+	if _, err := GetVODSegmentPath(id, "stream.m3u8"); err == nil {
+		t.Error("expected a non-segment file to be rejected")
+	}
+}
+
+func TestDeleteRecording(t *testing.T) {
+	storage := &testVODStorage{saved: map[int][]string{}}
+	_storage = storage
+
+	id := writeTestRecording(t, "stream-1.ts")
+	if err := publishRecording(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteRecording(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.deleted) != 1 || storage.deleted[0] != id {
+		t.Errorf("expected the saved segments of recording %d to be deleted, got %v", id, storage.deleted)
+	}
+	if _, err := os.Stat(getRecordingDirectory(id)); !os.IsNotExist(err) {
+		t.Error("expected the recording directory to be removed")
+	}
+	if _, err := data.GetRecording(id); err == nil {
+		t.Error("expected the recording to be removed")
+	}
+}
+
+func TestPublishDeletedRecording(t *testing.T) {
+	storage := &testVODStorage{saved: map[int][]string{}}
+	_storage = storage
+
+	id := writeTestRecording(t, "stream-1.ts")
+	if err := data.DeleteRecording(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := publishRecording(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.saved[id]) != 0 {
+		t.Errorf("expected nothing to be saved for a deleted recording, got %v", storage.saved[id])
+	}
+}
+
+func TestCloseInterruptedRecordings(t *testing.T) {
+	storage := &testVODStorage{saved: map[int][]string{}}
+	_storage = storage
+
+	id := writeTestRecording(t, "stream-1.ts", "stream-2.ts")
+	closeInterruptedRecordings()
+
+	recording, err := data.GetRecording(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.IsInProgress() {
+		t.Fatal("expected the interrupted recording to be ended")
+	}
+	if recording.Duration != 8 {
+		t.Errorf("expected the recorded segments to be kept, got a duration of %f", recording.Duration)
+	}
+
+	// The recorded segments are published in the background.
+	for i := 0; i < 50; i++ {
+		if _, err := GetVODPlaylistPath(id); err == nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Error("expected the interrupted recording to be published")
+}
@@ -0,0 +1,320 @@
+package restream
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nareix/joy5/av"
+	"github.com/nareix/joy5/format/rtmp"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/models"
+)
+
+// How many packets can be waiting to be sent before new ones are dropped.
+const packetQueueSize = 1024
+
+// How long a single write can block before the connection is seen as failed.
+const writeTimeout = 10 * time.Second
+
+const defaultReconnectDelay = 5
+
+type destination struct {
+	config  models.RestreamDestination
+	packets chan av.Packet
+	done    chan struct{}
+
+	status models.RestreamStatus
+	lock   sync.Mutex
+}
+
+func newDestination(config models.RestreamDestination) *destination {
+	return &destination{
+		config:  config,
+		packets: make(chan av.Packet, packetQueueSize),
+		done:    make(chan struct{}),
+		status: models.RestreamStatus{
+			ID:    config.ID,
+			Name:  config.Name,
+			State: models.RestreamConnecting,
+		},
+	}
+}
+
+func (d *destination) enqueue(pkt av.Packet) {
+	select {
+	case d.packets <- pkt:
+	default:
+		// The destination is not keeping up so drop the packet instead of
+		// holding up the inbound stream.
+	}
+}
+
+func (d *destination) stop() {
+	close(d.done)
+}
+
+func (d *destination) isStopped() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *destination) getStatus() models.RestreamStatus {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.status
+}
+
+func (d *destination) setState(state string, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.status.State = state
+	d.status.Error = ""
+	if err != nil {
+		d.status.Error = redactURL(err.Error(), d.config.URL)
+	}
+
+	if state == models.RestreamLive {
+		now := time.Now()
+		d.status.ConnectedTime = &now
+	} else {
+		d.status.ConnectedTime = nil
+	}
+}
+
+// redactURL will remove the parts of destinationURL that can carry its
+// stream key from message, so the error can be shown to any admin.
+func redactURL(message string, destinationURL string) string {
+	const redacted = "[redacted]"
+
+	message = strings.ReplaceAll(message, destinationURL, redacted)
+
+	u, err := url.Parse(destinationURL)
+	if err != nil {
+		return message
+	}
+
+	secrets := []string{u.RawQuery, u.EscapedPath(), u.Path}
+	if u.User != nil {
+		secrets = append(secrets, u.User.String())
+	}
+	if index := strings.LastIndex(u.Path, "/"); index >= 0 {
+		secrets = append(secrets, u.Path[index+1:])
+	}
+
+	for _, secret := range secrets {
+		if secret != "" && secret != "/" {
+			message = strings.ReplaceAll(message, secret, redacted)
+		}
+	}
+
+	return message
+}
+
+func (d *destination) run(getConfigPackets func() []av.Packet) {
+	attempts := 0
+
+	for {
+		d.setState(models.RestreamConnecting, nil)
+
+		connected, err := d.send(getConfigPackets)
+		if d.isStopped() {
+			return
+		}
+
+		log.Warnln("Restream to", d.config.Name, "failed:", redactURL(err.Error(), d.config.URL))
+
+		// Only count consecutive failures towards the reconnect limit.
+		if connected {
+			attempts = 0
+		}
+		attempts++
+
+		if !d.config.Reconnect || (d.config.MaxReconnectAttempts > 0 && attempts > d.config.MaxReconnectAttempts) {
+			d.setState(models.RestreamFailed, err)
+			return
+		}
+
+		d.lock.Lock()
+		d.status.ReconnectCount++
+		d.lock.Unlock()
+		d.setState(models.RestreamReconnecting, err)
+
+		delay := d.config.ReconnectDelay
+		if delay <= 0 {
+			delay = defaultReconnectDelay
+		}
+
+		select {
+		case <-d.done:
+			return
+		case <-time.After(time.Duration(delay) * time.Second):
+		}
+	}
+}
+
+// send will connect to the destination and write packets until stopped or
+// an error occurs. It returns if the connection was established.
+func (d *destination) send(getConfigPackets func() []av.Packet) (bool, error) {
+	conn, err := dial(d.config.URL)
+	if err != nil {
+		return false, err
+	}
+	defer conn.close()
+
+	// Anything queued while disconnected is stale.
+	d.drain()
+
+	configPackets := getConfigPackets()
+	hasVideo := false
+	for _, pkt := range configPackets {
+		if pkt.Type == av.H264DecoderConfig {
+			hasVideo = true
+		}
+		pkt.Time = 0
+		if err := conn.writePacket(pkt); err != nil {
+			return false, err
+		}
+	}
+
+	d.setState(models.RestreamLive, nil)
+
+	// Timestamps are rebased so every connection starts at zero.
+	var offset time.Duration
+	started := false
+
+	for {
+		select {
+		case <-d.done:
+			return true, nil
+		case pkt := <-d.packets:
+			if isConfigPacket(pkt) {
+				if pkt.Type == av.H264DecoderConfig {
+					hasVideo = true
+				}
+				if err := conn.writePacket(pkt); err != nil {
+					return true, err
+				}
+				continue
+			}
+
+			if !started {
+				// Start at a keyframe so the destination can decode from the first packet.
+				if hasVideo && (pkt.Type != av.H264 || !pkt.IsKeyFrame) {
+					continue
+				}
+				started = true
+				offset = pkt.Time
+			}
+
+			pkt.Time -= offset
+			if pkt.Time < 0 {
+				pkt.Time = 0
+			}
+
+			if err := conn.writePacket(pkt); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+func (d *destination) drain() {
+	for {
+		select {
+		case <-d.packets:
+		default:
+			return
+		}
+	}
+}
+
+// connection is an outbound rtmp connection that flushes after every packet
+// so audio and video are not held back in the write buffer.
+type connection struct {
+	conn   *rtmp.Conn
+	nc     net.Conn
+	writer *bufio.Writer
+}
+
+type connectionReadWriter struct {
+	*bufio.Reader
+	*bufio.Writer
+}
+
+func (c *connection) writePacket(pkt av.Packet) error {
+	if err := c.nc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+
+	if err := c.conn.WritePacket(pkt); err != nil {
+		return err
+	}
+
+	return c.writer.Flush()
+}
+
+func (c *connection) close() {
+	c.nc.Close()
+}
+
+func dial(destinationURL string) (*connection, error) {
+	u, err := url.Parse(destinationURL)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+
+	var nc net.Conn
+	switch u.Scheme {
+	case "rtmp":
+		nc, err = dialer.Dial("tcp", rtmp.UrlGetHost(u))
+	case "rtmps":
+		nc, err = tls.DialWithDialer(dialer, "tcp", rtmp.UrlGetHost(u), &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, errors.New("unsupported restream url scheme " + u.Scheme)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connection{
+		nc:     nc,
+		writer: bufio.NewWriterSize(nc, rtmp.BufioSize),
+	}
+	c.conn = rtmp.NewConn(&connectionReadWriter{
+		Reader: bufio.NewReaderSize(nc, rtmp.BufioSize),
+		Writer: c.writer,
+	})
+	c.conn.URL = u
+
+	// Complete the handshake and publish request.
+	if err := nc.SetDeadline(time.Now().Add(15 * time.Second)); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if err := c.conn.Prepare(rtmp.StageGotPublishOrPlayCommand, rtmp.PrepareWriting); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if err := nc.SetDeadline(time.Time{}); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,124 @@
+package restream
+
+import (
+	"sync"
+
+	"github.com/nareix/joy5/av"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+)
+
+var (
+	_destinations []*destination
+	_active       bool
+
+	// The most recent decoder configuration and metadata packets from the
+	// broadcaster. They are sent first to every new destination connection.
+	_configPackets = make(map[int]av.Packet)
+
+	_lock sync.Mutex
+)
+
+// Start will begin forwarding the inbound stream to the enabled destinations.
+// Packets are passed on with WritePacket, so only RTMP broadcasts are restreamed.
+func Start() {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	_active = true
+	_configPackets = make(map[int]av.Packet)
+	startDestinations()
+}
+
+// Stop will disconnect from all destinations.
+func Stop() {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	_active = false
+	stopDestinations()
+}
+
+// Reload will apply changes to the destinations while a stream is live.
+func Reload() {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	if !_active {
+		return
+	}
+
+	stopDestinations()
+	startDestinations()
+}
+
+// WritePacket will forward a single packet of the inbound stream to every destination.
+func WritePacket(pkt av.Packet) {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	if !_active {
+		return
+	}
+
+	if isConfigPacket(pkt) {
+		_configPackets[pkt.Type] = pkt
+	}
+
+	for _, d := range _destinations {
+		d.enqueue(pkt)
+	}
+}
+
+// GetStatus will return the state of each destination for the current stream.
+func GetStatus() []models.RestreamStatus {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	statuses := make([]models.RestreamStatus, 0, len(_destinations))
+	for _, d := range _destinations {
+		statuses = append(statuses, d.getStatus())
+	}
+
+	return statuses
+}
+
+func startDestinations() {
+	for _, config := range data.GetRestreamDestinations() {
+		if !config.Enabled {
+			continue
+		}
+
+		log.Infoln("Restreaming to", config.Name)
+		d := newDestination(config)
+		_destinations = append(_destinations, d)
+		go d.run(getConfigPackets)
+	}
+}
+
+func stopDestinations() {
+	for _, d := range _destinations {
+		d.stop()
+	}
+	_destinations = nil
+}
+
+func getConfigPackets() []av.Packet {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	packets := make([]av.Packet, 0, len(_configPackets))
+	for _, packetType := range []int{av.Metadata, av.H264DecoderConfig, av.AACDecoderConfig} {
+		if pkt, ok := _configPackets[packetType]; ok {
+			packets = append(packets, pkt)
+		}
+	}
+
+	return packets
+}
+
+func isConfigPacket(pkt av.Packet) bool {
+	return pkt.Type == av.H264DecoderConfig || pkt.Type == av.AACDecoderConfig || pkt.Type == av.Metadata
+}
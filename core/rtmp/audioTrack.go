@@ -0,0 +1,120 @@
+package rtmp
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/nareix/joy5/av"
+	"github.com/nareix/joy5/format/flv"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+)
+
+// audioTrackFeed is an inbound rtmp connection carrying an alternate audio
+// track of the live stream.
+type audioTrackFeed struct {
+	feed  *feed
+	pipe  *io.PipeWriter
+	muxer *flv.Muxer
+}
+
+// The connected alternate audio tracks, by their index in the config.
+var _audioTrackFeeds = make(map[int]*audioTrackFeed)
+
+var _setAudioTrackConnected func(int, *io.PipeReader)
+
+// audioTrackKeyMatch will return the index of the audio track the path has
+// the stream key of.
+func audioTrackKeyMatch(path string) (int, bool) {
+	for index, track := range data.GetAudioTracks() {
+		if secretMatch(track.StreamKey, path) {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+// handleAudioTrackConn will send the audio of an alternate audio track to
+// be transcoded while the stream is live.
+func handleAudioTrackConn(index int, f *feed) {
+	_lock.Lock()
+	if !_isStreamConnected() || _audioTrackFeeds[index] != nil {
+		_lock.Unlock()
+		log.Errorln("audio track can only be sent once while the stream is live; rejecting incoming stream")
+		f.nc.Close()
+		return
+	}
+
+	rtmpOut, rtmpIn := io.Pipe()
+	track := &audioTrackFeed{
+		feed:  f,
+		pipe:  rtmpIn,
+		muxer: flv.NewMuxer(rtmpIn),
+	}
+	_audioTrackFeeds[index] = track
+	_lock.Unlock()
+
+	log.Infof("Audio track %d connected.", index)
+	_setAudioTrackConnected(index, rtmpOut)
+
+	readAudioTrackFeed(index, track)
+}
+
+func readAudioTrackFeed(index int, track *audioTrackFeed) {
+	defer closeAudioTrackFeed(index, track)
+
+	for {
+		if err := track.feed.nc.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+			log.Debugln(err)
+		}
+
+		pkt, err := track.feed.conn.ReadPacket()
+		if err != nil {
+			if neterr, ok := err.(net.Error); ok && neterr.Timeout() {
+				log.Debugln("Timeout reading audio track", index, "from the broadcaster.  Assuming that they disconnected.")
+			}
+			return
+		}
+
+		// Only the audio of the track is used.
+		if pkt.Type != av.AAC && pkt.Type != av.AACDecoderConfig && pkt.Type != av.Metadata {
+			continue
+		}
+
+		if err := track.muxer.WritePacket(pkt); err != nil {
+			log.Debugln("unable to write audio track packet", err)
+			return
+		}
+	}
+}
+
+func closeAudioTrackFeed(index int, track *audioTrackFeed) {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	if _audioTrackFeeds[index] != track {
+		return
+	}
+
+	log.Infof("Audio track %d disconnected.", index)
+	disconnectAudioTrackFeed(index)
+}
+
+// disconnectAudioTrackFeed will close an audio track. The caller must hold _lock.
+func disconnectAudioTrackFeed(index int) {
+	track := _audioTrackFeeds[index]
+	track.feed.nc.Close()
+	track.pipe.Close()
+	delete(_audioTrackFeeds, index)
+}
+
+// disconnectAudioTrackFeeds will close every audio track, as they can't
+// continue without the stream. The caller must hold _lock.
+func disconnectAudioTrackFeeds() {
+	for index := range _audioTrackFeeds {
+		disconnectAudioTrackFeed(index)
+	}
+}
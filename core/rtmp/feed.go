@@ -0,0 +1,154 @@
+package rtmp
+
+import (
+	"net"
+	"time"
+
+	"github.com/nareix/joy5/av"
+	"github.com/nareix/joy5/format/flv/flvio"
+	"github.com/nareix/joy5/format/rtmp"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+)
+
+// The gap left between the last packet of a feed and the first packet of
+// the feed that replaces it, so timestamps keep increasing.
+const switchTimestampGap = 40 * time.Millisecond
+
+// How often a feed using an additional stream key checks the key can still be used.
+const streamKeyCheckInterval = 5 * time.Second
+
+// feed is an inbound rtmp connection. It is either the live feed being
+// transcoded or a standby feed waiting to take over from it.
+type feed struct {
+	conn     *rtmp.Conn
+	nc       net.Conn
+	isBackup bool
+	hasVideo bool
+	metadata *flvio.Tag
+
+	// The additional stream key the feed connected with, if any.
+	streamKey      string
+	streamKeyCheck time.Time
+
+	// The most recent decoder configuration packets, sent first when a
+	// standby feed is switched in.
+	configPackets map[int]av.Packet
+
+	// A feed that was switched in has its timestamps shifted to continue
+	// on from the feed it replaced.
+	switched   bool
+	sentConfig bool
+	started    bool
+	startTime  time.Duration
+	offset     time.Duration
+}
+
+func newFeed(c *rtmp.Conn, nc net.Conn) *feed {
+	return &feed{
+		conn:          c,
+		nc:            nc,
+		configPackets: make(map[int]av.Packet),
+	}
+}
+
+// markStreamKeyUsed will record that the additional stream key of an
+// accepted feed was used.
+func (f *feed) markStreamKeyUsed() {
+	if f.streamKey == "" {
+		return
+	}
+
+	if err := data.SetStreamKeyAsUsed(f.streamKey); err != nil {
+		log.Warnln("unable to update stream key last used time", err)
+	}
+}
+
+// hasValidStreamKey will return false once the additional stream key the
+// feed connected with has been revoked or has expired.
+func (f *feed) hasValidStreamKey(now time.Time) bool {
+	if f.streamKey == "" || now.Sub(f.streamKeyCheck) < streamKeyCheckInterval {
+		return true
+	}
+
+	f.streamKeyCheck = now
+	return data.IsValidAdditionalStreamKey(f.streamKey)
+}
+
+// holdPacket will keep the configuration from a standby feed and discard its media.
+func (f *feed) holdPacket(pkt av.Packet) {
+	if pkt.Type == av.H264DecoderConfig {
+		f.hasVideo = true
+	}
+
+	if isConfigPacket(pkt) {
+		f.configPackets[pkt.Type] = pkt
+	}
+}
+
+// getConfigPackets will return the held configuration packets in the order they are sent.
+func (f *feed) getConfigPackets() []av.Packet {
+	packets := make([]av.Packet, 0, len(f.configPackets))
+	for _, packetType := range []int{av.Metadata, av.H264DecoderConfig, av.AACDecoderConfig} {
+		if pkt, ok := f.configPackets[packetType]; ok {
+			packets = append(packets, pkt)
+		}
+	}
+
+	return packets
+}
+
+// preparePacket will return the packet to write for the live feed, and false
+// if it should be skipped. A feed that was switched in starts from a keyframe
+// and its timestamps continue on from lastTime.
+func (f *feed) preparePacket(pkt av.Packet, lastTime time.Duration) (av.Packet, bool) {
+	if pkt.Type == av.H264DecoderConfig {
+		f.hasVideo = true
+	}
+
+	// The configuration is sent again if the transcoder is restarted.
+	if isConfigPacket(pkt) {
+		f.configPackets[pkt.Type] = pkt
+	}
+
+	if !f.switched {
+		return pkt, true
+	}
+
+	if !f.started {
+		if isConfigPacket(pkt) {
+			pkt.Time = lastTime
+			return pkt, true
+		}
+
+		if f.hasVideo && (pkt.Type != av.H264 || !pkt.IsKeyFrame) {
+			return pkt, false
+		}
+
+		f.started = true
+		f.startTime = lastTime + switchTimestampGap
+		f.offset = f.startTime - pkt.Time
+	}
+
+	pkt.Time += f.offset
+
+	// Audio captured just before the keyframe would go back in time.
+	if pkt.Time < f.startTime && !isConfigPacket(pkt) {
+		return pkt, false
+	}
+
+	return pkt, true
+}
+
+// restart will start the feed again from its configuration and the next
+// keyframe, continuing on from the packets already sent.
+func (f *feed) restart() {
+	f.switched = true
+	f.sentConfig = false
+	f.started = false
+}
+
+func isConfigPacket(pkt av.Packet) bool {
+	return pkt.Type == av.H264DecoderConfig || pkt.Type == av.AACDecoderConfig || pkt.Type == av.Metadata
+}
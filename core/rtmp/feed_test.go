@@ -0,0 +1,99 @@
+package rtmp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nareix/joy5/av"
+
+	"github.com/owncast/owncast/core/data"
+)
+
+func TestMain(m *testing.M) {
+	directory, err := ioutil.TempDir("", "owncast-rtmp")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := data.SetupPersistence(filepath.Join(directory, "test.db")); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(directory)
+	os.Exit(code)
+}
+
+func TestSwitchedFeedTimestamps(t *testing.T) {
+	f := newFeed(nil, nil)
+	f.holdPacket(av.Packet{Type: av.H264DecoderConfig})
+	f.switched = true
+
+	lastTime := 10 * time.Second
+
+	if _, ok := f.preparePacket(av.Packet{Type: av.H264, Time: 50 * time.Second}, lastTime); ok {
+		t.Error("expected the feed to wait for a keyframe")
+	}
+
+	pkt, ok := f.preparePacket(av.Packet{Type: av.H264, IsKeyFrame: true, Time: 51 * time.Second}, lastTime)
+	if !ok || pkt.Time != lastTime+switchTimestampGap {
+		t.Errorf("expected the keyframe to continue on from %s, got %s", lastTime, pkt.Time)
+	}
+
+	if _, ok := f.preparePacket(av.Packet{Type: av.AAC, Time: 51*time.Second - time.Millisecond}, lastTime); ok {
+		t.Error("expected audio from before the keyframe to be skipped")
+	}
+
+	pkt, ok = f.preparePacket(av.Packet{Type: av.AAC, Time: 52 * time.Second}, lastTime)
+	if !ok || pkt.Time != lastTime+switchTimestampGap+time.Second {
+		t.Errorf("expected audio to be shifted to %s, got %s", lastTime+switchTimestampGap+time.Second, pkt.Time)
+	}
+}
+
+func TestLiveFeedTimestampsUnchanged(t *testing.T) {
+	f := newFeed(nil, nil)
+
+	pkt, ok := f.preparePacket(av.Packet{Type: av.H264, Time: 5 * time.Second}, 10*time.Second)
+	if !ok || pkt.Time != 5*time.Second {
+		t.Errorf("expected the timestamp to be unchanged, got %s", pkt.Time)
+	}
+}
+
+func TestFeedStreamKeyRevoked(t *testing.T) {
+	const streamKey = "test-feed-stream-key"
+
+	if err := data.InsertStreamKey(streamKey, "feed", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFeed(nil, nil)
+	f.streamKey = streamKey
+	f.markStreamKeyUsed()
+
+	key, err := data.GetAdditionalStreamKey(streamKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.LastUsed == nil {
+		t.Error("expected the stream key to be marked as used")
+	}
+
+	now := time.Now()
+	if !f.hasValidStreamKey(now) {
+		t.Error("expected the stream key to be valid")
+	}
+
+	if err := data.DeleteStreamKey(streamKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.hasValidStreamKey(now.Add(time.Second)) {
+		t.Error("expected the stream key to not be checked again so soon")
+	}
+	if f.hasValidStreamKey(now.Add(streamKeyCheckInterval)) {
+		t.Error("expected the revoked stream key to be invalid")
+	}
+}
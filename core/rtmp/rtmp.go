@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
 	"time"
 
+	"github.com/nareix/joy5/av"
 	"github.com/nareix/joy5/format/flv"
 	"github.com/nareix/joy5/format/flv/flvio"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/nareix/joy5/format/rtmp"
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/restream"
 	"github.com/owncast/owncast/models"
 )
 
@@ -19,19 +22,31 @@ var (
 	_hasInboundRTMPConnection = false
 )
 
+var (
+	_liveFeed       *feed
+	_standbyFeed    *feed
+	_muxer          *flv.Muxer
+	_lastPacketTime time.Duration
+	_lock           sync.Mutex
+)
+
 var _pipe *io.PipeWriter
-var _rtmpConnection net.Conn
+var _server *rtmp.Server
 
 var _setStreamAsConnected func(*io.PipeReader)
 var _setBroadcaster func(models.Broadcaster)
+var _isStreamConnected func() bool
 
 // Start starts the rtmp service, listening on specified RTMP port.
-func Start(setStreamAsConnected func(*io.PipeReader), setBroadcaster func(models.Broadcaster)) {
+func Start(setStreamAsConnected func(*io.PipeReader), setBroadcaster func(models.Broadcaster), isStreamConnected func() bool, setAudioTrackConnected func(int, *io.PipeReader)) {
 	_setStreamAsConnected = setStreamAsConnected
+	_setAudioTrackConnected = setAudioTrackConnected
 	_setBroadcaster = setBroadcaster
+	_isStreamConnected = isStreamConnected
 
 	port := data.GetRTMPPortNumber()
 	s := rtmp.NewServer()
+	_server = s
 	var lis net.Listener
 	var err error
 	if lis, err = net.Listen("tcp", fmt.Sprintf(":%d", port)); err != nil {
@@ -50,6 +65,10 @@ func Start(setStreamAsConnected func(*io.PipeReader), setBroadcaster func(models
 	}
 	log.Tracef("RTMP server is listening for incoming stream on port: %d", port)
 
+	if err := StartTLS(); err != nil {
+		log.Errorln("unable to start the RTMPS server", err)
+	}
+
 	for {
 		nc, err := lis.Accept()
 		if err != nil {
@@ -61,85 +80,267 @@ func Start(setStreamAsConnected func(*io.PipeReader), setBroadcaster func(models
 }
 
 func HandleConn(c *rtmp.Conn, nc net.Conn) {
+	f := newFeed(c, nc)
+
 	c.LogTagEvent = func(isRead bool, t flvio.Tag) {
 		if t.Type == flvio.TAG_AMF0 {
 			log.Tracef("%+v\n", t.DebugFields())
-			setCurrentBroadcasterInfo(t, nc.RemoteAddr().String())
+			setFeedMetadata(f, t)
 		}
 	}
 
-	if _hasInboundRTMPConnection {
-		log.Errorln("stream already running; can not overtake an existing stream")
-		nc.Close()
+	if index, ok := audioTrackKeyMatch(c.URL.Path); ok {
+		handleAudioTrackConn(index, f)
 		return
 	}
 
-	if !secretMatch(data.GetStreamKey(), c.URL.Path) {
-		log.Errorln("invalid streaming key; rejecting incoming stream")
-		nc.Close()
-		return
+	f.isBackup = backupKeyMatch(c.URL.Path)
+	if !f.isBackup && !secretMatch(data.GetStreamKey(), c.URL.Path) {
+		streamKey, ok := additionalKeyMatch(c.URL.Path)
+		if !ok {
+			log.Errorln("invalid streaming key; rejecting incoming stream")
+			nc.Close()
+			return
+		}
+		f.streamKey = streamKey
 	}
 
-  rtmpOut, rtmpIn := io.Pipe()
-  _pipe = rtmpIn
-	log.Infoln("Inbound stream connected.")
-	_setStreamAsConnected(rtmpOut)
+	_lock.Lock()
+	if f.isBackup || _hasInboundRTMPConnection || _isStreamConnected() {
+		held := holdAsStandby(f)
+		_lock.Unlock()
 
-	_hasInboundRTMPConnection = true
-	_rtmpConnection = nc
+		if !held {
+			log.Errorln("stream already running; can not overtake an existing stream")
+			nc.Close()
+			return
+		}
 
-	w := flv.NewMuxer(rtmpIn)
+		log.Infoln("Standby stream connected.  It will take over if the inbound stream is lost.")
+	} else {
+		rtmpOut, rtmpIn := io.Pipe()
+		_pipe = rtmpIn
+		_muxer = flv.NewMuxer(rtmpIn)
+		_liveFeed = f
+		_lastPacketTime = 0
+		_hasInboundRTMPConnection = true
+		_lock.Unlock()
 
+		log.Infoln("Inbound stream connected.")
+		_setStreamAsConnected(rtmpOut)
+	}
+
+	f.markStreamKeyUsed()
+	readFeed(f)
+}
+
+// holdAsStandby will keep a connection waiting to take over from the live
+// feed, returning false if it can not be held.
+func holdAsStandby(f *feed) bool {
+	if data.GetBackupStreamKey() == "" || _standbyFeed != nil {
+		return false
+	}
+
+	// Only a backup can wait for a primary, or a primary for a backup.
+	if !f.isBackup && (_liveFeed == nil || !_liveFeed.isBackup) {
+		return false
+	}
+
+	_standbyFeed = f
+	return true
+}
+
+func readFeed(f *feed) {
 	for {
-		if !_hasInboundRTMPConnection {
-			break
+		if !f.hasValidStreamKey(time.Now()) {
+			log.Errorln("stream key has been revoked or has expired; disconnecting the stream")
+			handleFeedDisconnect(f)
+			return
 		}
 
 		// If we don't get a readable packet in 10 seconds give up and disconnect
-		if err := _rtmpConnection.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		if err := f.nc.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
 			log.Debugln(err)
 		}
 
-		pkt, err := c.ReadPacket()
+		pkt, err := f.conn.ReadPacket()
+		if err != nil {
+			// Read timeout.  Disconnect.
+			if neterr, ok := err.(net.Error); ok && neterr.Timeout() {
+				log.Debugln("Timeout reading the inbound stream from the broadcaster.  Assuming that they disconnected.")
+			}
 
-		// Broadcaster disconnected
-		if err == io.EOF {
-			handleDisconnect(nc)
+			handleFeedDisconnect(f)
 			return
 		}
 
-		// Read timeout.  Disconnect.
-		if neterr, ok := err.(net.Error); ok && neterr.Timeout() {
-			log.Debugln("Timeout reading the inbound stream from the broadcaster.  Assuming that they disconnected and ending the stream.")
-			handleDisconnect(nc)
+		if !writeFeedPacket(f, pkt) {
 			return
 		}
+	}
+}
+
+// writeFeedPacket will send a packet of the live feed to the transcoder and
+// hold on to the configuration of a standby feed. It returns false once the
+// feed is no longer needed.
+func writeFeedPacket(f *feed, pkt av.Packet) bool {
+	_lock.Lock()
+	if f == _standbyFeed {
+		f.holdPacket(pkt)
+		_lock.Unlock()
+		return true
+	}
+
+	if f != _liveFeed {
+		_lock.Unlock()
+		f.nc.Close()
+		return false
+	}
+
+	var packets []av.Packet
+
+	// A feed that was just switched in starts with the configuration it held.
+	if f.switched && !f.sentConfig {
+		f.sentConfig = true
+		for _, configPacket := range f.getConfigPackets() {
+			configPacket, _ = f.preparePacket(configPacket, _lastPacketTime)
+			packets = append(packets, configPacket)
+		}
+	}
+
+	if pkt, ok := f.preparePacket(pkt, _lastPacketTime); ok {
+		packets = append(packets, pkt)
+	}
+
+	muxer := _muxer
+	_lock.Unlock()
+
+	// The lock is not held while writing as the transcoder may be slow to read.
+	for _, pkt := range packets {
+		if err := muxer.WritePacket(pkt); err != nil {
+			_lock.Lock()
+			defer _lock.Unlock()
+
+			// The transcoder was restarted with a new feed.
+			if muxer != _muxer {
+				return f == _liveFeed
+			}
 
-		if err := w.WritePacket(pkt); err != nil {
 			log.Errorln("unable to write rtmp packet", err)
-			handleDisconnect(nc)
+			if f == _liveFeed {
+				handleDisconnect()
+			}
+			return false
+		}
+
+		restream.WritePacket(pkt)
+
+		_lock.Lock()
+		if pkt.Time > _lastPacketTime {
+			_lastPacketTime = pkt.Time
+		}
+		_lock.Unlock()
+	}
+
+	return true
+}
+
+// handleFeedDisconnect will switch to the standby feed when the live feed is
+// lost, only ending the stream if there is nothing to switch to.
+func handleFeedDisconnect(f *feed) {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	f.nc.Close()
+
+	switch f {
+	case _standbyFeed:
+		log.Infoln("Standby stream disconnected.")
+		_standbyFeed = nil
+	case _liveFeed:
+		if _standbyFeed == nil {
+			handleDisconnect()
 			return
 		}
+
+		switchToStandby()
+	}
+}
+
+func switchToStandby() {
+	next := _standbyFeed
+	_standbyFeed = nil
+	_liveFeed = next
+	next.switched = true
+
+	log.Infoln("Inbound stream lost.  Switched to the standby stream.")
+
+	if next.metadata != nil {
+		setCurrentBroadcasterInfo(*next.metadata, next.nc.RemoteAddr().String())
 	}
 }
 
-func handleDisconnect(conn net.Conn) {
+func setFeedMetadata(f *feed, t flvio.Tag) {
+	_lock.Lock()
+	f.metadata = &t
+	isLive := f == _liveFeed
+	_lock.Unlock()
+
+	if isLive {
+		setCurrentBroadcasterInfo(t, f.nc.RemoteAddr().String())
+	}
+}
+
+// handleDisconnect will end the stream. The caller must hold _lock.
+func handleDisconnect() {
 	if !_hasInboundRTMPConnection {
 		return
 	}
 
 	log.Infoln("Inbound stream disconnected.")
-	conn.Close()
+	_liveFeed.nc.Close()
+	_liveFeed = nil
 	_pipe.Close()
 	_hasInboundRTMPConnection = false
+	disconnectAudioTrackFeeds()
 }
 
-// Disconnect will force disconnect the current inbound RTMP connection.
+// RestartFeed will return a new feed of the inbound stream for a restarted
+// transcoder, and false if the stream has ended.
+func RestartFeed() (*io.PipeReader, bool) {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	if !_hasInboundRTMPConnection || _liveFeed == nil {
+		return nil, false
+	}
+
+	rtmpOut, rtmpIn := io.Pipe()
+	_pipe.Close()
+	_pipe = rtmpIn
+	_muxer = flv.NewMuxer(rtmpIn)
+	_liveFeed.restart()
+
+	return rtmpOut, true
+}
+
+// Disconnect will force disconnect the current inbound RTMP connection,
+// along with any standby connection.
 func Disconnect() {
-	if _rtmpConnection == nil {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	if _standbyFeed != nil {
+		_standbyFeed.nc.Close()
+		_standbyFeed = nil
+	}
+
+	disconnectAudioTrackFeeds()
+
+	if _liveFeed == nil {
 		return
 	}
 
 	log.Traceln("Inbound stream disconnect requested.")
-	handleDisconnect(_rtmpConnection)
+	handleDisconnect()
 }
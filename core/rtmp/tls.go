@@ -0,0 +1,155 @@
+package rtmp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+)
+
+var (
+	_tlsListener net.Listener
+	_tlsLock     sync.Mutex
+)
+
+// StartTLS will start, restart or stop the RTMPS listener so it matches
+// the current configuration.
+func StartTLS() error {
+	_tlsLock.Lock()
+	defer _tlsLock.Unlock()
+
+	if _tlsListener != nil {
+		_tlsListener.Close()
+		_tlsListener = nil
+	}
+
+	config := data.GetRTMPSConfig()
+	if !config.Enabled {
+		return nil
+	}
+
+	if _server == nil {
+		return errors.New("the rtmp server has not been started")
+	}
+
+	certificates := newCertificateLoader(config.CertificatePath, config.KeyPath)
+
+	// Fail now instead of on the first connection if the certificate is invalid.
+	if _, err := certificates.getCertificate(nil); err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
+	if err != nil {
+		return err
+	}
+
+	_tlsListener = tls.NewListener(lis, &tls.Config{
+		GetCertificate: certificates.getCertificate,
+		MinVersion:     tls.VersionTLS12,
+	})
+
+	log.Infof("RTMPS is accepting inbound streams on port %d.", config.Port)
+	go serveTLS(_tlsListener)
+
+	return nil
+}
+
+func serveTLS(lis net.Listener) {
+	for {
+		nc, err := lis.Accept()
+		if err != nil {
+			_tlsLock.Lock()
+			replaced := _tlsListener != lis
+			_tlsLock.Unlock()
+
+			// The listener was closed to restart or stop it.
+			if replaced {
+				return
+			}
+
+			time.Sleep(time.Second)
+			continue
+		}
+		go _server.HandleNetConn(nc)
+	}
+}
+
+// certificateLoader will load the certificate from disk, loading it again
+// whenever the files change so renewed certificates are used without a restart.
+type certificateLoader struct {
+	certificatePath string
+	keyPath         string
+	certificate     *tls.Certificate
+	modTime         time.Time
+	lock            sync.Mutex
+}
+
+func newCertificateLoader(certificatePath string, keyPath string) *certificateLoader {
+	return &certificateLoader{
+		certificatePath: certificatePath,
+		keyPath:         keyPath,
+	}
+}
+
+func (l *certificateLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	modTime, err := l.getModTime()
+	if err != nil {
+		if l.certificate != nil {
+			log.Warnln("unable to check the RTMPS certificate for changes", err)
+			return l.certificate, nil
+		}
+		return nil, err
+	}
+
+	if l.certificate != nil && !modTime.After(l.modTime) {
+		return l.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(l.certificatePath, l.keyPath)
+	if err != nil {
+		// Keep using the previous certificate in case the files are mid-update.
+		if l.certificate != nil {
+			log.Warnln("unable to reload the RTMPS certificate", err)
+			return l.certificate, nil
+		}
+		return nil, err
+	}
+
+	if l.certificate != nil {
+		log.Infoln("Reloaded the RTMPS certificate.")
+	}
+
+	l.certificate = &certificate
+	l.modTime = modTime
+
+	return l.certificate, nil
+}
+
+// getModTime will return when the certificate or key was most recently changed.
+func (l *certificateLoader) getModTime() (time.Time, error) {
+	var modTime time.Time
+
+	for _, path := range []string{l.certificatePath, l.keyPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
@@ -0,0 +1,101 @@
+package rtmp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certificatePath string, keyPath string, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(certificatePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{certificatePath, keyPath} {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func getCommonName(t *testing.T, l *certificateLoader) string {
+	certificate, err := l.getCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed.Subject.CommonName
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "owncast-rtmps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certificatePath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	now := time.Now()
+
+	writeTestCertificate(t, certificatePath, keyPath, "first", now.Add(-time.Hour))
+	l := newCertificateLoader(certificatePath, keyPath)
+	if name := getCommonName(t, l); name != "first" {
+		t.Fatalf("expected the first certificate, got %s", name)
+	}
+
+	writeTestCertificate(t, certificatePath, keyPath, "renewed", now)
+	if name := getCommonName(t, l); name != "renewed" {
+		t.Fatalf("expected the renewed certificate, got %s", name)
+	}
+
+	// A broken certificate should not replace the one that is working.
+	if err := ioutil.WriteFile(keyPath, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(keyPath, now.Add(time.Hour), now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if name := getCommonName(t, l); name != "renewed" {
+		t.Fatalf("expected the renewed certificate to still be used, got %s", name)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/nareix/joy5/format/flv/flvio"
+	"github.com/owncast/owncast/core/data"
 	"github.com/owncast/owncast/models"
 	log "github.com/sirupsen/logrus"
 )
@@ -57,7 +58,11 @@ func getAudioCodec(codec interface{}) string {
 }
 
 func getVideoCodec(codec interface{}) string {
+	// Only a radio stream is expected to not describe its video.
 	if codec == nil {
+		if data.GetRadioModeEnabled() {
+			return "No video"
+		}
 		return unknownString
 	}
 
@@ -89,3 +94,26 @@ func secretMatch(configStreamKey string, path string) bool {
 	streamingKey := path[len(prefix):] // Remove $prefix
 	return streamingKey == configStreamKey
 }
+
+// additionalKeyMatch will return the additional, unexpired, stream key the
+// path contains, if any.
+func additionalKeyMatch(path string) (string, bool) {
+	prefix := "/live/"
+
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+
+	streamingKey := path[len(prefix):]
+	if streamingKey == "" || !data.IsValidAdditionalStreamKey(streamingKey) {
+		return "", false
+	}
+
+	return streamingKey, true
+}
+
+// backupKeyMatch will return if the path contains the backup stream key.
+func backupKeyMatch(path string) bool {
+	backupKey := data.GetBackupStreamKey()
+	return backupKey != "" && secretMatch(backupKey, path)
+}
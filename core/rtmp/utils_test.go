@@ -1,6 +1,10 @@
 package rtmp
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/owncast/owncast/core/data"
+)
 
 func Test_secretMatch(t *testing.T) {
 	tests := []struct {
@@ -33,3 +37,18 @@ func Test_secretMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVideoCodecWithoutVideo(t *testing.T) {
+	if codec := getVideoCodec(nil); codec != unknownString {
+		t.Errorf("expected a stream without video details to be %s, got %s", unknownString, codec)
+	}
+
+	if err := data.SetRadioModeEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	defer data.SetRadioModeEnabled(false) //nolint
+
+	if codec := getVideoCodec(nil); codec != "No video" {
+		t.Errorf("expected a radio stream to have no video, got %s", codec)
+	}
+}
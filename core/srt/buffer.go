@@ -0,0 +1,184 @@
+package srt
+
+import (
+	"sort"
+	"time"
+)
+
+// The most packets that are held waiting for a lost packet to be
+// retransmitted. The buffer never spans more sequence numbers than this.
+const maxBufferedPackets = 8192
+
+type bufferedPacket struct {
+	payload []byte
+	arrival time.Time
+}
+
+// receiveBuffer will put packets back in order and hold them for the latency
+// window so that lost packets have a chance to be retransmitted.
+type receiveBuffer struct {
+	next     uint32
+	largest  uint32
+	latency  time.Duration
+	packets  map[uint32]bufferedPacket
+	received int
+}
+
+func newReceiveBuffer(initialSequence uint32, latency time.Duration) *receiveBuffer {
+	return &receiveBuffer{
+		next:    initialSequence,
+		largest: seqAdd(initialSequence, sequenceMask),
+		latency: latency,
+		packets: make(map[uint32]bufferedPacket),
+	}
+}
+
+// push will add a packet to the buffer. It returns any payloads that can
+// now be delivered in order and the sequence numbers found to be missing.
+func (b *receiveBuffer) push(sequence uint32, payload []byte, now time.Time) ([][]byte, []uint32) {
+	if seqDiff(sequence, b.next) < 0 {
+		return nil, nil
+	}
+	if _, exists := b.packets[sequence]; exists {
+		return nil, nil
+	}
+
+	// The packets too far behind this one to be waited for are lost.
+	var delivered [][]byte
+	if seqDiff(sequence, b.next) >= maxBufferedPackets {
+		delivered = b.skipTo(seqSub(sequence, maxBufferedPackets-1))
+	}
+
+	var lost []uint32
+	if gap := seqDiff(sequence, b.largest); gap > 1 {
+		lost = []uint32{seqAdd(b.largest, 1), seqAdd(sequence, sequenceMask)}
+	}
+	if seqDiff(sequence, b.largest) > 0 {
+		b.largest = sequence
+	}
+
+	b.packets[sequence] = bufferedPacket{payload: payload, arrival: now}
+	b.received++
+
+	delivered = append(delivered, b.deliver()...)
+
+	// Give up on the missing packets rather than growing without bound.
+	if len(b.packets) > maxBufferedPackets {
+		delivered = append(delivered, b.skipToFirstBuffered()...)
+	}
+
+	return delivered, lost
+}
+
+// expire will skip over missing packets once the packets after them
+// have been waiting for longer than the latency window.
+func (b *receiveBuffer) expire(now time.Time) [][]byte {
+	first, ok := b.firstBuffered()
+	if !ok || now.Sub(b.packets[first].arrival) < b.latency {
+		return nil
+	}
+
+	return b.skipToFirstBuffered()
+}
+
+// drop will stop waiting for packets the sender will not retransmit.
+func (b *receiveBuffer) drop(first uint32, last uint32) [][]byte {
+	if seqDiff(b.next, first) < 0 || seqDiff(b.next, last) > 0 || seqDiff(last, b.next) >= maxBufferedPackets {
+		return nil
+	}
+
+	for seqDiff(b.next, last) <= 0 {
+		delete(b.packets, b.next)
+		b.next = seqAdd(b.next, 1)
+	}
+	if seqDiff(b.next, b.largest) > 1 {
+		b.largest = seqAdd(b.next, sequenceMask)
+	}
+
+	return b.deliver()
+}
+
+// missing will return the ranges of packets that have not arrived yet.
+func (b *receiveBuffer) missing() [][2]uint32 {
+	var ranges [][2]uint32
+
+	for seq := b.next; seqDiff(seq, b.largest) < 0; seq = seqAdd(seq, 1) {
+		if _, ok := b.packets[seq]; ok {
+			continue
+		}
+
+		if n := len(ranges); n > 0 && ranges[n-1][1] == seqAdd(seq, sequenceMask) {
+			ranges[n-1][1] = seq
+		} else {
+			ranges = append(ranges, [2]uint32{seq, seq})
+		}
+	}
+
+	return ranges
+}
+
+func (b *receiveBuffer) deliver() [][]byte {
+	var delivered [][]byte
+
+	for {
+		p, ok := b.packets[b.next]
+		if !ok {
+			return delivered
+		}
+
+		delivered = append(delivered, p.payload)
+		delete(b.packets, b.next)
+		b.next = seqAdd(b.next, 1)
+	}
+}
+
+func (b *receiveBuffer) firstBuffered() (uint32, bool) {
+	if len(b.packets) == 0 {
+		return 0, false
+	}
+
+	for seq := b.next; seqDiff(seq, b.largest) <= 0; seq = seqAdd(seq, 1) {
+		if _, ok := b.packets[seq]; ok {
+			return seq, true
+		}
+	}
+
+	return 0, false
+}
+
+// skipTo will give up on the packets before sequence, delivering the ones
+// that did arrive in order.
+func (b *receiveBuffer) skipTo(sequence uint32) [][]byte {
+	var skipped []uint32
+	for seq := range b.packets {
+		if seqDiff(seq, sequence) < 0 {
+			skipped = append(skipped, seq)
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return seqDiff(skipped[i], skipped[j]) < 0
+	})
+
+	delivered := make([][]byte, 0, len(skipped))
+	for _, seq := range skipped {
+		delivered = append(delivered, b.packets[seq].payload)
+		delete(b.packets, seq)
+	}
+
+	b.next = sequence
+	if seqDiff(b.next, b.largest) > 1 {
+		b.largest = seqSub(b.next, 1)
+	}
+
+	return append(delivered, b.deliver()...)
+}
+
+func (b *receiveBuffer) skipToFirstBuffered() [][]byte {
+	first, ok := b.firstBuffered()
+	if !ok {
+		return nil
+	}
+
+	b.next = first
+	return b.deliver()
+}
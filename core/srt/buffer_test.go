@@ -0,0 +1,132 @@
+package srt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReceiveBufferMissingRanges(t *testing.T) {
+	now := time.Now()
+	b := newReceiveBuffer(0, 100*time.Millisecond)
+
+	b.push(0, []byte{0}, now)
+	b.push(2, []byte{2}, now)
+	b.push(5, []byte{5}, now)
+
+	missing := b.missing()
+	if len(missing) != 2 || missing[0] != [2]uint32{1, 1} || missing[1] != [2]uint32{3, 4} {
+		t.Errorf("expected 1 and 3-4 to be missing, got %v", missing)
+	}
+}
+
+func TestReceiveBufferSequenceWraps(t *testing.T) {
+	now := time.Now()
+	b := newReceiveBuffer(sequenceMask, 100*time.Millisecond)
+
+	if delivered, _ := b.push(sequenceMask, []byte{0}, now); len(delivered) != 1 {
+		t.Fatalf("expected the last sequence number to be delivered, got %d", len(delivered))
+	}
+
+	delivered, lost := b.push(1, []byte{2}, now)
+	if len(delivered) != 0 || lost[0] != 0 || lost[1] != 0 {
+		t.Fatalf("expected 0 to be lost after wrapping, got %v", lost)
+	}
+
+	delivered, _ = b.push(0, []byte{1}, now)
+	if len(delivered) != 2 || delivered[0][0] != 1 || delivered[1][0] != 2 {
+		t.Fatalf("expected 0 and 1 to be delivered in order, got %v", delivered)
+	}
+}
+
+func TestReceiveBufferExpiresInOrder(t *testing.T) {
+	now := time.Now()
+	b := newReceiveBuffer(0, 100*time.Millisecond)
+
+	b.push(0, []byte{0}, now)
+	b.push(3, []byte{3}, now)
+	b.push(2, []byte{2}, now.Add(20*time.Millisecond))
+	b.push(6, []byte{6}, now.Add(40*time.Millisecond))
+
+	// 1 is skipped once 2 has waited for the latency window, while 4-5
+	// are still waited for.
+	if delivered := b.expire(now.Add(110 * time.Millisecond)); len(delivered) != 0 {
+		t.Fatal("packets were skipped before the latency window passed")
+	}
+
+	delivered := b.expire(now.Add(125 * time.Millisecond))
+	if len(delivered) != 2 || delivered[0][0] != 2 || delivered[1][0] != 3 {
+		t.Fatalf("expected 2 and 3 to be delivered, got %v", delivered)
+	}
+
+	if missing := b.missing(); len(missing) != 1 || missing[0] != [2]uint32{4, 5} {
+		t.Fatalf("expected 4-5 to be missing, got %v", missing)
+	}
+
+	delivered = b.expire(now.Add(150 * time.Millisecond))
+	if len(delivered) != 1 || delivered[0][0] != 6 {
+		t.Fatalf("expected 6 to be delivered, got %v", delivered)
+	}
+}
+
+func TestReceiveBufferDrop(t *testing.T) {
+	now := time.Now()
+	b := newReceiveBuffer(100, time.Second)
+
+	b.push(100, []byte{0}, now)
+	b.push(104, []byte{4}, now)
+
+	// Requests that do not start at the next packet are ignored.
+	if delivered := b.drop(102, 103); len(delivered) != 0 || b.next != 101 {
+		t.Fatalf("expected a drop after a missing packet to be ignored, got %v", delivered)
+	}
+
+	delivered := b.drop(101, 103)
+	if len(delivered) != 1 || delivered[0][0] != 4 {
+		t.Fatalf("expected 104 to be delivered, got %v", delivered)
+	}
+
+	if missing := b.missing(); len(missing) != 0 {
+		t.Errorf("expected nothing to be missing, got %v", missing)
+	}
+}
+
+func TestReceiveBufferDropOutsideWindow(t *testing.T) {
+	b := newReceiveBuffer(100, time.Second)
+	b.push(100, []byte{0}, time.Now())
+
+	if delivered := b.drop(101, 101+maxBufferedPackets); len(delivered) != 0 || b.next != 101 {
+		t.Errorf("expected a drop past the window to be ignored, next is %d", b.next)
+	}
+}
+
+func TestReceiveBufferFarAhead(t *testing.T) {
+	now := time.Now()
+	b := newReceiveBuffer(100, time.Second)
+
+	b.push(100, []byte{0}, now)
+	b.push(102, []byte{2}, now)
+
+	// Too far ahead to be a sequence number after the ones received.
+	if delivered, lost := b.push(101+1<<30, []byte{1}, now); delivered != nil || lost != nil || len(b.packets) != 1 {
+		t.Fatal("expected a packet outside the sequence space to be ignored")
+	}
+
+	// The window skips ahead, delivering what arrived before it.
+	sequence := uint32(100 + 1<<29)
+	delivered, lost := b.push(sequence, []byte{3}, now)
+	if len(delivered) != 1 || delivered[0][0] != 2 {
+		t.Fatalf("expected 102 to be delivered, got %v", delivered)
+	}
+	if seqDiff(lost[1], lost[0]) >= maxBufferedPackets {
+		t.Errorf("expected the lost packets to be within the window, got %v", lost)
+	}
+
+	if first := seqSub(sequence, maxBufferedPackets-1); b.next != first {
+		t.Errorf("expected the next packet to be %d, got %d", first, b.next)
+	}
+
+	missing := b.missing()
+	if len(missing) != 1 || seqDiff(missing[0][1], missing[0][0]) != maxBufferedPackets-2 {
+		t.Errorf("expected the missing packets to be within the window, got %v", missing)
+	}
+}
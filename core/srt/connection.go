@@ -0,0 +1,312 @@
+package srt
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+)
+
+const (
+	// How often received packets are acknowledged.
+	ackInterval = 10 * time.Millisecond
+	// The shortest time to wait before asking again for a lost packet.
+	minNAKInterval = 20 * time.Millisecond
+	// How often to let the caller know the connection is still alive.
+	keepaliveInterval = time.Second
+	// If nothing is received for this long the caller is assumed to be gone.
+	peerIdleTimeout = 10 * time.Second
+	// How many received payloads can be waiting to be written to the transcoder.
+	outputQueueSize = 4096
+	// How often a connection using an additional stream key checks the key can still be used.
+	streamKeyCheckInterval = 5 * time.Second
+)
+
+type connection struct {
+	listener     *net.UDPConn
+	addr         *net.UDPAddr
+	socketID     uint32
+	peerSocketID uint32
+	start        time.Time
+	conclusion   []byte
+	streamKey    string
+	lastKeyCheck time.Time
+	buffer       *receiveBuffer
+	packets      chan packet
+	output       chan []byte
+	done         chan struct{}
+	closeOnce    sync.Once
+	lastReceived time.Time
+	lastSent     time.Time
+	lastNAK      time.Time
+	ackNumber    uint32
+	ackTimes     map[uint32]time.Time
+	rtt          time.Duration
+	rttVariance  time.Duration
+	rateStart    time.Time
+	rateReceived int
+	rateBytes    int
+	packetRate   uint32
+	byteRate     uint32
+}
+
+func newConnection(listener *net.UDPConn, addr *net.UDPAddr, socketID uint32, peerSocketID uint32, initialSequence uint32, latency time.Duration) *connection {
+	now := time.Now()
+
+	return &connection{
+		listener:     listener,
+		addr:         addr,
+		socketID:     socketID,
+		peerSocketID: peerSocketID,
+		start:        now,
+		buffer:       newReceiveBuffer(initialSequence, latency),
+		packets:      make(chan packet, outputQueueSize),
+		output:       make(chan []byte, outputQueueSize),
+		done:         make(chan struct{}),
+		lastReceived: now,
+		rateStart:    now,
+		ackTimes:     make(map[uint32]time.Time),
+		rtt:          100 * time.Millisecond,
+		rttVariance:  50 * time.Millisecond,
+	}
+}
+
+// enqueue will pass a packet from the listener to the connection without
+// holding up the listener.
+func (c *connection) enqueue(p packet) {
+	select {
+	case c.packets <- p:
+	default:
+	}
+}
+
+// run will process packets from the caller until the connection ends.
+func (c *connection) run(w io.Writer) {
+	go c.write(w)
+
+	ticker := time.NewTicker(ackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case p := <-c.packets:
+			c.lastReceived = time.Now()
+			if p.isControl {
+				c.handleControlPacket(p)
+			} else {
+				c.handleDataPacket(p)
+			}
+		case now := <-ticker.C:
+			if now.Sub(c.lastReceived) > peerIdleTimeout {
+				log.Debugln("Timeout reading the inbound SRT stream from the broadcaster.  Assuming that they disconnected and ending the stream.")
+				c.close()
+				return
+			}
+
+			if !c.hasValidStreamKey(now) {
+				log.Errorln("stream key has been revoked or has expired; disconnecting the stream")
+				c.shutdown()
+				return
+			}
+
+			c.deliver(c.buffer.expire(now))
+			c.sendACK(now)
+			c.sendPeriodicNAK(now)
+
+			if now.Sub(c.lastSent) > keepaliveInterval {
+				c.send(controlKeepalive, 0, nil)
+			}
+		}
+	}
+}
+
+// hasValidStreamKey will return false once the additional stream key the
+// connection was accepted with has been revoked or has expired.
+func (c *connection) hasValidStreamKey(now time.Time) bool {
+	if c.streamKey == "" || now.Sub(c.lastKeyCheck) < streamKeyCheckInterval {
+		return true
+	}
+
+	c.lastKeyCheck = now
+	return data.IsValidAdditionalStreamKey(c.streamKey)
+}
+
+// write will pass the received stream to the transcoder.
+func (c *connection) write(w io.Writer) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case payload := <-c.output:
+			if _, err := w.Write(payload); err != nil {
+				log.Errorln("unable to write srt packet", err)
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (c *connection) handleDataPacket(p packet) {
+	if p.messageInfo&encryptionFlags != 0 {
+		// Encryption is rejected during the handshake so this is not expected.
+		return
+	}
+
+	c.rateReceived++
+	c.rateBytes += len(p.payload)
+
+	delivered, lost := c.buffer.push(p.sequence, p.payload, time.Now())
+	c.deliver(delivered)
+
+	if lost != nil {
+		c.sendNAK([][2]uint32{{lost[0], lost[1]}})
+	}
+}
+
+func (c *connection) handleControlPacket(p packet) {
+	switch p.controlType {
+	case controlHandshake:
+		// The caller did not get our response to its conclusion handshake.
+		if c.conclusion != nil {
+			c.sendRaw(c.conclusion)
+		}
+	case controlACKACK:
+		if sent, ok := c.ackTimes[p.typeSpecific]; ok {
+			c.updateRTT(time.Since(sent))
+			delete(c.ackTimes, p.typeSpecific)
+		}
+	case controlDropReq:
+		if len(p.payload) >= 8 {
+			first := binary.BigEndian.Uint32(p.payload[0:]) & sequenceMask
+			last := binary.BigEndian.Uint32(p.payload[4:]) & sequenceMask
+			c.deliver(c.buffer.drop(first, last))
+		}
+	case controlShutdown:
+		c.close()
+	}
+}
+
+func (c *connection) deliver(payloads [][]byte) {
+	for _, payload := range payloads {
+		select {
+		case c.output <- payload:
+		default:
+			log.Warnln("Inbound SRT stream is being received faster than it can be processed.  Dropping packets.")
+		}
+	}
+}
+
+func (c *connection) sendACK(now time.Time) {
+	if elapsed := now.Sub(c.rateStart); elapsed >= time.Second {
+		c.packetRate = uint32(float64(c.rateReceived) / elapsed.Seconds())
+		c.byteRate = uint32(float64(c.rateBytes) / elapsed.Seconds())
+		c.rateStart = now
+		c.rateReceived = 0
+		c.rateBytes = 0
+	}
+
+	cif := make([]byte, 28)
+	binary.BigEndian.PutUint32(cif[0:], c.buffer.next)
+	binary.BigEndian.PutUint32(cif[4:], uint32(c.rtt.Microseconds()))
+	binary.BigEndian.PutUint32(cif[8:], uint32(c.rttVariance.Microseconds()))
+	binary.BigEndian.PutUint32(cif[12:], uint32(maxBufferedPackets-len(c.buffer.packets)))
+	binary.BigEndian.PutUint32(cif[16:], c.packetRate)
+	binary.BigEndian.PutUint32(cif[20:], c.packetRate)
+	binary.BigEndian.PutUint32(cif[24:], c.byteRate)
+
+	c.ackNumber++
+	c.ackTimes[c.ackNumber] = now
+
+	// Forget acknowledgements the caller never answered.
+	for number, sent := range c.ackTimes {
+		if now.Sub(sent) > peerIdleTimeout {
+			delete(c.ackTimes, number)
+		}
+	}
+
+	c.send(controlACK, c.ackNumber, cif)
+}
+
+func (c *connection) sendPeriodicNAK(now time.Time) {
+	interval := c.rtt + 4*c.rttVariance
+	if interval < minNAKInterval {
+		interval = minNAKInterval
+	}
+
+	if now.Sub(c.lastNAK) < interval {
+		return
+	}
+
+	if missing := c.buffer.missing(); len(missing) > 0 {
+		c.sendNAK(missing)
+	}
+}
+
+func (c *connection) sendNAK(ranges [][2]uint32) {
+	cif := make([]byte, 0, len(ranges)*8)
+	word := make([]byte, 4)
+	for _, r := range ranges {
+		if r[0] == r[1] {
+			binary.BigEndian.PutUint32(word, r[0])
+			cif = append(cif, word...)
+			continue
+		}
+
+		binary.BigEndian.PutUint32(word, r[0]|lossRangeFlag)
+		cif = append(cif, word...)
+		binary.BigEndian.PutUint32(word, r[1])
+		cif = append(cif, word...)
+	}
+
+	c.lastNAK = time.Now()
+	c.send(controlNAK, 0, cif)
+}
+
+func (c *connection) updateRTT(sample time.Duration) {
+	diff := c.rtt - sample
+	if diff < 0 {
+		diff = -diff
+	}
+
+	c.rttVariance = (3*c.rttVariance + diff) / 4
+	c.rtt = (7*c.rtt + sample) / 8
+}
+
+func (c *connection) send(controlType uint16, typeSpecific uint32, cif []byte) {
+	c.sendRaw(marshalControlPacket(controlType, typeSpecific, c.timestamp(), c.peerSocketID, cif))
+}
+
+func (c *connection) sendRaw(b []byte) {
+	c.lastSent = time.Now()
+	if _, err := c.listener.WriteToUDP(b, c.addr); err != nil {
+		log.Debugln("unable to send srt packet", err)
+	}
+}
+
+func (c *connection) timestamp() uint32 {
+	return uint32(time.Since(c.start).Microseconds())
+}
+
+// close will end the connection. It is safe to call more than once.
+func (c *connection) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// shutdown will let the caller know the connection is being closed.
+func (c *connection) shutdown() {
+	b := marshalControlPacket(controlShutdown, 0, c.timestamp(), c.peerSocketID, nil)
+	if _, err := c.listener.WriteToUDP(b, c.addr); err != nil {
+		log.Debugln("unable to send srt packet", err)
+	}
+	c.close()
+}
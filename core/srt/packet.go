@@ -0,0 +1,272 @@
+package srt
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+const headerSize = 16
+
+// Control packet types.
+const (
+	controlHandshake = 0x0000
+	controlKeepalive = 0x0001
+	controlACK       = 0x0002
+	controlNAK       = 0x0003
+	controlShutdown  = 0x0005
+	controlACKACK    = 0x0006
+	controlDropReq   = 0x0007
+)
+
+// Handshake types. Values of 1000 and above are rejection reasons.
+const (
+	handshakeTypeInduction  = 0x00000001
+	handshakeTypeConclusion = 0xFFFFFFFF
+)
+
+// Rejection reasons sent back to a caller in place of the handshake type.
+const (
+	rejectBadSecret    = 1010
+	rejectUnauthorized = 1401
+	rejectConflict     = 1409
+)
+
+// Handshake extension types and the flags announcing them.
+const (
+	extensionHSReq = 1
+	extensionHSRsp = 2
+	extensionSID   = 5
+
+	extensionFlagHSReq = 0x1
+	extensionFlagKMReq = 0x2
+)
+
+// SRT options negotiated in the HSREQ and HSRSP extensions.
+const (
+	flagTSBPDSend    = 0x01
+	flagTSBPDReceive = 0x02
+	flagTLPacketDrop = 0x08
+	flagNAKReport    = 0x10
+	flagRexmit       = 0x20
+)
+
+const (
+	handshakeExtensionMagic = 0x4A17
+	srtVersion              = 0x00010401
+	sequenceMask            = 0x7FFFFFFF
+	lossRangeFlag           = 0x80000000
+	encryptionFlags         = 0x18000000
+)
+
+type packet struct {
+	isControl         bool
+	sequence          uint32
+	messageInfo       uint32
+	controlType       uint16
+	typeSpecific      uint32
+	timestamp         uint32
+	destinationSocket uint32
+	payload           []byte
+}
+
+func parsePacket(b []byte) (packet, error) {
+	if len(b) < headerSize {
+		return packet{}, errors.New("packet too short")
+	}
+
+	p := packet{
+		timestamp:         binary.BigEndian.Uint32(b[8:]),
+		destinationSocket: binary.BigEndian.Uint32(b[12:]),
+		payload:           b[headerSize:],
+	}
+
+	first := binary.BigEndian.Uint32(b[0:])
+	if first&0x80000000 != 0 {
+		p.isControl = true
+		p.controlType = uint16(first>>16) & 0x7FFF
+		p.typeSpecific = binary.BigEndian.Uint32(b[4:])
+	} else {
+		p.sequence = first
+		p.messageInfo = binary.BigEndian.Uint32(b[4:])
+	}
+
+	return p, nil
+}
+
+func marshalControlPacket(controlType uint16, typeSpecific uint32, timestamp uint32, destinationSocket uint32, cif []byte) []byte {
+	b := make([]byte, headerSize+len(cif))
+	binary.BigEndian.PutUint32(b[0:], 0x80000000|uint32(controlType)<<16)
+	binary.BigEndian.PutUint32(b[4:], typeSpecific)
+	binary.BigEndian.PutUint32(b[8:], timestamp)
+	binary.BigEndian.PutUint32(b[12:], destinationSocket)
+	copy(b[headerSize:], cif)
+
+	return b
+}
+
+type handshakeExtension struct {
+	extensionType uint16
+	content       []byte
+}
+
+type handshake struct {
+	version         uint32
+	encryptionField uint16
+	extensionField  uint16
+	initialSequence uint32
+	mtu             uint32
+	flowWindow      uint32
+	handshakeType   uint32
+	socketID        uint32
+	synCookie       uint32
+	peerIP          [16]byte
+	extensions      []handshakeExtension
+}
+
+const handshakeSize = 48
+
+func parseHandshake(cif []byte) (handshake, error) {
+	if len(cif) < handshakeSize {
+		return handshake{}, errors.New("handshake too short")
+	}
+
+	h := handshake{
+		version:         binary.BigEndian.Uint32(cif[0:]),
+		encryptionField: binary.BigEndian.Uint16(cif[4:]),
+		extensionField:  binary.BigEndian.Uint16(cif[6:]),
+		initialSequence: binary.BigEndian.Uint32(cif[8:]),
+		mtu:             binary.BigEndian.Uint32(cif[12:]),
+		flowWindow:      binary.BigEndian.Uint32(cif[16:]),
+		handshakeType:   binary.BigEndian.Uint32(cif[20:]),
+		socketID:        binary.BigEndian.Uint32(cif[24:]),
+		synCookie:       binary.BigEndian.Uint32(cif[28:]),
+	}
+	copy(h.peerIP[:], cif[32:48])
+
+	rest := cif[handshakeSize:]
+	for len(rest) >= 4 {
+		extensionType := binary.BigEndian.Uint16(rest[0:])
+		length := int(binary.BigEndian.Uint16(rest[2:])) * 4
+		rest = rest[4:]
+		if length > len(rest) {
+			return h, errors.New("handshake extension too long")
+		}
+
+		h.extensions = append(h.extensions, handshakeExtension{
+			extensionType: extensionType,
+			content:       rest[:length],
+		})
+		rest = rest[length:]
+	}
+
+	return h, nil
+}
+
+func (h handshake) marshal() []byte {
+	size := handshakeSize
+	for _, e := range h.extensions {
+		size += 4 + len(e.content)
+	}
+
+	b := make([]byte, size)
+	binary.BigEndian.PutUint32(b[0:], h.version)
+	binary.BigEndian.PutUint16(b[4:], h.encryptionField)
+	binary.BigEndian.PutUint16(b[6:], h.extensionField)
+	binary.BigEndian.PutUint32(b[8:], h.initialSequence)
+	binary.BigEndian.PutUint32(b[12:], h.mtu)
+	binary.BigEndian.PutUint32(b[16:], h.flowWindow)
+	binary.BigEndian.PutUint32(b[20:], h.handshakeType)
+	binary.BigEndian.PutUint32(b[24:], h.socketID)
+	binary.BigEndian.PutUint32(b[28:], h.synCookie)
+	copy(b[32:48], h.peerIP[:])
+
+	offset := handshakeSize
+	for _, e := range h.extensions {
+		binary.BigEndian.PutUint16(b[offset:], e.extensionType)
+		binary.BigEndian.PutUint16(b[offset+2:], uint16(len(e.content)/4))
+		copy(b[offset+4:], e.content)
+		offset += 4 + len(e.content)
+	}
+
+	return b
+}
+
+func (h handshake) getExtension(extensionType uint16) ([]byte, bool) {
+	for _, e := range h.extensions {
+		if e.extensionType == extensionType {
+			return e.content, true
+		}
+	}
+
+	return nil, false
+}
+
+// getStreamID will return the stream id sent by the caller. It is sent as
+// 32 bit words with the bytes of each word in reverse order.
+func (h handshake) getStreamID() string {
+	content, ok := h.getExtension(extensionSID)
+	if !ok {
+		return ""
+	}
+
+	streamID := make([]byte, 0, len(content))
+	for i := 0; i+4 <= len(content); i += 4 {
+		streamID = append(streamID, content[i+3], content[i+2], content[i+1], content[i])
+	}
+
+	for len(streamID) > 0 && streamID[len(streamID)-1] == 0 {
+		streamID = streamID[:len(streamID)-1]
+	}
+
+	return string(streamID)
+}
+
+// setStreamID will add the stream id extension in the same format it is read.
+func (h *handshake) setStreamID(streamID string) {
+	padded := []byte(streamID)
+	for len(padded)%4 != 0 {
+		padded = append(padded, 0)
+	}
+
+	content := make([]byte, len(padded))
+	for i := 0; i < len(padded); i += 4 {
+		content[i], content[i+1], content[i+2], content[i+3] = padded[i+3], padded[i+2], padded[i+1], padded[i]
+	}
+
+	h.extensions = append(h.extensions, handshakeExtension{extensionType: extensionSID, content: content})
+}
+
+func getPeerIP(addr *net.UDPAddr) [16]byte {
+	var peerIP [16]byte
+
+	// IPv4 addresses are sent in the first four bytes in little endian order.
+	if ip := addr.IP.To4(); ip != nil {
+		peerIP[0], peerIP[1], peerIP[2], peerIP[3] = ip[3], ip[2], ip[1], ip[0]
+		return peerIP
+	}
+
+	copy(peerIP[:], addr.IP.To16())
+	return peerIP
+}
+
+func newSRTOptions(flags uint32, receiveDelay uint16, sendDelay uint16) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b[0:], srtVersion)
+	binary.BigEndian.PutUint32(b[4:], flags)
+	binary.BigEndian.PutUint32(b[8:], uint32(receiveDelay)<<16|uint32(sendDelay))
+
+	return b
+}
+
+func seqDiff(a uint32, b uint32) int32 {
+	return int32((a-b)<<1) >> 1
+}
+
+func seqAdd(a uint32, n uint32) uint32 {
+	return (a + n) & sequenceMask
+}
+
+func seqSub(a uint32, n uint32) uint32 {
+	return (a - n) & sequenceMask
+}
@@ -0,0 +1,300 @@
+package srt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+)
+
+const (
+	// The latency used when the broadcaster asks for less.
+	defaultLatency = 120 * time.Millisecond
+	// How long a handshake cookie is valid for.
+	cookieLifetime = time.Minute
+	// Larger than any SRT packet sent over a standard MTU.
+	maxPacketSize = 1500
+)
+
+var (
+	_connection *connection
+	_pipe       *io.PipeWriter
+	_lock       sync.Mutex
+
+	_cookieSecret     = make([]byte, 32)
+	_listenerSocketID uint32
+)
+
+var _setStreamAsConnected func(*io.PipeReader)
+var _setBroadcaster func(models.Broadcaster)
+var _isStreamConnected func() bool
+
+// Start starts the srt service, listening on the specified SRT port.
+func Start(setStreamAsConnected func(*io.PipeReader), setBroadcaster func(models.Broadcaster), isStreamConnected func() bool) {
+	_setStreamAsConnected = setStreamAsConnected
+	_setBroadcaster = setBroadcaster
+	_isStreamConnected = isStreamConnected
+
+	if _, err := rand.Read(_cookieSecret); err != nil {
+		log.Errorln("unable to start the SRT server", err)
+		return
+	}
+	_listenerSocketID = newSocketID()
+
+	port := data.GetSRTPortNumber()
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	if err != nil {
+		log.Errorln("unable to start the SRT server", err)
+		return
+	}
+
+	log.Tracef("SRT server is listening for incoming stream on port: %d", port)
+
+	for {
+		buf := make([]byte, maxPacketSize)
+		n, addr, err := listener.ReadFromUDP(buf)
+		if err != nil {
+			time.Sleep(time.Second)
+			continue
+		}
+
+		p, err := parsePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		handlePacket(listener, addr, p)
+	}
+}
+
+func handlePacket(listener *net.UDPConn, addr *net.UDPAddr, p packet) {
+	_lock.Lock()
+	c := _connection
+	_lock.Unlock()
+
+	if c != nil && p.destinationSocket == c.socketID && addr.String() == c.addr.String() {
+		c.enqueue(p)
+		return
+	}
+
+	if !p.isControl || p.controlType != controlHandshake || p.destinationSocket != 0 {
+		return
+	}
+
+	h, err := parseHandshake(p.payload)
+	if err != nil {
+		log.Traceln("Invalid SRT handshake from", addr, err)
+		return
+	}
+
+	switch h.handshakeType {
+	case handshakeTypeInduction:
+		handleInduction(listener, addr, h)
+	case handshakeTypeConclusion:
+		handleConclusion(listener, addr, h)
+	}
+}
+
+// handleInduction will reply to the first handshake from a caller with
+// the cookie it must send back to connect.
+func handleInduction(listener *net.UDPConn, addr *net.UDPAddr, h handshake) {
+	response := handshake{
+		version:         5,
+		extensionField:  handshakeExtensionMagic,
+		initialSequence: h.initialSequence,
+		mtu:             h.mtu,
+		flowWindow:      h.flowWindow,
+		handshakeType:   handshakeTypeInduction,
+		socketID:        _listenerSocketID,
+		synCookie:       getSynCookie(addr, time.Now()),
+		peerIP:          getPeerIP(addr),
+	}
+
+	sendHandshake(listener, addr, h.socketID, response)
+}
+
+// handleConclusion will accept or reject a caller once it has told us
+// which stream it wants to publish to.
+func handleConclusion(listener *net.UDPConn, addr *net.UDPAddr, h handshake) {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	// The caller did not get our response and is trying again. The
+	// connection resends it so only its own goroutine writes to the caller.
+	if _connection != nil && _connection.peerSocketID == h.socketID && _connection.addr.String() == addr.String() {
+		_connection.enqueue(packet{isControl: true, controlType: controlHandshake})
+		return
+	}
+
+	if !isValidSynCookie(h.synCookie, addr, time.Now()) {
+		log.Traceln("Invalid SRT handshake cookie from", addr)
+		return
+	}
+
+	if h.version != 5 {
+		log.Errorln("unsupported SRT version; rejecting incoming stream")
+		return
+	}
+
+	hsreq, ok := h.getExtension(extensionHSReq)
+	if !ok || len(hsreq) < 12 {
+		log.Errorln("SRT caller is not in live mode; rejecting incoming stream")
+		return
+	}
+
+	if h.encryptionField != 0 || h.extensionField&extensionFlagKMReq != 0 {
+		log.Errorln("encrypted SRT streams are not supported; rejecting incoming stream")
+		rejectHandshake(listener, addr, h, rejectBadSecret)
+		return
+	}
+
+	streamKey, ok := streamKeyMatch(h.getStreamID())
+	if !ok {
+		log.Errorln("invalid streaming key; rejecting incoming stream")
+		rejectHandshake(listener, addr, h, rejectUnauthorized)
+		return
+	}
+
+	if _connection != nil || _isStreamConnected() {
+		log.Errorln("stream already running; can not overtake an existing stream")
+		rejectHandshake(listener, addr, h, rejectConflict)
+		return
+	}
+
+	latency := time.Duration(binary.BigEndian.Uint32(hsreq[8:])&0xFFFF) * time.Millisecond
+	if latency < defaultLatency {
+		latency = defaultLatency
+	}
+
+	c := newConnection(listener, addr, newSocketID(), h.socketID, h.initialSequence&sequenceMask, latency)
+	c.streamKey = streamKey
+
+	latencyMs := uint16(latency / time.Millisecond)
+	response := handshake{
+		version:         5,
+		extensionField:  extensionFlagHSReq,
+		initialSequence: h.initialSequence,
+		mtu:             h.mtu,
+		flowWindow:      h.flowWindow,
+		handshakeType:   handshakeTypeConclusion,
+		socketID:        c.socketID,
+		synCookie:       h.synCookie,
+		peerIP:          getPeerIP(addr),
+		extensions: []handshakeExtension{{
+			extensionType: extensionHSRsp,
+			content:       newSRTOptions(flagTSBPDSend|flagTSBPDReceive|flagTLPacketDrop|flagNAKReport|flagRexmit, latencyMs, latencyMs),
+		}},
+	}
+	c.conclusion = marshalControlPacket(controlHandshake, 0, 0, h.socketID, response.marshal())
+	c.sendRaw(c.conclusion)
+
+	srtOut, srtIn := io.Pipe()
+	_pipe = srtIn
+	_connection = c
+
+	log.Infoln("Inbound SRT stream connected.")
+	if streamKey != "" {
+		if err := data.SetStreamKeyAsUsed(streamKey); err != nil {
+			log.Warnln("unable to update stream key last used time", err)
+		}
+	}
+	_setStreamAsConnected(srtOut)
+
+	_setBroadcaster(models.Broadcaster{
+		RemoteAddr: addr.String(),
+		Time:       time.Now(),
+	})
+
+	go func() {
+		c.run(newStreamDetailsWriter(srtIn, addr.String()))
+		handleDisconnect(c)
+	}()
+}
+
+func rejectHandshake(listener *net.UDPConn, addr *net.UDPAddr, h handshake, reason uint32) {
+	response := handshake{
+		version:         5,
+		initialSequence: h.initialSequence,
+		mtu:             h.mtu,
+		flowWindow:      h.flowWindow,
+		handshakeType:   reason,
+		socketID:        _listenerSocketID,
+		synCookie:       h.synCookie,
+		peerIP:          getPeerIP(addr),
+	}
+
+	sendHandshake(listener, addr, h.socketID, response)
+}
+
+func sendHandshake(listener *net.UDPConn, addr *net.UDPAddr, destinationSocket uint32, h handshake) {
+	b := marshalControlPacket(controlHandshake, 0, 0, destinationSocket, h.marshal())
+	if _, err := listener.WriteToUDP(b, addr); err != nil {
+		log.Debugln("unable to send srt handshake", err)
+	}
+}
+
+func handleDisconnect(c *connection) {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	if _connection != c {
+		return
+	}
+
+	log.Infoln("Inbound stream disconnected.")
+	_pipe.Close()
+	_connection = nil
+}
+
+// IsConnected will return true if there is an inbound SRT connection.
+func IsConnected() bool {
+	_lock.Lock()
+	defer _lock.Unlock()
+
+	return _connection != nil
+}
+
+// Disconnect will force disconnect the current inbound SRT connection.
+func Disconnect() {
+	_lock.Lock()
+	c := _connection
+	_lock.Unlock()
+
+	if c == nil {
+		return
+	}
+
+	log.Traceln("Inbound stream disconnect requested.")
+	c.shutdown()
+}
+
+// getSynCookie will return a value the caller can only know by having
+// received our response from the address it is connecting from.
+func getSynCookie(addr *net.UDPAddr, now time.Time) uint32 {
+	bucket := now.Unix() / int64(cookieLifetime/time.Second)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%x%s%d", _cookieSecret, addr.String(), bucket)))
+
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+func isValidSynCookie(cookie uint32, addr *net.UDPAddr, now time.Time) bool {
+	return cookie == getSynCookie(addr, now) || cookie == getSynCookie(addr, now.Add(-cookieLifetime))
+}
+
+func newSocketID() uint32 {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return uint32(time.Now().UnixNano())
+	}
+
+	return binary.BigEndian.Uint32(b) | 1
+}
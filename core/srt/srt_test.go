@@ -0,0 +1,269 @@
+package srt
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
+)
+
+const testStreamKey = "test-srt-stream-key"
+
+func TestMain(m *testing.M) {
+	directory, err := ioutil.TempDir("", "owncast-srt")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := data.SetupPersistence(filepath.Join(directory, "test.db")); err != nil {
+		panic(err)
+	}
+	if err := data.SetStreamKey(testStreamKey); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(directory)
+	os.Exit(code)
+}
+
+// newTestSockets will return a listener and a caller connected to it.
+func newTestSockets(t *testing.T) (*net.UDPConn, *net.UDPConn) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	return listener, newTestCaller(t, listener)
+}
+
+func newTestCaller(t *testing.T, listener *net.UDPConn) *net.UDPConn {
+	caller, err := net.DialUDP("udp", nil, listener.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { caller.Close() })
+
+	return caller
+}
+
+func readTestPacket(t *testing.T, caller *net.UDPConn) packet {
+	t.Helper()
+	if err := caller.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, maxPacketSize)
+	n, err := caller.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := parsePacket(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func readTestHandshake(t *testing.T, caller *net.UDPConn) handshake {
+	p := readTestPacket(t, caller)
+
+	// Skip the acknowledgements of a running connection.
+	for p.isControl && p.controlType == controlACK {
+		p = readTestPacket(t, caller)
+	}
+
+	if !p.isControl || p.controlType != controlHandshake {
+		t.Fatalf("expected a handshake, got %+v", p)
+	}
+
+	h, err := parseHandshake(p.payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+// connectTestCaller will send the induction and conclusion handshakes for
+// the stream id and return the response to the conclusion.
+func connectTestCaller(t *testing.T, listener *net.UDPConn, caller *net.UDPConn, streamID string) handshake {
+	addr := caller.LocalAddr().(*net.UDPAddr)
+
+	handlePacket(listener, addr, packet{
+		isControl:   true,
+		controlType: controlHandshake,
+		payload: handshake{
+			version:         4,
+			initialSequence: 100,
+			handshakeType:   handshakeTypeInduction,
+			socketID:        7,
+		}.marshal(),
+	})
+
+	induction := readTestHandshake(t, caller)
+	if induction.handshakeType != handshakeTypeInduction || induction.extensionField != handshakeExtensionMagic {
+		t.Fatalf("unexpected induction response %+v", induction)
+	}
+
+	conclusion := handshake{
+		version:         5,
+		extensionField:  extensionFlagHSReq,
+		initialSequence: 100,
+		handshakeType:   handshakeTypeConclusion,
+		socketID:        7,
+		synCookie:       induction.synCookie,
+		extensions: []handshakeExtension{{
+			extensionType: extensionHSReq,
+			content:       newSRTOptions(flagTSBPDSend, 0, 0),
+		}},
+	}
+	conclusion.setStreamID(streamID)
+
+	handlePacket(listener, addr, packet{
+		isControl:   true,
+		controlType: controlHandshake,
+		payload:     conclusion.marshal(),
+	})
+
+	return readTestHandshake(t, caller)
+}
+
+func setupTestServer(t *testing.T) chan *io.PipeReader {
+	connected := make(chan *io.PipeReader, 1)
+	_setStreamAsConnected = func(r *io.PipeReader) { connected <- r }
+	_setBroadcaster = func(models.Broadcaster) {}
+	_isStreamConnected = func() bool { return false }
+	_listenerSocketID = newSocketID()
+
+	t.Cleanup(func() {
+		Disconnect()
+		for deadline := time.Now().Add(5 * time.Second); IsConnected() && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	return connected
+}
+
+func TestHandshake(t *testing.T) {
+	connected := setupTestServer(t)
+	listener, caller := newTestSockets(t)
+
+	response := connectTestCaller(t, listener, caller, "#!::r="+testStreamKey+",m=publish")
+	if response.handshakeType != handshakeTypeConclusion {
+		t.Fatalf("expected the caller to be accepted, got handshake type %d", response.handshakeType)
+	}
+
+	options, ok := response.getExtension(extensionHSRsp)
+	if !ok || len(options) != 12 {
+		t.Fatal("expected the negotiated options in the response")
+	}
+	if latency := time.Duration(binary.BigEndian.Uint32(options[8:])>>16) * time.Millisecond; latency != defaultLatency {
+		t.Errorf("expected the default latency of %s, got %s", defaultLatency, latency)
+	}
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the stream to be set as connected")
+	}
+
+	// A second caller can not take over the stream.
+	other := newTestCaller(t, listener)
+	if rejected := connectTestCaller(t, listener, other, testStreamKey); rejected.handshakeType != rejectConflict {
+		t.Errorf("expected a second caller to be rejected, got handshake type %d", rejected.handshakeType)
+	}
+}
+
+func TestHandshakeRetry(t *testing.T) {
+	setupTestServer(t)
+	listener, caller := newTestSockets(t)
+
+	streamID := "#!::r=" + testStreamKey + ",m=publish"
+	response := connectTestCaller(t, listener, caller, streamID)
+
+	// The caller did not get the response and sends its conclusion again
+	// while the connection is running.
+	time.Sleep(5 * ackInterval)
+	retry := connectTestCaller(t, listener, caller, streamID)
+	if retry.handshakeType != handshakeTypeConclusion || retry.socketID != response.socketID {
+		t.Errorf("expected the response to be sent again, got %+v", retry)
+	}
+}
+
+func TestHandshakeInvalidStreamKey(t *testing.T) {
+	setupTestServer(t)
+	listener, caller := newTestSockets(t)
+
+	response := connectTestCaller(t, listener, caller, "#!::r=wrong-key,m=publish")
+	if response.handshakeType != rejectUnauthorized {
+		t.Errorf("expected the caller to be unauthorized, got handshake type %d", response.handshakeType)
+	}
+
+	if IsConnected() {
+		t.Error("expected the caller to not be connected")
+	}
+}
+
+func TestConnectionNAK(t *testing.T) {
+	listener, caller := newTestSockets(t)
+	c := newConnection(listener, caller.LocalAddr().(*net.UDPAddr), 1, 7, 100, defaultLatency)
+
+	c.handleDataPacket(packet{sequence: 100, payload: []byte{0}})
+	c.handleDataPacket(packet{sequence: 103, payload: []byte{3}})
+
+	// The gap is reported as a range as soon as it is seen.
+	p := readTestPacket(t, caller)
+	if p.controlType != controlNAK || p.destinationSocket != 7 || len(p.payload) != 8 {
+		t.Fatalf("expected a loss report, got %+v", p)
+	}
+	if first, last := binary.BigEndian.Uint32(p.payload[0:]), binary.BigEndian.Uint32(p.payload[4:]); first != 101|lossRangeFlag || last != 102 {
+		t.Errorf("expected 101-102 to be reported lost, got %x-%x", first, last)
+	}
+
+	// Once 101 arrives only 102 is reported.
+	c.handleDataPacket(packet{sequence: 101, payload: []byte{1}})
+	c.sendPeriodicNAK(time.Now().Add(time.Second))
+
+	p = readTestPacket(t, caller)
+	if p.controlType != controlNAK || len(p.payload) != 4 || binary.BigEndian.Uint32(p.payload) != 102 {
+		t.Errorf("expected 102 to be reported lost, got %+v", p)
+	}
+}
+
+func TestConnectionDropRequest(t *testing.T) {
+	listener, caller := newTestSockets(t)
+	c := newConnection(listener, caller.LocalAddr().(*net.UDPAddr), 1, 7, 100, defaultLatency)
+
+	c.handleDataPacket(packet{sequence: 100, payload: []byte{0}})
+	c.handleDataPacket(packet{sequence: 103, payload: []byte{3}})
+
+	if payload := <-c.output; payload[0] != 0 {
+		t.Fatalf("expected 100 to be delivered, got %v", payload)
+	}
+
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:], 101)
+	binary.BigEndian.PutUint32(request[4:], 102)
+	c.handleControlPacket(packet{isControl: true, controlType: controlDropReq, payload: request})
+
+	select {
+	case payload := <-c.output:
+		if payload[0] != 3 {
+			t.Errorf("expected 103 to be delivered, got %v", payload)
+		}
+	default:
+		t.Error("expected 103 to be delivered once 101-102 were dropped")
+	}
+}
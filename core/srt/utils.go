@@ -0,0 +1,143 @@
+package srt
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/mpegts"
+	"github.com/owncast/owncast/models"
+)
+
+const unknownString = "Unknown"
+
+// MPEG-TS stream types for the codecs we can report.
+var videoStreamTypes = map[byte]string{
+	0x1B: "H.264",
+	0x24: "H.265",
+}
+
+var audioStreamTypes = map[byte]string{
+	0x03: "MP3",
+	0x04: "MP3",
+	0x0F: "AAC",
+	0x11: "AAC",
+	0x81: "AC-3",
+}
+
+// getStreamKeyFromStreamID will return the stream key from a streamid that is
+// either the key itself or in the SRT access control format, #!::r=key,m=publish
+func getStreamKeyFromStreamID(streamID string) string {
+	if !strings.HasPrefix(streamID, "#!::") {
+		return streamID
+	}
+
+	for _, pair := range strings.Split(strings.TrimPrefix(streamID, "#!::"), ",") {
+		if strings.HasPrefix(pair, "r=") {
+			return strings.TrimPrefix(pair, "r=")
+		}
+	}
+
+	return ""
+}
+
+// streamKeyMatch will return if the streamid contains the stream key or one
+// of the additional, unexpired, stream keys, along with the additional key.
+func streamKeyMatch(streamID string) (string, bool) {
+	streamingKey := getStreamKeyFromStreamID(streamID)
+	if streamingKey == "" {
+		return "", false
+	}
+
+	if streamingKey == data.GetStreamKey() {
+		return "", true
+	}
+
+	if !data.IsValidAdditionalStreamKey(streamingKey) {
+		return "", false
+	}
+
+	return streamingKey, true
+}
+
+// streamDetailsWriter passes the stream through while looking for the
+// program map table that describes the codecs being sent.
+type streamDetailsWriter struct {
+	writer     io.Writer
+	remoteAddr string
+	pmtPID     int
+	found      bool
+}
+
+func newStreamDetailsWriter(w io.Writer, remoteAddr string) *streamDetailsWriter {
+	return &streamDetailsWriter{
+		writer:     w,
+		remoteAddr: remoteAddr,
+		pmtPID:     -1,
+	}
+}
+
+func (w *streamDetailsWriter) Write(b []byte) (int, error) {
+	if !w.found {
+		if details, ok := w.inspect(b); ok {
+			w.found = true
+			_setBroadcaster(models.Broadcaster{
+				RemoteAddr:    w.remoteAddr,
+				Time:          time.Now(),
+				StreamDetails: details,
+			})
+		}
+	}
+
+	return w.writer.Write(b)
+}
+
+func (w *streamDetailsWriter) inspect(b []byte) (models.InboundStreamDetails, bool) {
+	for ; len(b) >= mpegts.PacketSize; b = b[mpegts.PacketSize:] {
+		pkt := b[:mpegts.PacketSize]
+
+		// Only packets that start a section are of interest.
+		if pkt[0] != mpegts.SyncByte || !mpegts.IsUnitStart(pkt) {
+			continue
+		}
+
+		section := mpegts.GetSection(pkt)
+		if section == nil {
+			continue
+		}
+
+		if pid := mpegts.GetPID(pkt); pid == 0 && w.pmtPID < 0 {
+			w.pmtPID = mpegts.GetPMTPID(section)
+		} else if pid == w.pmtPID {
+			return getStreamDetailsFromPMT(section)
+		}
+	}
+
+	return models.InboundStreamDetails{}, false
+}
+
+func getStreamDetailsFromPMT(section []byte) (models.InboundStreamDetails, bool) {
+	streams, ok := mpegts.GetElementaryStreams(section)
+	if !ok {
+		return models.InboundStreamDetails{}, false
+	}
+
+	details := models.InboundStreamDetails{
+		VideoCodec: unknownString,
+		AudioCodec: "No audio",
+		VideoOnly:  true,
+	}
+
+	for _, stream := range streams {
+		if codec, ok := videoStreamTypes[stream.StreamType]; ok && details.VideoCodec == unknownString {
+			details.VideoCodec = codec
+		}
+		if codec, ok := audioStreamTypes[stream.StreamType]; ok && details.VideoOnly {
+			details.AudioCodec = codec
+			details.VideoOnly = false
+		}
+	}
+
+	return details, true
+}
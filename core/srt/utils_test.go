@@ -0,0 +1,112 @@
+package srt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owncast/owncast/core/mpegts"
+)
+
+func TestGetStreamKeyFromStreamID(t *testing.T) {
+	tests := map[string]string{
+		"abc123":                    "abc123",
+		"#!::r=abc123,m=publish":    "abc123",
+		"#!::m=publish,r=abc123":    "abc123",
+		"#!::m=publish":             "",
+		"#!::u=broadcaster,r=other": "other",
+	}
+
+	for streamID, expected := range tests {
+		if key := getStreamKeyFromStreamID(streamID); key != expected {
+			t.Errorf("%s returned %s, expected %s", streamID, key, expected)
+		}
+	}
+}
+
+func TestHandshakeStreamID(t *testing.T) {
+	h := handshake{version: 5, handshakeType: handshakeTypeConclusion}
+	h.setStreamID("#!::r=abc123,m=publish")
+
+	parsed, err := parseHandshake(h.marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if streamID := parsed.getStreamID(); streamID != "#!::r=abc123,m=publish" {
+		t.Errorf("unexpected stream id %s", streamID)
+	}
+}
+
+func TestStreamDetailsFromTransportStream(t *testing.T) {
+	pat := newTSPacket(0x0000, []byte{
+		0x00, 0xB0, 0x0D, 0x00, 0x01, 0xC1, 0x00, 0x00,
+		0x00, 0x01, 0xF0, 0x00, // program 1 on pid 0x1000
+		0x00, 0x00, 0x00, 0x00, // crc
+	})
+	pmt := newTSPacket(0x1000, []byte{
+		0x02, 0xB0, 0x17, 0x00, 0x01, 0xC1, 0x00, 0x00,
+		0xE1, 0x00, 0xF0, 0x00,
+		0x1B, 0xE1, 0x00, 0xF0, 0x00, // h.264 on pid 0x100
+		0x0F, 0xE1, 0x01, 0xF0, 0x00, // aac on pid 0x101
+		0x00, 0x00, 0x00, 0x00, // crc
+	})
+
+	w := newStreamDetailsWriter(nil, "")
+	details, ok := w.inspect(append(pat, pmt...))
+	if !ok {
+		t.Fatal("program map table was not found")
+	}
+
+	if details.VideoCodec != "H.264" || details.AudioCodec != "AAC" || details.VideoOnly {
+		t.Errorf("unexpected stream details %+v", details)
+	}
+}
+
+func TestReceiveBufferReordersPackets(t *testing.T) {
+	now := time.Now()
+	b := newReceiveBuffer(100, 100*time.Millisecond)
+
+	delivered, _ := b.push(100, []byte{0}, now)
+	if len(delivered) != 1 {
+		t.Fatalf("expected first packet to be delivered, got %d", len(delivered))
+	}
+
+	delivered, lost := b.push(103, []byte{3}, now)
+	if len(delivered) != 0 || lost[0] != 101 || lost[1] != 102 {
+		t.Fatalf("expected 101-102 to be lost, got %v", lost)
+	}
+
+	delivered, _ = b.push(101, []byte{1}, now)
+	if len(delivered) != 1 || delivered[0][0] != 1 {
+		t.Fatalf("expected 101 to be delivered, got %v", delivered)
+	}
+
+	if missing := b.missing(); len(missing) != 1 || missing[0] != [2]uint32{102, 102} {
+		t.Fatalf("expected 102 to be missing, got %v", missing)
+	}
+
+	// 102 never arrives so it is skipped once the latency window has passed.
+	if delivered := b.expire(now.Add(50 * time.Millisecond)); len(delivered) != 0 {
+		t.Fatal("packets were skipped before the latency window passed")
+	}
+	delivered = b.expire(now.Add(150 * time.Millisecond))
+	if len(delivered) != 1 || delivered[0][0] != 3 {
+		t.Fatalf("expected 103 to be delivered, got %v", delivered)
+	}
+}
+
+func newTSPacket(pid int, section []byte) []byte {
+	pkt := make([]byte, mpegts.PacketSize)
+	for i := range pkt {
+		pkt[i] = 0xFF
+	}
+
+	pkt[0] = mpegts.SyncByte
+	pkt[1] = 0x40 | byte(pid>>8)
+	pkt[2] = byte(pid)
+	pkt[3] = 0x10
+	pkt[4] = 0x00
+	copy(pkt[5:], section)
+
+	return pkt
+}
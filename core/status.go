@@ -33,11 +33,38 @@ func GetCurrentBroadcast() *models.CurrentBroadcast {
 	return _currentBroadcast
 }
 
+// GetTranscoderHealth will return how well the transcoder is keeping up
+// with the stream, or nil if there is no stream.
+func GetTranscoderHealth() *models.TranscoderHealth {
+	t := _transcoder
+	if t == nil || !IsStreamConnected() {
+		return nil
+	}
+
+	health := t.GetHealth()
+	return &health
+}
+
 // setBroadcaster will store the current inbound broadcasting details.
 func setBroadcaster(broadcaster models.Broadcaster) {
+	_broadcasterLock.Lock()
+	defer _broadcasterLock.Unlock()
+
 	_broadcaster = &broadcaster
 }
 
+// clearBroadcaster will remove the inbound broadcasting details once the
+// stream ends.
+func clearBroadcaster() {
+	_broadcasterLock.Lock()
+	defer _broadcasterLock.Unlock()
+
+	_broadcaster = nil
+}
+
 func GetBroadcaster() *models.Broadcaster {
+	_broadcasterLock.Lock()
+	defer _broadcasterLock.Unlock()
+
 	return _broadcaster
 }
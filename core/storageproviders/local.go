@@ -1,6 +1,7 @@
 package storageproviders
 
 import (
+	"fmt"
 	"path/filepath"
 	"time"
 
@@ -19,8 +20,8 @@ var _onlineCleanupTicker *time.Ticker
 
 // Setup configures this storage provider.
 func (s *LocalStorage) Setup() error {
-	// NOTE: This cleanup timer will have to be disabled to support recordings in the future
-	// as all HLS segments have to be publicly available on disk to keep a recording of them.
+	// Recordings keep their own copy of each segment so the public HLS
+	// content can continue to be cleaned up while a stream is live.
 	_onlineCleanupTicker = time.NewTicker(1 * time.Minute)
 	go func() {
 		for range _onlineCleanupTicker.C {
@@ -52,6 +53,29 @@ func (s *LocalStorage) MasterPlaylistWritten(localFilePath string) {
 	}
 }
 
+// DASHManifestWritten is called when the mpeg-dash manifest is written.
+func (s *LocalStorage) DASHManifestWritten(localFilePath string) {
+	if _, err := s.Save(localFilePath, 0); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// SaveVODSegment will return the location of a recorded segment for a VOD playlist.
+// Local segments are served directly out of the recording directory.
+func (s *LocalStorage) SaveVODSegment(localFilePath string, recordingID int) (string, error) {
+	if !utils.DoesFileExists(localFilePath) {
+		return "", fmt.Errorf("%s does not exist", localFilePath)
+	}
+
+	return filepath.Base(localFilePath), nil
+}
+
+// DeleteVODSegments will delete the segments saved for a recording's VOD
+// playlist. Local segments are deleted along with the recording directory.
+func (s *LocalStorage) DeleteVODSegments(recordingID int) error {
+	return nil
+}
+
 // Save will save a local filepath using the storage provider.
 func (s *LocalStorage) Save(filePath string, retryCount int) (string, error) {
 	newPath := ""
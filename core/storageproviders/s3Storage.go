@@ -3,8 +3,11 @@ package storageproviders
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/owncast/owncast/core/data"
 	"github.com/owncast/owncast/core/playlist"
@@ -14,6 +17,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 
 	"github.com/owncast/owncast/config"
@@ -25,6 +29,13 @@ import (
 // then keep a reference to it here.
 var _queuedPlaylistUpdates = make(map[string]string)
 
+// The fMP4 initialization segments that have been uploaded, so a playlist
+// is never uploaded before the initialization segment it refers to.
+var (
+	_uploadedInitSegments     = make(map[string]bool)
+	_uploadedInitSegmentsLock sync.Mutex
+)
+
 // S3Storage is the s3 implementation of the ChunkStorageProvider.
 type S3Storage struct {
 	sess *session.Session
@@ -80,6 +91,12 @@ func (s *S3Storage) SegmentWritten(localFilePath string) {
 	}
 	averagePerformance := utils.GetAveragePerformance(performanceMonitorKey)
 
+	if strings.HasSuffix(localFilePath, ".mp4") {
+		_uploadedInitSegmentsLock.Lock()
+		_uploadedInitSegments[localFilePath] = true
+		_uploadedInitSegmentsLock.Unlock()
+	}
+
 	// Warn the user about long-running save operations
 	if averagePerformance != 0 {
 		if averagePerformance > float64(data.GetStreamLatencyLevel().SecondsPerSegment)*0.9 {
@@ -91,6 +108,11 @@ func (s *S3Storage) SegmentWritten(localFilePath string) {
 	// so the segments and the HLS playlist referencing
 	// them are in sync.
 	playlistPath := filepath.Join(filepath.Dir(localFilePath), "stream.m3u8")
+	if !isInitSegmentUploaded(playlistPath) {
+		_queuedPlaylistUpdates[playlistPath] = playlistPath
+		return
+	}
+
 	if _, err := s.Save(playlistPath, 0); err != nil {
 		_queuedPlaylistUpdates[playlistPath] = playlistPath
 		if pErr, ok := err.(*os.PathError); ok {
@@ -122,8 +144,50 @@ func (s *S3Storage) MasterPlaylistWritten(localFilePath string) {
 	}
 }
 
+// DASHManifestWritten is called when the mpeg-dash manifest is written.
+func (s *S3Storage) DASHManifestWritten(localFilePath string) {
+	if _, err := s.Save(localFilePath, 0); err != nil {
+		log.Warnln(err)
+		return
+	}
+
+	// The copy served locally refers to the segments in the bucket.
+	if err := s.rewriteRemoteManifest(localFilePath); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// SaveVODSegment uploads a recorded segment to the s3 bucket and returns its public URL.
+func (s *S3Storage) SaveVODSegment(localFilePath string, recordingID int) (string, error) {
+	key := getVODSegmentPrefix(recordingID) + filepath.Base(localFilePath)
+	if _, err := s.upload(localFilePath, key, 0); err != nil {
+		return "", err
+	}
+
+	return s.host + "/" + key, nil
+}
+
+// DeleteVODSegments deletes the segments uploaded for a recording from the s3 bucket.
+func (s *S3Storage) DeleteVODSegments(recordingID int) error {
+	client := s3.New(s.sess)
+	iterator := s3manager.NewDeleteListIterator(client, &s3.ListObjectsInput{
+		Bucket: aws.String(s.s3Bucket),
+		Prefix: aws.String(getVODSegmentPrefix(recordingID)),
+	})
+
+	return s3manager.NewBatchDeleteWithClient(client).Delete(aws.BackgroundContext(), iterator)
+}
+
+func getVODSegmentPrefix(recordingID int) string {
+	return fmt.Sprintf("recordings/%d/", recordingID)
+}
+
 // Save saves the file to the s3 bucket.
 func (s *S3Storage) Save(filePath string, retryCount int) (string, error) {
+	return s.upload(filePath, filePath, retryCount)
+}
+
+func (s *S3Storage) upload(filePath string, key string, retryCount int) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", err
@@ -134,11 +198,15 @@ func (s *S3Storage) Save(filePath string, retryCount int) (string, error) {
 	cacheControlHeader := fmt.Sprintf("max-age=%d", maxAgeSeconds)
 	uploadInput := &s3manager.UploadInput{
 		Bucket:       aws.String(s.s3Bucket), // Bucket to be used
-		Key:          aws.String(filePath),   // Name of the file to be saved
+		Key:          aws.String(key),        // Name of the file to be saved
 		Body:         file,                   // File
 		CacheControl: &cacheControlHeader,
 	}
 
+	if contentType := utils.GetContentTypeForPath(filePath); contentType != "" {
+		uploadInput.ContentType = aws.String(contentType)
+	}
+
 	if s.s3ACL != "" {
 		uploadInput.ACL = aws.String(s.s3ACL)
 	} else {
@@ -152,7 +220,7 @@ func (s *S3Storage) Save(filePath string, retryCount int) (string, error) {
 		log.Traceln("error uploading:", filePath, err.Error())
 		if retryCount < 4 {
 			log.Traceln("Retrying...")
-			return s.Save(filePath, retryCount+1)
+			return s.upload(filePath, key, retryCount+1)
 		} else {
 			log.Warnln("Giving up on", filePath, err)
 			return "", fmt.Errorf("Giving up on %s", filePath)
@@ -183,6 +251,55 @@ func (s *S3Storage) connectAWS() *session.Session {
 	return sess
 }
 
+// rewriteRemoteManifest will write a local copy of the dash manifest that
+// uses the bucket as the base of the segment URLs.
+func (s *S3Storage) rewriteRemoteManifest(filePath string) error {
+	content, err := ioutil.ReadFile(filePath) // nolint
+	if err != nil {
+		return err
+	}
+
+	baseURL := "<BaseURL>" + s.host + "/hls/</BaseURL>\n  "
+	manifest := strings.Replace(string(content), "<Period", baseURL+"<Period", 1)
+	publicPath := filepath.Join(config.PublicHLSStoragePath, filepath.Base(filePath))
+
+	return playlist.WritePlaylist(manifest, publicPath)
+}
+
+// ResetUploadedInitSegments will forget the initialization segments that
+// have been uploaded, as the next stream writes its own.
+func ResetUploadedInitSegments() {
+	_uploadedInitSegmentsLock.Lock()
+	defer _uploadedInitSegmentsLock.Unlock()
+
+	_uploadedInitSegments = make(map[string]bool)
+}
+
+// isInitSegmentUploaded will return false if the playlist refers to an
+// fMP4 initialization segment that has not been uploaded yet.
+func isInitSegmentUploaded(playlistPath string) bool {
+	f, err := os.Open(playlistPath)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != m3u8.MEDIA {
+		return true
+	}
+
+	mediaPlaylist := p.(*m3u8.MediaPlaylist)
+	if mediaPlaylist.Map == nil {
+		return true
+	}
+
+	_uploadedInitSegmentsLock.Lock()
+	defer _uploadedInitSegmentsLock.Unlock()
+
+	return _uploadedInitSegments[filepath.Join(filepath.Dir(playlistPath), mediaPlaylist.Map.URI)]
+}
+
 // rewriteRemotePlaylist will take a local playlist and rewrite it to have absolute URLs to remote locations.
 func (s *S3Storage) rewriteRemotePlaylist(filePath string) error {
 	f, err := os.Open(filePath)
@@ -197,6 +314,14 @@ func (s *S3Storage) rewriteRemotePlaylist(filePath string) error {
 
 	for _, item := range p.Variants {
 		item.URI = s.host + filepath.Join("/hls", item.URI)
+
+		// Alternate audio and captions renditions are listed ahead of the
+		// first variant that follows them.
+		for _, alternative := range item.Alternatives {
+			if alternative.URI != "" {
+				alternative.URI = s.host + filepath.Join("/hls", alternative.URI)
+			}
+		}
 	}
 
 	publicPath := filepath.Join(config.PublicHLSStoragePath, filepath.Base(filePath))
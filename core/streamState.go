@@ -6,13 +6,17 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/owncast/owncast/config"
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/restream"
 	"github.com/owncast/owncast/core/rtmp"
+	"github.com/owncast/owncast/core/srt"
+	"github.com/owncast/owncast/core/storageproviders"
 	"github.com/owncast/owncast/core/transcoder"
 	"github.com/owncast/owncast/core/webhooks"
 	"github.com/owncast/owncast/models"
@@ -29,8 +33,142 @@ var _onlineCleanupTicker *time.Ticker
 
 var _currentBroadcast *models.CurrentBroadcast
 
-// setStreamAsConnected sets the stream as connected.
-func setStreamAsConnected(rtmpOut *io.PipeReader) {
+var (
+	// If the stream is waiting for the details of the inbound stream
+	// before the transcoder is started.
+	_streamStarting     bool
+	_streamStartingLock sync.Mutex
+)
+
+// How long to wait for the details of the inbound stream the auto ladder is
+// picked from.
+const autoLadderBroadcasterTimeout = 5 * time.Second
+
+// How far from its start time a scheduled stream is matched to a stream
+// going live.
+const scheduledStreamWindow = time.Hour
+
+// setRTMPStreamAsConnected sets the stream from the RTMP server as connected.
+// Only the RTMP server passes its packets on to be restreamed, so the
+// destinations are not connected to for any other input.
+func setRTMPStreamAsConnected(rtmpOut *io.PipeReader) {
+	restream.Start()
+	setStreamAsConnected(rtmpOut, rtmp.RestartFeed)
+}
+
+// setSRTStreamAsConnected sets the stream from the SRT server as connected.
+// MPEG-TS can be read from any point, so a restarted transcoder carries on
+// reading the same feed.
+func setSRTStreamAsConnected(srtOut *io.PipeReader) {
+	setStreamAsConnected(srtOut, func() (*io.PipeReader, bool) {
+		return srtOut, srt.IsConnected()
+	})
+}
+
+// setStreamAsConnected sets the stream as connected. The transcoder is
+// given a new feed from restartFeed if it has to be restarted.
+func setStreamAsConnected(rtmpOut *io.PipeReader, restartFeed func() (*io.PipeReader, bool)) {
+	start := func() {
+		setScheduledStreamTitle()
+		startStream(func(t *transcoder.Transcoder) {
+			t.SetStdin(rtmpOut)
+			t.RestartInput = func(t *transcoder.Transcoder) bool {
+				feed, ok := restartFeed()
+				if ok {
+					t.SetStdin(feed)
+				}
+				return ok
+			}
+		}, nil)
+	}
+
+	// The details of the inbound stream are sent after it connects, and are
+	// not read until this returns. The stream is already active while
+	// waiting for them so no other source can start one.
+	if data.GetAutoLadderEnabled() && GetBroadcaster() == nil {
+		setStreamStarting(true)
+		go func() {
+			waitForBroadcaster(autoLadderBroadcasterTimeout)
+			start()
+			setStreamStarting(false)
+		}()
+		return
+	}
+
+	start()
+}
+
+// waitForBroadcaster will wait until the details of the inbound stream are
+// known or the timeout passes.
+func waitForBroadcaster(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for GetBroadcaster() == nil && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func setStreamStarting(starting bool) {
+	_streamStartingLock.Lock()
+	defer _streamStartingLock.Unlock()
+
+	_streamStarting = starting
+}
+
+func isStreamStarting() bool {
+	_streamStartingLock.Lock()
+	defer _streamStartingLock.Unlock()
+
+	return _streamStarting
+}
+
+// getStreamOutputVariants will return the variants the stream is transcoded
+// to, picked from the inbound stream when the auto ladder is enabled.
+func getStreamOutputVariants() []models.StreamOutputVariant {
+	variants := data.GetStreamOutputVariants()
+	if !data.GetAutoLadderEnabled() || data.GetRadioModeEnabled() {
+		return variants
+	}
+
+	broadcaster := GetBroadcaster()
+	if broadcaster == nil {
+		log.Warnln("The details of the inbound stream are not known. Using the configured stream output variants instead of the auto ladder.")
+		return variants
+	}
+
+	cpuUsageLevel := 0
+	if len(variants) > 0 {
+		cpuUsageLevel = variants[0].CPUUsageLevel
+	}
+
+	details := broadcaster.StreamDetails
+	ladder := transcoder.GetAutoLadder(details, cpuUsageLevel)
+	if len(ladder) == 0 {
+		log.Warnln("The size of the inbound stream is not known. Using the configured stream output variants instead of the auto ladder.")
+		return variants
+	}
+
+	log.Infof("Auto ladder picked %d stream variants for the %dx%d inbound stream.", len(ladder), details.Width, details.Height)
+	return ladder
+}
+
+// setAudioTrackAsConnected will transcode an alternate audio track of the
+// live stream.
+func setAudioTrackAsConnected(index int, rtmpOut *io.PipeReader) {
+	if _transcoder == nil {
+		rtmpOut.Close()
+		return
+	}
+
+	go func(t *transcoder.Transcoder) {
+		t.StartAudioTrack(index, rtmpOut)
+		rtmpOut.Close()
+	}(_transcoder)
+}
+
+// startStream will start the transcoder and everything that takes place while
+// a stream is live. The input of the transcoder is set up by configureInput and
+// completed is called after the stream has been set as disconnected.
+func startStream(configureInput func(*transcoder.Transcoder), completed func()) {
 	_stats.StreamConnected = true
 	_stats.LastConnectTime = utils.NullTime{Time: time.Now(), Valid: true}
 	_stats.LastDisconnectTime = utils.NullTime{Time: time.Now(), Valid: false}
@@ -38,7 +176,7 @@ func setStreamAsConnected(rtmpOut *io.PipeReader) {
 
 	_currentBroadcast = &models.CurrentBroadcast{
 		LatencyLevel:   data.GetStreamLatencyLevel(),
-		OutputSettings: data.GetStreamOutputVariants(),
+		OutputSettings: getStreamOutputVariants(),
 	}
 
 	StopOfflineCleanupTimer()
@@ -59,32 +197,59 @@ func setStreamAsConnected(rtmpOut *io.PipeReader) {
 		segmentPath = config.PrivateHLSStoragePath
 	}
 
-	go func() {
-		_transcoder = transcoder.NewTranscoder()
-		_transcoder.TranscoderCompleted = func(error) {
-			SetStreamAsDisconnected()
-			_transcoder = nil
-			_currentBroadcast = nil
+	// The transcoder recreates the variant directories and the
+	// initialization segments in them.
+	storageproviders.ResetUploadedInitSegments()
+
+	_transcoder = transcoder.NewTranscoderWithOutputVariants(_currentBroadcast.OutputSettings)
+	_transcoder.TranscoderCompleted = func(error) {
+		SetStreamAsDisconnected()
+		_transcoder = nil
+		_currentBroadcast = nil
+
+		if completed != nil {
+			completed()
 		}
-		_transcoder.SetStdin(rtmpOut)
-		_transcoder.Start()
-	}()
+	}
+	configureInput(_transcoder)
+	go _transcoder.Start()
+
+	startRecording()
 
 	go webhooks.SendStreamStatusEvent(models.StreamStarted)
 	transcoder.StartThumbnailGenerator(segmentPath, data.FindHighestVideoQualityIndex(_currentBroadcast.OutputSettings))
 }
 
+// setScheduledStreamTitle will set the stream title to the title of the
+// broadcast scheduled for around now, if there is one.
+func setScheduledStreamTitle() {
+	scheduledStream := data.GetScheduledStreamNear(time.Now(), scheduledStreamWindow)
+	if scheduledStream == nil {
+		return
+	}
+
+	if err := data.SetStreamTitle(scheduledStream.Title); err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	log.Infoln("Stream title set to the scheduled stream:", scheduledStream.Title)
+}
+
 // SetStreamAsDisconnected sets the stream as disconnected.
 func SetStreamAsDisconnected() {
 	_stats.StreamConnected = false
 	_stats.LastDisconnectTime = utils.NullTime{Time: time.Now(), Valid: true}
-	_broadcaster = nil
+	clearBroadcaster()
 
 	offlineFilename := "offline.ts"
 	offlineFilePath := "static/" + offlineFilename
 
 	transcoder.StopThumbnailGenerator()
 	rtmp.Disconnect()
+	srt.Disconnect()
+	restream.Stop()
+	stopRecording()
 
 	if _yp != nil {
 		_yp.Stop()
@@ -100,6 +265,13 @@ func SetStreamAsDisconnected() {
 		if _, err := _storage.Save(segmentFilePath, 0); err != nil {
 			log.Warnln(err)
 		}
+
+		// Low-latency playlists are written by us instead of the transcoder.
+		if transcoder.IsLowLatencyHLSActive() {
+			transcoder.AppendLowLatencyOfflineSegment(index, offlineFilename, 8.0)
+			continue
+		}
+
 		if utils.DoesFileExists(playlistFilePath) {
 			f, err := os.OpenFile(playlistFilePath, os.O_CREATE|os.O_RDWR, os.ModePerm)
 			if err != nil {
@@ -117,11 +289,17 @@ func SetStreamAsDisconnected() {
 				variantPlaylist.Segments = variantPlaylist.Segments[:len(variantPlaylist.Segments)]
 			}
 
-			if err := variantPlaylist.Append(offlineFilename, 8.0, ""); err != nil {
-				log.Fatalln(err)
-			}
-			if err := variantPlaylist.SetDiscontinuity(); err != nil {
-				log.Fatalln(err)
+			// The MPEG-TS offline segment can't follow fMP4 segments, so
+			// their playlist is ended instead.
+			if variantPlaylist.Map != nil {
+				variantPlaylist.Close()
+			} else {
+				if err := variantPlaylist.Append(offlineFilename, 8.0, ""); err != nil {
+					log.Fatalln(err)
+				}
+				if err := variantPlaylist.SetDiscontinuity(); err != nil {
+					log.Fatalln(err)
+				}
 			}
 			if _, err := f.WriteAt(variantPlaylist.Encode().Bytes(), 0); err != nil {
 				log.Errorln(err)
@@ -152,6 +330,8 @@ func SetStreamAsDisconnected() {
 		}
 	}
 
+	storageproviders.ResetUploadedInitSegments()
+
 	StartOfflineCleanupTimer()
 	stopOnlineCleanupTimer()
 	saveStats()
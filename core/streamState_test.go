@@ -0,0 +1,34 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestWaitForBroadcaster(t *testing.T) {
+	clearBroadcaster()
+	defer clearBroadcaster()
+
+	setStreamStarting(true)
+	if !isInboundStreamActive() {
+		t.Error("expected the stream to be active while waiting for the broadcaster")
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		setBroadcaster(models.Broadcaster{RemoteAddr: "127.0.0.1"})
+	}()
+
+	started := time.Now()
+	waitForBroadcaster(5 * time.Second)
+	if GetBroadcaster() == nil || time.Since(started) > 4*time.Second {
+		t.Error("expected to stop waiting once the broadcaster was set")
+	}
+
+	setStreamStarting(false)
+	if isInboundStreamActive() {
+		t.Error("expected the stream to not be active")
+	}
+}
@@ -0,0 +1,179 @@
+package transcoder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+// Alternate audio tracks are transcoded on their own alongside the stream
+// variants, and listed in the master playlist once they can be played.
+var (
+	_audioTracks     = make(map[int]*audioTrack)
+	_audioTracksLock sync.Mutex
+)
+
+const audioTrackDirectoryPrefix = "audio-"
+
+type audioTrack struct {
+	index   int
+	track   models.AudioTrack
+	command *exec.Cmd
+	ready   bool
+}
+
+func getAudioTrackDirectory(index int) string {
+	return audioTrackDirectoryPrefix + strconv.Itoa(index)
+}
+
+// StartAudioTrack will transcode an alternate audio track from stdin until
+// it ends. Its timestamps are moved to line up with the stream's.
+func (t *Transcoder) StartAudioTrack(index int, stdin io.Reader) {
+	if index >= len(t.audioTracks) {
+		log.Warnln("audio track", index, "is not available for the current stream")
+		return
+	}
+
+	for _, directory := range []string{config.PrivateHLSStoragePath, config.PublicHLSStoragePath} {
+		if err := os.MkdirAll(path.Join(directory, getAudioTrackDirectory(index)), 0777); err != nil {
+			log.Errorln(err)
+			return
+		}
+	}
+
+	command := t.getAudioTrackString(index, time.Since(t.startTime))
+	if config.EnableDebugFeatures {
+		log.Println(command)
+	}
+
+	track := &audioTrack{
+		index:   index,
+		track:   t.audioTracks[index],
+		command: exec.Command("sh", "-c", command),
+	}
+	track.command.Stdin = stdin
+
+	_audioTracksLock.Lock()
+	_audioTracks[index] = track
+	_audioTracksLock.Unlock()
+
+	log.Infof("Audio track %s started.", track.track.Name)
+	if output, err := track.command.CombinedOutput(); err != nil {
+		log.Debugln("audio track", track.track.Name, "ended", err, string(output))
+	}
+
+	_audioTracksLock.Lock()
+	wasReady := track.ready
+	if _audioTracks[index] == track {
+		delete(_audioTracks, index)
+	}
+	_audioTracksLock.Unlock()
+
+	if wasReady {
+		writeMasterPlaylist()
+	}
+}
+
+func (t *Transcoder) getAudioTrackString(index int, offset time.Duration) string {
+	localListenerAddress := "http://127.0.0.1:" + t.internalListenerPort
+	directory := getAudioTrackDirectory(index)
+	name := "stream-" + t.segmentIdentifier + "-" + directory
+	segmentExtension, segmentTypeFlags := t.getSegmentTypeFlags(name)
+
+	flags := []string{
+		t.ffmpegPath,
+		"-hide_banner",
+		"-loglevel warning",
+		fmt.Sprintf("-itsoffset %.3f", offset.Seconds()), // Line up with the stream that started before it
+		"-fflags +genpts",
+		"-i pipe:0",
+
+		"-map a:0",
+		fmt.Sprintf("-c:a:0 %s -b:a:0 %dk", models.AudioCodecAAC, t.audioTracks[index].GetAudioBitrate()),
+
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(t.currentLatencyLevel.SecondsPerSegment),
+		"-hls_list_size", strconv.Itoa(t.currentLatencyLevel.SegmentCount),
+		segmentTypeFlags,
+		"-strftime 1",
+		"-hls_segment_filename", localListenerAddress + "/" + directory + "/" + name + "%s" + segmentExtension,
+		"-method PUT -http_persistent 0",
+		localListenerAddress + "/" + directory + "/stream.m3u8",
+	}
+
+	return strings.Join(flags, " ")
+}
+
+// audioTrackPlaylistWritten will list an audio track in the master playlist
+// once its first playlist has been written.
+func audioTrackPlaylistWritten(localFilePath string) {
+	directory := utils.GetIndexFromFilePath(localFilePath)
+	if !strings.HasPrefix(directory, audioTrackDirectoryPrefix) {
+		return
+	}
+
+	index, err := strconv.Atoi(strings.TrimPrefix(directory, audioTrackDirectoryPrefix))
+	if err != nil {
+		return
+	}
+
+	_audioTracksLock.Lock()
+	track := _audioTracks[index]
+	if track == nil || track.ready {
+		_audioTracksLock.Unlock()
+		return
+	}
+	track.ready = true
+	_audioTracksLock.Unlock()
+
+	writeMasterPlaylist()
+}
+
+// getReadyAudioTracks will return the audio tracks that can be played, in
+// the order they are configured.
+func getReadyAudioTracks() []audioTrack {
+	_audioTracksLock.Lock()
+	defer _audioTracksLock.Unlock()
+
+	tracks := []audioTrack{}
+	for _, track := range _audioTracks {
+		if track.ready {
+			tracks = append(tracks, *track)
+		}
+	}
+
+	sort.Slice(tracks, func(a, b int) bool {
+		return tracks[a].index < tracks[b].index
+	})
+
+	return tracks
+}
+
+// stopAudioTracks will end every audio track being transcoded.
+func stopAudioTracks() {
+	_audioTracksLock.Lock()
+	defer _audioTracksLock.Unlock()
+
+	for _, track := range _audioTracks {
+		if track.command.Process == nil {
+			continue
+		}
+
+		if err := track.command.Process.Kill(); err != nil {
+			log.Errorln(err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package transcoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestFFmpegAudioTrackCommand(t *testing.T) {
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+	transcoder.audioTracks = []models.AudioTrack{{Name: "Commentary"}, {Name: "Español", Language: "es", AudioBitrate: 96}}
+
+	cmd := transcoder.getAudioTrackString(1, 12345*time.Millisecond)
+
+	expected := `/fake/path/ffmpeg -hide_banner -loglevel warning -itsoffset 12.345 -fflags +genpts -i pipe:0 -map a:0 -c:a:0 aac -b:a:0 96k -f hls -hls_time 3 -hls_list_size 3 -segment_format_options mpegts_flags=+initial_discontinuity:mpegts_copyts=1 -strftime 1 -hls_segment_filename http://127.0.0.1:8123/audio-1/stream-jdofFGg-audio-1%s.ts -method PUT -http_persistent 0 http://127.0.0.1:8123/audio-1/stream.m3u8`
+
+	if cmd != expected {
+		t.Errorf("ffmpeg command does not match expected.\nGot %s\n, want: %s", cmd, expected)
+	}
+}
+
+func TestMasterPlaylistAudioTracks(t *testing.T) {
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\"\n0/stream.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=3800000,RESOLUTION=1920x1080,CODECS=\"avc1.640028,mp4a.40.2\"\n1/stream.m3u8\n"
+
+	if getMasterPlaylistWithAudioTracks(playlist, nil) != playlist {
+		t.Error("master playlist without audio tracks should not be changed")
+	}
+
+	tracks := []audioTrack{{index: 1, track: models.AudioTrack{Name: "Español", Language: "es"}}}
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"alternate-audio\",NAME=\"Main\",DEFAULT=YES,AUTOSELECT=YES\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"alternate-audio\",NAME=\"Español\",LANGUAGE=\"es\",DEFAULT=NO,AUTOSELECT=YES,URI=\"audio-1/stream.m3u8\"\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\",AUDIO=\"alternate-audio\"\n0/stream.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=3800000,RESOLUTION=1920x1080,CODECS=\"avc1.640028,mp4a.40.2\",AUDIO=\"alternate-audio\"\n1/stream.m3u8\n"
+
+	if updated := getMasterPlaylistWithAudioTracks(playlist, tracks); updated != expected {
+		t.Errorf("master playlist does not match expected.\nGot %s\n, want: %s", updated, expected)
+	}
+}
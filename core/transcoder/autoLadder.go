@@ -0,0 +1,154 @@
+package transcoder
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/models"
+)
+
+// The auto ladder picks the stream output variants from the details of the
+// inbound stream, using fewer of them when the CPU is busy.
+var (
+	_cpuUsage               int
+	_droppedAutoLadderRungs int
+	_autoLadderLock         sync.Mutex
+)
+
+type autoLadderRung struct {
+	height       int
+	videoBitrate int
+}
+
+// The rungs of the ladder, highest first.
+var autoLadderRungs = []autoLadderRung{
+	{height: 1080, videoBitrate: 4500},
+	{height: 720, videoBitrate: 2500},
+	{height: 480, videoBitrate: 1200},
+	{height: 360, videoBitrate: 800},
+	{height: 240, videoBitrate: 400},
+}
+
+// SetCPUUsage will set the recent CPU usage the auto ladder is picked with.
+func SetCPUUsage(percent int) {
+	_autoLadderLock.Lock()
+	defer _autoLadderLock.Unlock()
+
+	_cpuUsage = percent
+}
+
+// DropAutoLadderRung will leave the highest rung out of the auto ladder the
+// next time it is picked, as the CPU is too busy for it.
+func DropAutoLadderRung() {
+	_autoLadderLock.Lock()
+	defer _autoLadderLock.Unlock()
+
+	if _droppedAutoLadderRungs < len(autoLadderRungs)-1 {
+		_droppedAutoLadderRungs++
+		log.Warnln("The CPU is too busy for the auto ladder. Its highest rung will be left out the next time the stream starts.")
+	}
+}
+
+// ResetAutoLadder will use every rung the CPU has room for again.
+func ResetAutoLadder() {
+	_autoLadderLock.Lock()
+	defer _autoLadderLock.Unlock()
+
+	_droppedAutoLadderRungs = 0
+}
+
+// GetAutoLadder will return the stream output variants suited to the
+// inbound stream and the CPU, or nothing if the size of the stream is not
+// known.
+func GetAutoLadder(source models.InboundStreamDetails, cpuUsageLevel int) []models.StreamOutputVariant {
+	_autoLadderLock.Lock()
+	defer _autoLadderLock.Unlock()
+
+	return getAutoLadder(source, _cpuUsage, _droppedAutoLadderRungs, cpuUsageLevel)
+}
+
+func getAutoLadder(source models.InboundStreamDetails, cpuUsage int, droppedRungs int, cpuUsageLevel int) []models.StreamOutputVariant {
+	if source.Height <= 0 {
+		return nil
+	}
+
+	// The video is never scaled up.
+	rungs := []autoLadderRung{}
+	for _, rung := range autoLadderRungs {
+		if rung.height <= source.Height {
+			rungs = append(rungs, rung)
+		}
+	}
+	if len(rungs) == 0 {
+		rungs = append(rungs, autoLadderRung{height: source.Height, videoBitrate: autoLadderRungs[len(autoLadderRungs)-1].videoBitrate})
+	}
+
+	// Rungs the CPU was too busy for are left out from the top, as they are
+	// the most costly.
+	if droppedRungs >= len(rungs) {
+		droppedRungs = len(rungs) - 1
+	}
+	rungs = rungs[droppedRungs:]
+
+	if count := getAutoLadderRungCount(cpuUsage); count < len(rungs) {
+		rungs = rungs[:count]
+	}
+
+	framerate := int(math.Round(float64(source.VideoFramerate)))
+	if framerate <= 0 {
+		framerate = 30
+	}
+
+	variants := make([]models.StreamOutputVariant, 0, len(rungs))
+	for index, rung := range rungs {
+		variant := models.StreamOutputVariant{
+			Name:          fmt.Sprintf("%dp", rung.height),
+			ScaledHeight:  rung.height,
+			VideoBitrate:  rung.videoBitrate,
+			Framerate:     framerate,
+			CPUUsageLevel: cpuUsageLevel,
+		}
+
+		// Only the highest rung keeps a high framerate, with the bitrate it needs.
+		if index > 0 && variant.Framerate > 30 {
+			variant.Framerate = 30
+		} else if variant.Framerate > 60 {
+			variant.Framerate = 60
+		}
+		if variant.Framerate > 30 {
+			variant.VideoBitrate = variant.VideoBitrate * 3 / 2
+		}
+
+		// There is nothing to gain from more bits than the inbound stream has.
+		if source.VideoBitrate > 0 && variant.VideoBitrate > source.VideoBitrate {
+			variant.VideoBitrate = source.VideoBitrate
+		}
+
+		if source.AudioCodec == "AAC" {
+			variant.IsAudioPassthrough = true
+		} else {
+			variant.AudioBitrate = 128
+		}
+
+		variants = append(variants, variant)
+	}
+
+	return variants
+}
+
+// getAutoLadderRungCount will return how many rungs the CPU has room for.
+func getAutoLadderRungCount(cpuUsage int) int {
+	switch {
+	case cpuUsage < 25:
+		return 4
+	case cpuUsage < 50:
+		return 3
+	case cpuUsage < 70:
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,62 @@
+package transcoder
+
+import (
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestAutoLadder(t *testing.T) {
+	source := models.InboundStreamDetails{Width: 1280, Height: 720, VideoFramerate: 60, VideoBitrate: 3000, AudioCodec: "AAC"}
+
+	ladder := getAutoLadder(source, 10, 0, 2)
+	expected := []models.StreamOutputVariant{
+		{Name: "720p", ScaledHeight: 720, VideoBitrate: 3000, Framerate: 60, CPUUsageLevel: 2, IsAudioPassthrough: true},
+		{Name: "480p", ScaledHeight: 480, VideoBitrate: 1200, Framerate: 30, CPUUsageLevel: 2, IsAudioPassthrough: true},
+		{Name: "360p", ScaledHeight: 360, VideoBitrate: 800, Framerate: 30, CPUUsageLevel: 2, IsAudioPassthrough: true},
+		{Name: "240p", ScaledHeight: 240, VideoBitrate: 400, Framerate: 30, CPUUsageLevel: 2, IsAudioPassthrough: true},
+	}
+
+	if len(ladder) != len(expected) {
+		t.Fatalf("expected %d variants, got %d: %+v", len(expected), len(ladder), ladder)
+	}
+	for index := range expected {
+		if ladder[index] != expected[index] {
+			t.Errorf("variant %d is %+v, want %+v", index, ladder[index], expected[index])
+		}
+	}
+}
+
+func TestAutoLadderCPU(t *testing.T) {
+	source := models.InboundStreamDetails{Width: 1920, Height: 1080, VideoFramerate: 30, AudioCodec: "MP3"}
+
+	// A busy CPU has room for fewer rungs.
+	if ladder := getAutoLadder(source, 40, 0, 1); len(ladder) != 3 || ladder[0].ScaledHeight != 1080 || ladder[2].ScaledHeight != 480 {
+		t.Errorf("expected the 1080p to 480p rungs, got %+v", ladder)
+	}
+
+	// Dropped rungs leave out the most costly rungs.
+	ladder := getAutoLadder(source, 40, 1, 1)
+	if len(ladder) != 3 || ladder[0].ScaledHeight != 720 || ladder[2].ScaledHeight != 360 {
+		t.Errorf("expected the 720p to 360p rungs, got %+v", ladder)
+	}
+
+	if ladder[0].IsAudioPassthrough || ladder[0].AudioBitrate != 128 {
+		t.Errorf("audio that is not AAC should be transcoded, got %+v", ladder[0])
+	}
+
+	// There is always one rung.
+	if ladder := getAutoLadder(source, 95, 10, 1); len(ladder) != 1 || ladder[0].ScaledHeight != 240 {
+		t.Errorf("expected the 240p rung, got %+v", ladder)
+	}
+
+	// The video is never scaled up.
+	small := models.InboundStreamDetails{Width: 320, Height: 180}
+	if ladder := getAutoLadder(small, 0, 0, 1); len(ladder) != 1 || ladder[0].ScaledHeight != 180 {
+		t.Errorf("expected a single rung at the inbound size, got %+v", ladder)
+	}
+
+	if ladder := getAutoLadder(models.InboundStreamDetails{}, 0, 0, 1); ladder != nil {
+		t.Errorf("expected no ladder without the inbound size, got %+v", ladder)
+	}
+}
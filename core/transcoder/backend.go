@@ -0,0 +1,90 @@
+package transcoder
+
+import (
+	"io"
+	"os/exec"
+
+	"github.com/owncast/owncast/config"
+)
+
+// TranscoderBackend runs the transcoder's ffmpeg command.
+type TranscoderBackend interface {
+	// Start will run the command returned for the port on 127.0.0.1 its
+	// output is to be sent to, reading the stream from stdin if it is set.
+	Start(getCommand func(outputPort string) string, stdin io.Reader) (TranscoderProcess, error)
+}
+
+// TranscoderProcess is a single run of the transcoder by a backend.
+type TranscoderProcess interface {
+	// Messages is what the transcoder writes to stderr.
+	Messages() io.Reader
+	// Progress is what the transcoder writes with -progress.
+	Progress() io.Reader
+	// Wait will wait for the transcoder to exit.
+	Wait() error
+	// Kill will end the transcoder.
+	Kill() error
+}
+
+// LocalTranscoderBackend runs ffmpeg on this server. It is the default.
+type LocalTranscoderBackend struct{}
+
+type localTranscoderProcess struct {
+	command  *exec.Cmd
+	messages io.Reader
+	progress io.Reader
+}
+
+// Start will run the command in a local shell, with its output sent to the
+// file writer.
+func (b LocalTranscoderBackend) Start(getCommand func(outputPort string) string, stdin io.Reader) (TranscoderProcess, error) {
+	command := exec.Command("sh", "-c", getCommand(config.InternalHLSListenerPort))
+	if stdin != nil {
+		command.Stdin = stdin
+	}
+
+	messages, err := command.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := command.Start(); err != nil {
+		return nil, err
+	}
+
+	return &localTranscoderProcess{
+		command:  command,
+		messages: messages,
+		progress: progress,
+	}, nil
+}
+
+func (p *localTranscoderProcess) Messages() io.Reader {
+	return p.messages
+}
+
+func (p *localTranscoderProcess) Progress() io.Reader {
+	return p.progress
+}
+
+func (p *localTranscoderProcess) Wait() error {
+	return p.command.Wait()
+}
+
+func (p *localTranscoderProcess) Kill() error {
+	return p.command.Process.Kill()
+}
+
+// getBackend will return the backend the transcoder is run by.
+func (t *Transcoder) getBackend() TranscoderBackend {
+	if t.backend == nil {
+		return LocalTranscoderBackend{}
+	}
+
+	return t.backend
+}
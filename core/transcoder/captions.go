@@ -0,0 +1,327 @@
+package transcoder
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafov/m3u8"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+// Live captions are written as WebVTT segments that line up with the
+// segments of the first stream variant, and are listed in the master
+// playlist once the first of them is available.
+var (
+	_captions     *captionTrack
+	_captionsLock sync.Mutex
+)
+
+// ErrCaptionsUnavailable is returned when captions are sent while they are
+// not being published.
+var ErrCaptionsUnavailable = errors.New("captions are not being published for the stream")
+
+const (
+	captionsDirectory = "captions"
+	captionsGroup     = "captions"
+
+	// The variant the caption segments follow.
+	captionsVariantIndex = "0"
+
+	// The transcoder keeps the timestamps of the inbound stream, so the
+	// stream starts at zero.
+	captionsTimestampMap = "X-TIMESTAMP-MAP=MPEGTS:0,LOCAL:00:00:00.000"
+)
+
+type captionCue struct {
+	start float64
+	end   float64
+	text  string
+}
+
+type captionSegment struct {
+	sequence uint64
+	uri      string
+	start    float64
+	duration float64
+}
+
+// captionTrack is the captions of a live stream. Times are the number of
+// seconds since the stream started.
+type captionTrack struct {
+	settings  models.Captions
+	startTime time.Time
+
+	cues           []captionCue
+	segments       []captionSegment
+	nextSequence   uint64
+	streamTime     float64
+	targetDuration float64
+	ready          bool
+}
+
+// startCaptions will start publishing the captions of the stream started
+// at startTime, if they are enabled.
+func startCaptions(settings models.Captions, startTime time.Time) {
+	_captionsLock.Lock()
+	defer _captionsLock.Unlock()
+
+	_captions = nil
+	if !settings.Enabled {
+		return
+	}
+
+	for _, directory := range []string{config.PrivateHLSStoragePath, config.PublicHLSStoragePath} {
+		if err := os.MkdirAll(path.Join(directory, captionsDirectory), 0777); err != nil {
+			log.Errorln(err)
+			return
+		}
+	}
+
+	_captions = &captionTrack{
+		settings:  settings,
+		startTime: startTime,
+	}
+}
+
+// stopCaptions will stop publishing the captions of the stream started at
+// startTime.
+func stopCaptions(startTime time.Time) {
+	_captionsLock.Lock()
+	if _captions == nil || !_captions.startTime.Equal(startTime) {
+		_captionsLock.Unlock()
+		return
+	}
+
+	wasReady := _captions.ready
+	_captions = nil
+	_captionsLock.Unlock()
+
+	if wasReady {
+		writeMasterPlaylist()
+	}
+}
+
+// AddCaptionCues will add captions to be shown on the live stream.
+func AddCaptionCues(cues []models.CaptionCue) error {
+	_captionsLock.Lock()
+	defer _captionsLock.Unlock()
+
+	if _captions == nil {
+		return ErrCaptionsUnavailable
+	}
+
+	now := time.Since(_captions.startTime).Seconds()
+	for _, cue := range cues {
+		text := getCaptionText(cue.Text)
+		if text == "" {
+			continue
+		}
+
+		start := now
+		if cue.Start != nil {
+			start = *cue.Start
+		}
+
+		_captions.cues = append(_captions.cues, captionCue{
+			start: start,
+			end:   start + cue.GetDuration(),
+			text:  text,
+		})
+	}
+
+	sort.SliceStable(_captions.cues, func(a, b int) bool {
+		return _captions.cues[a].start < _captions.cues[b].start
+	})
+
+	return nil
+}
+
+// getReadyCaptions will return the settings of the captions if they can be
+// played.
+func getReadyCaptions() *models.Captions {
+	_captionsLock.Lock()
+	defer _captionsLock.Unlock()
+
+	if _captions == nil || !_captions.ready {
+		return nil
+	}
+
+	settings := _captions.settings
+	return &settings
+}
+
+// captionsPlaylistWritten will write the caption segments for the segments
+// new to the playlist of the variant the captions follow.
+func captionsPlaylistWritten(localFilePath string, storage models.StorageProvider) {
+	if utils.GetIndexFromFilePath(localFilePath) != captionsVariantIndex {
+		return
+	}
+
+	f, err := os.Open(localFilePath)
+	if err != nil {
+		log.Debugln(err)
+		return
+	}
+	defer f.Close()
+
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != m3u8.MEDIA {
+		return
+	}
+	playlist := p.(*m3u8.MediaPlaylist)
+
+	_captionsLock.Lock()
+	if _captions == nil {
+		_captionsLock.Unlock()
+		return
+	}
+
+	directory := filepath.Join(config.PrivateHLSStoragePath, captionsDirectory)
+	written := _captions.update(playlist, directory)
+	playlistPath := filepath.Join(directory, "stream.m3u8")
+	if err := ioutil.WriteFile(playlistPath, []byte(_captions.getPlaylist()), 0600); err != nil {
+		_captionsLock.Unlock()
+		log.Errorln(err)
+		return
+	}
+
+	wasReady := _captions.ready
+	_captions.ready = true
+	_captionsLock.Unlock()
+
+	for _, segmentPath := range written {
+		storage.SegmentWritten(segmentPath)
+	}
+	storage.VariantPlaylistWritten(playlistPath)
+
+	if !wasReady {
+		writeMasterPlaylist()
+	}
+}
+
+// update will write a caption segment for each segment new to the
+// playlist, returning their paths.
+func (c *captionTrack) update(playlist *m3u8.MediaPlaylist, directory string) []string {
+	written := []string{}
+
+	for index, segment := range playlist.Segments {
+		if segment == nil {
+			break
+		}
+
+		sequence := playlist.SeqNo + uint64(index)
+		if sequence < c.nextSequence {
+			continue
+		}
+
+		captionSegment := captionSegment{
+			sequence: sequence,
+			uri:      strings.TrimSuffix(path.Base(segment.URI), path.Ext(segment.URI)) + ".vtt",
+			start:    c.streamTime,
+			duration: segment.Duration,
+		}
+		c.streamTime += segment.Duration
+		c.nextSequence = sequence + 1
+		c.segments = append(c.segments, captionSegment)
+
+		segmentPath := filepath.Join(directory, captionSegment.uri)
+		content := getCaptionSegment(c.cues, captionSegment.start, captionSegment.start+captionSegment.duration)
+		if err := ioutil.WriteFile(segmentPath, []byte(content), 0600); err != nil {
+			log.Errorln(err)
+			continue
+		}
+		written = append(written, segmentPath)
+	}
+
+	// Only the segments still in the variant's playlist are listed.
+	for len(c.segments) > 0 && c.segments[0].sequence < playlist.SeqNo {
+		c.segments = c.segments[1:]
+	}
+	c.targetDuration = playlist.TargetDuration
+
+	// Cues that end before the segments still listed are no longer needed.
+	if len(c.segments) > 0 {
+		cues := c.cues[:0]
+		for _, cue := range c.cues {
+			if cue.end > c.segments[0].start {
+				cues = append(cues, cue)
+			}
+		}
+		c.cues = cues
+	}
+
+	return written
+}
+
+func (c *captionTrack) getPlaylist() string {
+	firstSequence := c.nextSequence
+	if len(c.segments) > 0 {
+		firstSequence = c.segments[0].sequence
+	}
+
+	lines := []string{
+		"#EXTM3U",
+		"#EXT-X-VERSION:3",
+		fmt.Sprintf("#EXT-X-TARGETDURATION:%d", int(math.Ceil(c.targetDuration))),
+		fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d", firstSequence),
+	}
+	for _, segment := range c.segments {
+		lines = append(lines, fmt.Sprintf("#EXTINF:%.3f,", segment.duration), segment.uri)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// getCaptionSegment will return a WebVTT segment with the cues shown
+// between start and end. Cues shown across segments are in each of them.
+func getCaptionSegment(cues []captionCue, start float64, end float64) string {
+	lines := []string{"WEBVTT", captionsTimestampMap, ""}
+
+	for _, cue := range cues {
+		if cue.end <= start || cue.start >= end {
+			continue
+		}
+
+		lines = append(lines, formatCaptionTimestamp(cue.start)+" --> "+formatCaptionTimestamp(cue.end), cue.text, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// getCaptionText will return the text of a cue with the lines and markup
+// WebVTT would read as the end of the cue or as styling removed.
+func getCaptionText(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(strings.Join(lines, "\n"))
+}
+
+func formatCaptionTimestamp(seconds float64) string {
+	milliseconds := int64(math.Round(math.Max(seconds, 0) * 1000))
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		milliseconds/3600000,
+		milliseconds/60000%60,
+		milliseconds/1000%60,
+		milliseconds%1000,
+	)
+}
@@ -0,0 +1,92 @@
+package transcoder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafov/m3u8"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestCaptionSegments(t *testing.T) {
+	directory, err := ioutil.TempDir("", "captions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	playlist, err := m3u8.NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	playlist.TargetDuration = 4
+	for _, uri := range []string{"stream-abc-1.ts", "stream-abc-2.ts"} {
+		if err := playlist.Append(uri, 4, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	track := &captionTrack{
+		cues: []captionCue{
+			{start: 1, end: 2.5, text: getCaptionText("Hello <there> &\r\n\r\n  friends")},
+			{start: 3.5, end: 5, text: "Across segments"},
+		},
+	}
+
+	written := track.update(playlist, directory)
+	if len(written) != 2 {
+		t.Fatalf("expected 2 caption segments, got %d", len(written))
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(directory, "stream-abc-1.vtt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "WEBVTT\n" + captionsTimestampMap + "\n\n" +
+		"00:00:01.000 --> 00:00:02.500\nHello &lt;there&gt; &amp;\nfriends\n\n" +
+		"00:00:03.500 --> 00:00:05.000\nAcross segments\n"
+	if string(content) != expected {
+		t.Errorf("caption segment does not match expected.\nGot %s\n, want: %s", content, expected)
+	}
+
+	content, err = ioutil.ReadFile(filepath.Join(directory, "stream-abc-2.vtt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "Hello") || !strings.Contains(string(content), "Across segments") {
+		t.Errorf("second caption segment has the wrong cues: %s", content)
+	}
+
+	expectedPlaylist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+		"#EXTINF:4.000,\nstream-abc-1.vtt\n#EXTINF:4.000,\nstream-abc-2.vtt\n"
+	if track.getPlaylist() != expectedPlaylist {
+		t.Errorf("captions playlist does not match expected.\nGot %s\n, want: %s", track.getPlaylist(), expectedPlaylist)
+	}
+
+	// Segments already written are not written again.
+	if written := track.update(playlist, directory); len(written) != 0 {
+		t.Errorf("expected no new caption segments, got %d", len(written))
+	}
+}
+
+func TestMasterPlaylistCaptions(t *testing.T) {
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\"\n0/stream.m3u8\n"
+
+	if getMasterPlaylistWithCaptions(playlist, nil) != playlist {
+		t.Error("master playlist without captions should not be changed")
+	}
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"captions\",NAME=\"Captions\",LANGUAGE=\"en\",DEFAULT=NO,AUTOSELECT=YES,FORCED=NO,URI=\"captions/stream.m3u8\"\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\",SUBTITLES=\"captions\"\n0/stream.m3u8\n"
+
+	if updated := getMasterPlaylistWithCaptions(playlist, &models.Captions{Enabled: true, Language: "en"}); updated != expected {
+		t.Errorf("master playlist does not match expected.\nGot %s\n, want: %s", updated, expected)
+	}
+}
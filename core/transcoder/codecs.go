@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/owncast/owncast/models"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,6 +28,8 @@ var supportedCodecs = map[string]string{
 	(&OmxCodec{}).Name():     "omx",
 	(&VaapiCodec{}).Name():   "vaapi",
 	(&NvencCodec{}).Name():   "NVIDIA nvenc",
+	(&Libx265Codec{}).Name(): "libx265",
+	(&SvtAv1Codec{}).Name():  "SVT-AV1",
 }
 
 type Libx264Codec struct {
@@ -62,9 +65,9 @@ func (c *Libx264Codec) VariantFlags(v *HLSVariant) string {
 	bufferSize := int(float64(v.videoBitrate) * 1.2) // How often it checks the bitrate of encoded segments to see if it's too high/low.
 
 	return strings.Join([]string{
-		fmt.Sprintf("-x264-params:v:%d \"scenecut=0:open_gop=0\"", v.index), // How often the encoder checks the bitrate in order to meet average/max values
-		fmt.Sprintf("-bufsize:v:%d %dk", v.index, bufferSize),
-		fmt.Sprintf("-profile:v:%d %s", v.index, "high"), // Encoding profile
+		fmt.Sprintf("-x264-params:v:%d \"scenecut=0:open_gop=0\"", v.videoIndex), // How often the encoder checks the bitrate in order to meet average/max values
+		fmt.Sprintf("-bufsize:v:%d %dk", v.videoIndex, bufferSize),
+		fmt.Sprintf("-profile:v:%d %s", v.videoIndex, "high"), // Encoding profile
 	}, " ")
 }
 
@@ -217,7 +220,7 @@ func (c *NvencCodec) ExtraFilters() string {
 
 func (c *NvencCodec) VariantFlags(v *HLSVariant) string {
 	tuning := "ll" // low latency
-	return fmt.Sprintf("-tune:v:%d %s", v.index, tuning)
+	return fmt.Sprintf("-tune:v:%d %s", v.videoIndex, tuning)
 }
 
 func (c *NvencCodec) GetPresetForLevel(l int) string {
@@ -329,6 +332,115 @@ func (c *Video4Linux) GetPresetForLevel(l int) string {
 	return presetMapping[l]
 }
 
+type Libx265Codec struct {
+}
+
+func (c *Libx265Codec) Name() string {
+	return "libx265"
+}
+
+func (c *Libx265Codec) DisplayName() string {
+	return "x265"
+}
+
+func (c *Libx265Codec) GlobalFlags() string {
+	return ""
+}
+
+func (c *Libx265Codec) PixelFormat() string {
+	return "yuv420p"
+}
+
+func (c *Libx265Codec) ExtraArguments() string {
+	return strings.Join([]string{
+		"-tune", "zerolatency",
+	}, " ")
+}
+
+func (c *Libx265Codec) ExtraFilters() string {
+	return ""
+}
+
+func (c *Libx265Codec) VariantFlags(v *HLSVariant) string {
+	bufferSize := int(float64(v.videoBitrate) * 1.2)
+
+	return strings.Join([]string{
+		fmt.Sprintf("-x265-params:v:%d \"scenecut=0:open-gop=0:log-level=warning\"", v.videoIndex),
+		fmt.Sprintf("-bufsize:v:%d %dk", v.videoIndex, bufferSize),
+		fmt.Sprintf("-profile:v:%d %s", v.videoIndex, "main"),
+		fmt.Sprintf("-tag:v:%d hvc1", v.videoIndex), // Apple devices only play HEVC tagged as hvc1
+	}, " ")
+}
+
+func (c *Libx265Codec) GetPresetForLevel(l int) string {
+	presetMapping := []string{
+		"ultrafast",
+		"superfast",
+		"veryfast",
+		"faster",
+		"fast",
+	}
+
+	if l >= len(presetMapping) {
+		return "superfast"
+	}
+
+	return presetMapping[l]
+}
+
+type SvtAv1Codec struct {
+}
+
+func (c *SvtAv1Codec) Name() string {
+	return "libsvtav1"
+}
+
+func (c *SvtAv1Codec) DisplayName() string {
+	return "SVT-AV1"
+}
+
+func (c *SvtAv1Codec) GlobalFlags() string {
+	return ""
+}
+
+func (c *SvtAv1Codec) PixelFormat() string {
+	return "yuv420p"
+}
+
+func (c *SvtAv1Codec) ExtraArguments() string {
+	return ""
+}
+
+func (c *SvtAv1Codec) ExtraFilters() string {
+	return ""
+}
+
+func (c *SvtAv1Codec) VariantFlags(v *HLSVariant) string {
+	bufferSize := int(float64(v.videoBitrate) * 1.2)
+
+	return strings.Join([]string{
+		fmt.Sprintf("-svtav1-params:v:%d \"scd=0\"", v.videoIndex), // Keyframes only where the segments start
+		fmt.Sprintf("-bufsize:v:%d %dk", v.videoIndex, bufferSize),
+	}, " ")
+}
+
+// SVT-AV1 presets are numbered, with higher numbers encoding faster.
+func (c *SvtAv1Codec) GetPresetForLevel(l int) string {
+	presetMapping := []string{
+		"12",
+		"11",
+		"10",
+		"9",
+		"8",
+	}
+
+	if l >= len(presetMapping) {
+		return "11"
+	}
+
+	return presetMapping[l]
+}
+
 // GetCodecs will return the supported codecs available on the system.
 func GetCodecs(ffmpegPath string) []string {
 	codecs := make([]string, 0)
@@ -343,7 +455,7 @@ func GetCodecs(ffmpegPath string) []string {
 	response := string(out)
 	lines := strings.Split(response, "\n")
 	for _, line := range lines {
-		if strings.Contains(line, "H.264") {
+		if strings.Contains(line, "H.264") || strings.Contains(line, "HEVC") || strings.Contains(line, "AV1") {
 			fields := strings.Fields(line)
 			codec := fields[1]
 			if _, supported := supportedCodecs[codec]; supported {
@@ -367,7 +479,128 @@ func getCodec(name string) Codec {
 		return &OmxCodec{}
 	case (&Video4Linux{}).Name():
 		return &Video4Linux{}
+	case (&Libx265Codec{}).Name():
+		return &Libx265Codec{}
+	case (&SvtAv1Codec{}).Name():
+		return &SvtAv1Codec{}
 	default:
 		return &Libx264Codec{}
 	}
 }
+
+// codecLevel is a level of a video codec and the largest picture size,
+// sample rate and bitrate, in kbps, its main tier allows.
+type codecLevel struct {
+	tag            string
+	maxPictureSize int
+	maxSampleRate  int
+	maxBitrate     int
+}
+
+// The HEVC levels, tagged with their general_level_idc.
+var hevcLevels = []codecLevel{
+	{"L30", 36864, 552960, 128},
+	{"L60", 122880, 3686400, 1500},
+	{"L63", 245760, 7372800, 3000},
+	{"L90", 552960, 16588800, 6000},
+	{"L93", 983040, 33177600, 10000},
+	{"L120", 2228224, 66846720, 12000},
+	{"L123", 2228224, 133693440, 20000},
+	{"L150", 8912896, 267386880, 25000},
+	{"L153", 8912896, 534773760, 40000},
+	{"L156", 8912896, 1069547520, 60000},
+	{"L180", 35651584, 1069547520, 60000},
+	{"L183", 35651584, 2139095040, 120000},
+	{"L186", 35651584, 4278190080, 240000},
+}
+
+// The AV1 levels, tagged with their seq_level_idx.
+var av1Levels = []codecLevel{
+	{"00", 147456, 4423680, 1500},
+	{"01", 278784, 8363520, 3000},
+	{"04", 665856, 19975680, 6000},
+	{"05", 1065024, 31950720, 10000},
+	{"08", 2359296, 70778880, 12000},
+	{"09", 2359296, 141557760, 20000},
+	{"12", 8912896, 267386880, 30000},
+	{"13", 8912896, 534773760, 40000},
+	{"14", 8912896, 1069547520, 60000},
+	{"16", 35651584, 1069547520, 60000},
+	{"17", 35651584, 2139095040, 100000},
+	{"18", 35651584, 4278190080, 160000},
+}
+
+// getCodecTag will return the HLS CODECS attribute value for the video a
+// codec outputs for a variant, for when the transcoder doesn't write one
+// itself.
+func getCodecTag(codec Codec, v *HLSVariant) string {
+	switch codec.(type) {
+	case *Libx265Codec:
+		return "hvc1.1.6." + getVariantLevel(hevcLevels, v) + ".90" // Main profile
+	case *SvtAv1Codec:
+		return "av01.0." + getVariantLevel(av1Levels, v) + "M.08" // Main profile, 8 bit
+	default:
+		return "avc1.640028" // High profile, level 4
+	}
+}
+
+// getVariantLevel will return the lowest of the levels that allows the size,
+// framerate and bitrate of a variant's video.
+func getVariantLevel(levels []codecLevel, v *HLSVariant) string {
+	width, height := getVariantPictureSize(v)
+	pictureSize := width * height
+	sampleRate := pictureSize * v.framerate
+	maxBitrate := int(float64(v.videoBitrate) * 1.06)
+
+	for _, level := range levels {
+		if pictureSize <= level.maxPictureSize && sampleRate <= level.maxSampleRate && maxBitrate <= level.maxBitrate {
+			return level.tag
+		}
+	}
+
+	return levels[len(levels)-1].tag
+}
+
+// getVariantPictureSize will return the size of a variant's video. A
+// dimension left to keep the aspect ratio is assumed to be 16:9, and an
+// unscaled video is assumed to be 1080p.
+func getVariantPictureSize(v *HLSVariant) (int, int) {
+	width, height := v.videoSize.Width, v.videoSize.Height
+
+	switch {
+	case width > 0 && height > 0:
+		return width, height
+	case width > 0:
+		return width, width * 9 / 16
+	case height > 0:
+		return height * 16 / 9, height
+	default:
+		return 1920, 1080
+	}
+}
+
+// getAudioCodecTag will return the HLS CODECS attribute value for the audio
+// an audio codec outputs.
+func getAudioCodecTag(audioCodec string) string {
+	if audioCodec == models.AudioCodecOpus {
+		return "Opus"
+	}
+
+	return "mp4a.40.2"
+}
+
+// requiresFMP4Segments will return if the video a codec outputs can only be
+// delivered in fMP4 segments.
+func requiresFMP4Segments(codec Codec) bool {
+	switch codec.(type) {
+	case *Libx265Codec, *SvtAv1Codec:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsValidCodec will return if the codec is one video can be encoded with.
+func IsValidCodec(codecName string) bool {
+	return getCodec(codecName).Name() == codecName
+}
@@ -0,0 +1,413 @@
+package transcoder
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafov/m3u8"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/models"
+)
+
+// DASHManifestFilename is the name of the MPEG-DASH manifest written
+// alongside the HLS master playlist.
+const DASHManifestFilename = "stream.mpd"
+
+// The MPEG-DASH manifest is built from the same fMP4 segments as the HLS
+// playlists, so both can be played from a single transcoder.
+var (
+	_dashManifest *dashManifest
+	_dashLock     sync.Mutex
+)
+
+type dashManifest struct {
+	outputSettings        []models.StreamOutputVariant
+	segmentDuration       int
+	representations       map[int]*dashRepresentation
+	availabilityStartTime time.Time
+}
+
+// dashRepresentation keeps what has been read from the segments of a single
+// variant so each file is only read once.
+type dashRepresentation struct {
+	track                  fmp4Track
+	initSegment            string
+	presentationTimeOffset uint64
+	hasOffset              bool
+	decodeTimes            map[string]uint64
+}
+
+type dashSegment struct {
+	uri      string
+	time     uint64
+	duration uint64
+}
+
+type mpd struct {
+	XMLName                    xml.Name  `xml:"MPD"`
+	Namespace                  string    `xml:"xmlns,attr"`
+	Profiles                   string    `xml:"profiles,attr"`
+	Type                       string    `xml:"type,attr"`
+	AvailabilityStartTime      string    `xml:"availabilityStartTime,attr"`
+	PublishTime                string    `xml:"publishTime,attr"`
+	MinimumUpdatePeriod        string    `xml:"minimumUpdatePeriod,attr"`
+	MinBufferTime              string    `xml:"minBufferTime,attr"`
+	TimeShiftBufferDepth       string    `xml:"timeShiftBufferDepth,attr"`
+	SuggestedPresentationDelay string    `xml:"suggestedPresentationDelay,attr"`
+	Period                     mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	ID            string           `xml:"id,attr"`
+	Start         string           `xml:"start,attr"`
+	AdaptationSet mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	ID               int                 `xml:"id,attr"`
+	MimeType         string              `xml:"mimeType,attr"`
+	SegmentAlignment bool                `xml:"segmentAlignment,attr"`
+	StartWithSAP     int                 `xml:"startWithSAP,attr"`
+	Representations  []mpdRepresentation `xml:"Representation"`
+}
+
+type mpdRepresentation struct {
+	ID          string         `xml:"id,attr"`
+	Bandwidth   int            `xml:"bandwidth,attr"`
+	Codecs      string         `xml:"codecs,attr,omitempty"`
+	Width       int            `xml:"width,attr,omitempty"`
+	Height      int            `xml:"height,attr,omitempty"`
+	FrameRate   int            `xml:"frameRate,attr,omitempty"`
+	SegmentList mpdSegmentList `xml:"SegmentList"`
+}
+
+type mpdSegmentList struct {
+	Timescale              uint32            `xml:"timescale,attr"`
+	PresentationTimeOffset uint64            `xml:"presentationTimeOffset,attr"`
+	Initialization         mpdInitialization `xml:"Initialization"`
+	Timeline               []mpdSegmentTime  `xml:"SegmentTimeline>S"`
+	SegmentURLs            []mpdSegmentURL   `xml:"SegmentURL"`
+}
+
+type mpdInitialization struct {
+	SourceURL string `xml:"sourceURL,attr"`
+}
+
+type mpdSegmentTime struct {
+	Time     uint64 `xml:"t,attr"`
+	Duration uint64 `xml:"d,attr"`
+}
+
+type mpdSegmentURL struct {
+	Media string `xml:"media,attr"`
+}
+
+// startDASHManifest will set up the manifest for a new run of the
+// transcoder, or remove it if it is not enabled.
+func startDASHManifest(enabled bool, outputSettings []models.StreamOutputVariant, latencyLevel models.LatencyLevel) {
+	_dashLock.Lock()
+	defer _dashLock.Unlock()
+
+	if !enabled {
+		_dashManifest = nil
+		return
+	}
+
+	_dashManifest = &dashManifest{
+		outputSettings:  outputSettings,
+		segmentDuration: latencyLevel.SecondsPerSegment,
+		representations: make(map[int]*dashRepresentation),
+	}
+}
+
+// updateDASHManifest will write the manifest from the current HLS playlists
+// and let the storage provider know it was written.
+func updateDASHManifest(storage models.StorageProvider) {
+	_dashLock.Lock()
+	defer _dashLock.Unlock()
+
+	if _dashManifest == nil {
+		return
+	}
+
+	manifest, ok := _dashManifest.build(config.PrivateHLSStoragePath, time.Now())
+	if !ok {
+		return
+	}
+
+	manifestPath := filepath.Join(config.PrivateHLSStoragePath, DASHManifestFilename)
+	if err := ioutil.WriteFile(manifestPath, manifest, 0600); err != nil {
+		log.Errorln("unable to write dash manifest", err)
+		return
+	}
+
+	storage.DASHManifestWritten(manifestPath)
+}
+
+// build will return the manifest of the variants in the HLS master playlist
+// within directory, and false if nothing can be played yet.
+func (m *dashManifest) build(directory string, now time.Time) ([]byte, bool) {
+	f, err := os.Open(filepath.Join(directory, "stream.m3u8")) // nolint
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	master := m3u8.NewMasterPlaylist()
+	if err := master.DecodeFrom(bufio.NewReader(f), false); err != nil {
+		log.Debugln("unable to read master playlist for dash manifest", err)
+		return nil, false
+	}
+
+	adaptationSet := mpdAdaptationSet{
+		MimeType:         "video/mp4",
+		SegmentAlignment: true,
+		StartWithSAP:     1,
+	}
+
+	// Variants without video are only listed when there is no video at all.
+	audioOnly := m.isAudioOnly()
+	if audioOnly {
+		adaptationSet.MimeType = "audio/mp4"
+	}
+
+	var bufferDepth float64
+	for _, variant := range master.Variants {
+		index, err := strconv.Atoi(filepath.Dir(variant.URI))
+		if err != nil {
+			continue
+		}
+
+		if index < len(m.outputSettings) && m.outputSettings[index].IsAudioOnly != audioOnly {
+			continue
+		}
+
+		segments, duration, ok := m.getSegments(directory, index)
+		if !ok || len(segments) == 0 {
+			continue
+		}
+
+		representation := m.getRepresentation(index, variant, segments)
+		adaptationSet.Representations = append(adaptationSet.Representations, representation)
+
+		bufferDepth = math.Max(bufferDepth, duration)
+
+		// Media time presentationTimeOffset is when the stream became available.
+		if m.availabilityStartTime.IsZero() {
+			first := segments[0]
+			rep := m.representations[index]
+			firstDuration := time.Duration(float64(first.duration) / float64(rep.track.timescale) * float64(time.Second))
+			m.availabilityStartTime = now.Add(-firstDuration)
+		}
+	}
+
+	if len(adaptationSet.Representations) == 0 {
+		return nil, false
+	}
+
+	manifest := mpd{
+		Namespace:                  "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:                   "urn:mpeg:dash:profile:isoff-main:2011",
+		Type:                       "dynamic",
+		AvailabilityStartTime:      m.availabilityStartTime.UTC().Format(time.RFC3339),
+		PublishTime:                now.UTC().Format(time.RFC3339),
+		MinimumUpdatePeriod:        formatDASHDuration(float64(m.segmentDuration)),
+		MinBufferTime:              formatDASHDuration(float64(m.segmentDuration)),
+		TimeShiftBufferDepth:       formatDASHDuration(math.Ceil(bufferDepth)),
+		SuggestedPresentationDelay: formatDASHDuration(float64(m.segmentDuration * 3)),
+		Period: mpdPeriod{
+			ID:            "0",
+			Start:         "PT0S",
+			AdaptationSet: adaptationSet,
+		},
+	}
+
+	content, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Errorln("unable to create dash manifest", err)
+		return nil, false
+	}
+
+	return append([]byte(xml.Header), content...), true
+}
+
+// isAudioOnly will return if none of the variants have video.
+func (m *dashManifest) isAudioOnly() bool {
+	for _, quality := range m.outputSettings {
+		if !quality.IsAudioOnly {
+			return false
+		}
+	}
+
+	return len(m.outputSettings) > 0
+}
+
+// getSegments will return the segments listed in a variant playlist with
+// their times read from the segments themselves, and their total duration.
+func (m *dashManifest) getSegments(directory string, index int) ([]dashSegment, float64, bool) {
+	variantDirectory := filepath.Join(directory, strconv.Itoa(index))
+
+	f, err := os.Open(filepath.Join(variantDirectory, "stream.m3u8")) // nolint
+	if err != nil {
+		return nil, 0, false
+	}
+	defer f.Close()
+
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != m3u8.MEDIA {
+		return nil, 0, false
+	}
+
+	playlist := p.(*m3u8.MediaPlaylist)
+	if playlist.Map == nil {
+		return nil, 0, false
+	}
+
+	rep, ok := m.representations[index]
+	if !ok || rep.initSegment != playlist.Map.URI {
+		initSegment, err := ioutil.ReadFile(filepath.Join(variantDirectory, filepath.Base(playlist.Map.URI))) // nolint
+		if err != nil {
+			return nil, 0, false
+		}
+
+		track, ok := getInitSegmentTrack(initSegment)
+		if !ok {
+			log.Warnln("unable to read the tracks of", playlist.Map.URI)
+			return nil, 0, false
+		}
+
+		rep = &dashRepresentation{
+			track:       track,
+			initSegment: playlist.Map.URI,
+			decodeTimes: make(map[string]uint64),
+		}
+		m.representations[index] = rep
+	}
+
+	var segments []dashSegment
+	var totalDuration float64
+	decodeTimes := make(map[string]uint64)
+
+	for _, segment := range playlist.Segments {
+		if segment == nil {
+			continue
+		}
+
+		decodeTime, ok := rep.decodeTimes[segment.URI]
+		if !ok {
+			content, err := ioutil.ReadFile(filepath.Join(variantDirectory, filepath.Base(segment.URI))) // nolint
+			if err != nil {
+				continue
+			}
+
+			if decodeTime, ok = getSegmentDecodeTime(content, rep.track.id); !ok {
+				continue
+			}
+		}
+		decodeTimes[segment.URI] = decodeTime
+
+		if !rep.hasOffset {
+			rep.presentationTimeOffset = decodeTime
+			rep.hasOffset = true
+		}
+
+		// Anything from before the offset can't be placed on the timeline.
+		if decodeTime < rep.presentationTimeOffset {
+			continue
+		}
+
+		segments = append(segments, dashSegment{
+			uri:      segment.URI,
+			time:     decodeTime,
+			duration: uint64(math.Round(segment.Duration * float64(rep.track.timescale))),
+		})
+		totalDuration += segment.Duration
+	}
+
+	// Forget the times of segments no longer in the playlist.
+	rep.decodeTimes = decodeTimes
+
+	// Segments follow on from each other, so the time between them is used
+	// instead of the rounded playlist durations.
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i+1].time > segments[i].time {
+			segments[i].duration = segments[i+1].time - segments[i].time
+		}
+	}
+
+	return segments, totalDuration, true
+}
+
+func (m *dashManifest) getRepresentation(index int, variant *m3u8.Variant, segments []dashSegment) mpdRepresentation {
+	rep := m.representations[index]
+	prefix := strconv.Itoa(index) + "/"
+
+	representation := mpdRepresentation{
+		ID:        strconv.Itoa(index),
+		Bandwidth: int(variant.Bandwidth),
+		Codecs:    variant.Codecs,
+		SegmentList: mpdSegmentList{
+			Timescale:              rep.track.timescale,
+			PresentationTimeOffset: rep.presentationTimeOffset,
+			Initialization:         mpdInitialization{SourceURL: prefix + filepath.Base(rep.initSegment)},
+		},
+	}
+
+	if width, height, ok := parseResolution(variant.Resolution); ok {
+		representation.Width = width
+		representation.Height = height
+	}
+
+	if index < len(m.outputSettings) {
+		outputSettings := m.outputSettings[index]
+		representation.FrameRate = outputSettings.GetFramerate()
+		if representation.Bandwidth == 0 {
+			representation.Bandwidth = (outputSettings.VideoBitrate + outputSettings.AudioBitrate) * 1000
+		}
+	}
+
+	for _, segment := range segments {
+		representation.SegmentList.Timeline = append(representation.SegmentList.Timeline, mpdSegmentTime{
+			Time:     segment.time,
+			Duration: segment.duration,
+		})
+		representation.SegmentList.SegmentURLs = append(representation.SegmentList.SegmentURLs, mpdSegmentURL{
+			Media: prefix + filepath.Base(segment.uri),
+		})
+	}
+
+	return representation
+}
+
+func parseResolution(resolution string) (int, int, bool) {
+	components := strings.Split(resolution, "x")
+	if len(components) != 2 {
+		return 0, 0, false
+	}
+
+	width, err := strconv.Atoi(components[0])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	height, err := strconv.Atoi(components[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return width, height, true
+}
+
+func formatDASHDuration(seconds float64) string {
+	return "PT" + strconv.FormatFloat(seconds, 'f', -1, 64) + "S"
+}
@@ -0,0 +1,107 @@
+package transcoder
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owncast/owncast/models"
+)
+
+func newTestBox(boxType string, payload ...[]byte) []byte {
+	var content []byte
+	for _, p := range payload {
+		content = append(content, p...)
+	}
+
+	box := make([]byte, 8, 8+len(content))
+	binary.BigEndian.PutUint32(box[0:4], uint32(8+len(content)))
+	copy(box[4:8], boxType)
+
+	return append(box, content...)
+}
+
+func newTestTrack(id uint32, timescale uint32, handler string) []byte {
+	tkhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(tkhd[12:16], id)
+
+	mdhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(mdhd[12:16], timescale)
+
+	hdlr := make([]byte, 12)
+	copy(hdlr[8:12], handler)
+
+	return newTestBox("trak", newTestBox("tkhd", tkhd), newTestBox("mdia", newTestBox("mdhd", mdhd), newTestBox("hdlr", hdlr)))
+}
+
+func newTestMediaSegment(trackID uint32, decodeTime uint64) []byte {
+	tfhd := make([]byte, 8)
+	binary.BigEndian.PutUint32(tfhd[4:8], trackID)
+
+	tfdt := make([]byte, 12)
+	tfdt[0] = 1
+	binary.BigEndian.PutUint64(tfdt[4:12], decodeTime)
+
+	audio := newTestBox("traf", newTestBox("tfhd", []byte{0, 0, 0, 0, 0, 0, 0, 2}), newTestBox("tfdt", []byte{0, 0, 0, 0, 0, 0, 0, 1}))
+	video := newTestBox("traf", newTestBox("tfhd", tfhd), newTestBox("tfdt", tfdt))
+
+	return append(newTestBox("moof", audio, video), newTestBox("mdat", []byte{0})...)
+}
+
+func TestDASHManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "owncast-dash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "0"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"stream.m3u8": []byte("#EXTM3U\n#EXT-X-VERSION:7\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\"\n0/stream.m3u8\n"),
+		"0/stream.m3u8": []byte("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:3\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+			"#EXT-X-MAP:URI=\"stream-abc-init.mp4\"\n" +
+			"#EXTINF:3.000000,\nstream-abc1.m4s\n#EXTINF:3.000000,\nstream-abc2.m4s\n"),
+		"0/stream-abc-init.mp4": newTestBox("moov", newTestTrack(2, 48000, "soun"), newTestTrack(1, 90000, "vide")),
+		"0/stream-abc1.m4s":     newTestMediaSegment(1, 900000),
+		"0/stream-abc2.m4s":     newTestMediaSegment(1, 1170000),
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &dashManifest{
+		outputSettings:  []models.StreamOutputVariant{{VideoBitrate: 1200, Framerate: 30}},
+		segmentDuration: 3,
+		representations: make(map[int]*dashRepresentation),
+	}
+
+	manifest, ok := m.build(dir, time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("manifest was not built")
+	}
+
+	for _, expected := range []string{
+		`type="dynamic" availabilityStartTime="2021-05-01T11:59:57Z" publishTime="2021-05-01T12:00:00Z"`,
+		`<Representation id="0" bandwidth="1400000" codecs="avc1.64001f,mp4a.40.2" width="1280" height="720" frameRate="30">`,
+		`<SegmentList timescale="90000" presentationTimeOffset="900000">`,
+		`<Initialization sourceURL="0/stream-abc-init.mp4"></Initialization>`,
+		`<S t="900000" d="270000"></S>`,
+		`<S t="1170000" d="270000"></S>`,
+		`<SegmentURL media="0/stream-abc2.m4s"></SegmentURL>`,
+	} {
+		if !strings.Contains(string(manifest), expected) {
+			t.Errorf("manifest does not contain %s.\nGot %s", expected, manifest)
+		}
+	}
+}
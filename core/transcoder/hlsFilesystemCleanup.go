@@ -8,15 +8,24 @@ import (
 	"sort"
 
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/utils"
 )
 
 // CleanupOldContent will delete old files from the private dir that are no longer being referenced
 // in the stream.
 func CleanupOldContent(baseDirectory string) {
 	// Determine how many files we should keep on disk
-	maxNumber := data.GetStreamLatencyLevel().SegmentCount
+	latencyLevel := data.GetStreamLatencyLevel()
+	maxNumber := latencyLevel.SegmentCount
 	buffer := 10
 
+	// Low-latency HLS keeps each partial segment alongside the full segment.
+	if latencyLevel.IsLowLatencyHLS() {
+		filesPerSegment := latencyLevel.GetPartsPerSegment() + 1
+		maxNumber *= filesPerSegment
+		buffer *= filesPerSegment
+	}
+
 	files, err := getAllFilesRecursive(baseDirectory)
 	if err != nil {
 		log.Errorln("Unable to cleanup old video files", err)
@@ -56,7 +65,7 @@ func getAllFilesRecursive(baseDirectory string) (map[string][]os.FileInfo, error
 			directory = info.Name()
 		}
 
-		if filepath.Ext(info.Name()) == ".ts" {
+		if utils.IsVideoSegment(info.Name()) || utils.IsCaptionSegment(info.Name()) {
 			files[directory] = append(files[directory], info)
 		}
 
@@ -1,25 +1,65 @@
 package transcoder
 
 import (
+	"sync"
+
 	"github.com/owncast/owncast/models"
 )
 
 // HLSHandler gets told about available HLS playlists and segments.
 type HLSHandler struct {
 	Storage models.StorageProvider
+
+	recorder     *HLSRecorder
+	recorderLock sync.Mutex
+}
+
+// SetRecorder will set the recorder the variant playlists are passed to, or
+// stop passing them on if it is nil. A playlist being recorded when it is
+// changed is finished first.
+func (h *HLSHandler) SetRecorder(recorder *HLSRecorder) {
+	h.recorderLock.Lock()
+	defer h.recorderLock.Unlock()
+
+	h.recorder = recorder
+}
+
+// GetRecorder will return the recorder the variant playlists are passed to.
+func (h *HLSHandler) GetRecorder() *HLSRecorder {
+	h.recorderLock.Lock()
+	defer h.recorderLock.Unlock()
+
+	return h.recorder
 }
 
 // SegmentWritten is fired when a HLS segment is written to disk.
 func (h *HLSHandler) SegmentWritten(localFilePath string) {
+	timedMetadataSegmentWritten(localFilePath)
 	h.Storage.SegmentWritten(localFilePath)
 }
 
 // VariantPlaylistWritten is fired when a HLS variant playlist is written to disk.
 func (h *HLSHandler) VariantPlaylistWritten(localFilePath string) {
+	h.recorderLock.Lock()
+	if h.recorder != nil {
+		h.recorder.VariantPlaylistWritten(localFilePath)
+	}
+	h.recorderLock.Unlock()
+
+	// Low-latency playlists are written in place of the transcoder's own.
+	if playlist := getLowLatencyPlaylistForPath(localFilePath); playlist != nil {
+		playlist.update(localFilePath, h.Storage.SegmentWritten)
+		return
+	}
+
 	h.Storage.VariantPlaylistWritten(localFilePath)
+	mediaSequencePlaylistWritten(localFilePath)
+	audioTrackPlaylistWritten(localFilePath)
+	captionsPlaylistWritten(localFilePath, h.Storage)
+	updateDASHManifest(h.Storage)
 }
 
 // MasterPlaylistWritten is fired when a HLS master playlist is written to disk.
 func (h *HLSHandler) MasterPlaylistWritten(localFilePath string) {
-	h.Storage.MasterPlaylistWritten(localFilePath)
+	masterPlaylistWritten(localFilePath, h.Storage)
 }
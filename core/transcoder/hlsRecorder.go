@@ -0,0 +1,203 @@
+package transcoder
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/grafov/m3u8"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/playlist"
+	"github.com/owncast/owncast/utils"
+)
+
+// RecordingPlaylistFilename is the name of the playlist written alongside the recorded segments.
+const RecordingPlaylistFilename = "stream.m3u8"
+
+type recordedSegment struct {
+	filename string
+	duration float64
+}
+
+// HLSRecorder keeps a persisted copy of every segment of a single stream
+// variant so it outlives the cleanup of the live HLS directories.
+type HLSRecorder struct {
+	directory    string
+	variantIndex string
+	segments     []recordedSegment
+	initSegment  string
+	recorded     map[string]bool
+	finished     bool
+
+	lock sync.Mutex
+}
+
+// NewHLSRecorder will return a recorder writing the variant at variantIndex to directory.
+func NewHLSRecorder(directory string, variantIndex int) (*HLSRecorder, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, err
+	}
+
+	return &HLSRecorder{
+		directory:    directory,
+		variantIndex: strconv.Itoa(variantIndex),
+		recorded:     make(map[string]bool),
+	}, nil
+}
+
+// VariantPlaylistWritten will copy any segments referenced in a variant
+// playlist that have not yet been recorded.
+func (r *HLSRecorder) VariantPlaylistWritten(localFilePath string) {
+	if utils.GetIndexFromFilePath(localFilePath) != r.variantIndex {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.finished {
+		return
+	}
+
+	f, err := os.Open(localFilePath)
+	if err != nil {
+		log.Warnln(err)
+		return
+	}
+	defer f.Close()
+
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != m3u8.MEDIA {
+		log.Debugln("unable to read variant playlist for recording", localFilePath, err)
+		return
+	}
+
+	mediaPlaylist := p.(*m3u8.MediaPlaylist)
+	hasChanges := false
+
+	// fMP4 segments need the initialization segment they were written with.
+	if mediaPlaylist.Map != nil && r.initSegment == "" {
+		filename := filepath.Base(mediaPlaylist.Map.URI)
+		source := filepath.Join(filepath.Dir(localFilePath), filename)
+		if err := utils.Copy(source, filepath.Join(r.directory, filename)); err != nil {
+			log.Warnln("unable to record initialization segment", source, err)
+			return
+		}
+
+		r.initSegment = filename
+		hasChanges = true
+	}
+
+	for _, segment := range mediaPlaylist.Segments {
+		if segment == nil || r.recorded[segment.URI] {
+			continue
+		}
+
+		filename := filepath.Base(segment.URI)
+		source := filepath.Join(filepath.Dir(localFilePath), filename)
+		if err := utils.Copy(source, filepath.Join(r.directory, filename)); err != nil {
+			log.Warnln("unable to record segment", source, err)
+			continue
+		}
+
+		r.recorded[segment.URI] = true
+		r.segments = append(r.segments, recordedSegment{filename: filename, duration: segment.Duration})
+		hasChanges = true
+	}
+
+	if hasChanges {
+		if err := r.writePlaylist(false); err != nil {
+			log.Errorln("unable to write recording playlist", err)
+		}
+	}
+}
+
+// Finish will stop recording and finalize the playlist.
+// It returns the total duration in seconds and the size on disk in bytes.
+func (r *HLSRecorder) Finish() (float64, int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.finished = true
+
+	if err := r.writePlaylist(true); err != nil {
+		return 0, 0, err
+	}
+
+	var duration float64
+	for _, segment := range r.segments {
+		duration += segment.duration
+	}
+
+	size, err := utils.GetDirectorySize(r.directory)
+
+	return duration, size, err
+}
+
+func (r *HLSRecorder) writePlaylist(complete bool) error {
+	capacity := uint(len(r.segments))
+	if capacity == 0 {
+		capacity = 1
+	}
+
+	p, err := m3u8.NewMediaPlaylist(0, capacity)
+	if err != nil {
+		return err
+	}
+
+	p.MediaType = m3u8.EVENT
+	if r.initSegment != "" {
+		p.SetDefaultMap(r.initSegment, 0, 0)
+	}
+
+	for _, segment := range r.segments {
+		if err := p.Append(segment.filename, segment.duration, ""); err != nil {
+			return err
+		}
+	}
+
+	if complete {
+		p.MediaType = m3u8.VOD
+		p.Close()
+	}
+
+	return playlist.WritePlaylist(p.String(), filepath.Join(r.directory, RecordingPlaylistFilename))
+}
+
+// GetRecordedSegmentFiles will return the ordered segment files of a recording
+// directory, starting with the initialization segment of fMP4 recordings.
+func GetRecordedSegmentFiles(directory string) ([]string, error) {
+	files := make([]string, 0)
+
+	f, err := os.Open(filepath.Join(directory, RecordingPlaylistFilename))
+	if err != nil {
+		return files, err
+	}
+	defer f.Close()
+
+	p, _, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil {
+		return files, err
+	}
+
+	mediaPlaylist, ok := p.(*m3u8.MediaPlaylist)
+	if !ok {
+		return files, nil
+	}
+
+	if mediaPlaylist.Map != nil {
+		files = append(files, filepath.Join(directory, filepath.Base(mediaPlaylist.Map.URI)))
+	}
+
+	for _, segment := range mediaPlaylist.Segments {
+		if segment == nil {
+			continue
+		}
+		files = append(files, filepath.Join(directory, filepath.Base(segment.URI)))
+	}
+
+	return files, nil
+}
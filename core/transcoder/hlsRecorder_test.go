@@ -0,0 +1,136 @@
+package transcoder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type testStorage struct{}
+
+func (s *testStorage) Setup() error { return nil }
+func (s *testStorage) Save(filePath string, retryCount int) (string, error) {
+	return filePath, nil
+}
+func (s *testStorage) SaveVODSegment(localFilePath string, recordingID int) (string, error) {
+	return localFilePath, nil
+}
+func (s *testStorage) DeleteVODSegments(recordingID int) error     { return nil }
+func (s *testStorage) SegmentWritten(localFilePath string)         {}
+func (s *testStorage) VariantPlaylistWritten(localFilePath string) {}
+func (s *testStorage) MasterPlaylistWritten(localFilePath string)  {}
+func (s *testStorage) DASHManifestWritten(localFilePath string)    {}
+
+func writeTestVariant(t *testing.T, directory string, segments ...string) string {
+	variantDirectory := filepath.Join(directory, "hls", "0")
+	if err := os.MkdirAll(variantDirectory, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:0\n"
+	for _, segment := range segments {
+		if err := ioutil.WriteFile(filepath.Join(variantDirectory, segment), []byte(segment), 0600); err != nil {
+			t.Fatal(err)
+		}
+		playlist += "#EXTINF:4.000,\n" + segment + "\n"
+	}
+
+	playlistPath := filepath.Join(variantDirectory, "stream.m3u8")
+	if err := ioutil.WriteFile(playlistPath, []byte(playlist), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return playlistPath
+}
+
+func TestHLSRecorder(t *testing.T) {
+	directory, err := ioutil.TempDir("", "owncast-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	recordingDirectory := filepath.Join(directory, "recording")
+	recorder, err := NewHLSRecorder(recordingDirectory, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.VariantPlaylistWritten(writeTestVariant(t, directory, "stream-1.ts", "stream-2.ts"))
+	// Segments already recorded are not recorded again when they are still
+	// in the next playlist.
+	recorder.VariantPlaylistWritten(writeTestVariant(t, directory, "stream-2.ts", "stream-3.ts"))
+
+	duration, size, err := recorder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if duration != 12 {
+		t.Errorf("expected 12 seconds to be recorded, got %f", duration)
+	}
+	if size == 0 {
+		t.Error("expected the size of the recording")
+	}
+
+	files, err := GetRecordedSegmentFiles(recordingDirectory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"stream-1.ts", "stream-2.ts", "stream-3.ts"}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d recorded segments, got %v", len(expected), files)
+	}
+	for i, file := range files {
+		if file != filepath.Join(recordingDirectory, expected[i]) {
+			t.Errorf("expected %s, got %s", expected[i], file)
+		}
+	}
+
+	// Nothing is recorded once the recording is finished.
+	recorder.VariantPlaylistWritten(writeTestVariant(t, directory, "stream-4.ts"))
+	if files, _ := GetRecordedSegmentFiles(recordingDirectory); len(files) != len(expected) {
+		t.Errorf("expected a finished recording not to change, got %v", files)
+	}
+}
+
+func TestHLSHandlerRecorder(t *testing.T) {
+	directory, err := ioutil.TempDir("", "owncast-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	playlistPath := writeTestVariant(t, directory, "stream-1.ts")
+	handler := &HLSHandler{Storage: &testStorage{}}
+
+	// The recorder is changed by the stream while playlists are written.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			handler.VariantPlaylistWritten(playlistPath)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			recorder, err := NewHLSRecorder(filepath.Join(directory, "recording"), 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			handler.SetRecorder(recorder)
+			handler.SetRecorder(nil)
+		}
+	}()
+	wg.Wait()
+
+	if handler.GetRecorder() != nil {
+		t.Error("expected the recorder to be cleared")
+	}
+}
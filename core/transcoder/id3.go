@@ -0,0 +1,53 @@
+package transcoder
+
+import (
+	"sort"
+
+	"github.com/owncast/owncast/models"
+)
+
+const id3EncodingUTF8 = 0x03
+
+// getID3Tag will return an ID3v2.4 tag with the title and values of the
+// metadata as its text frames.
+func getID3Tag(metadata models.TimedMetadata) []byte {
+	var frames []byte
+
+	if metadata.Title != "" {
+		frames = append(frames, getID3Frame("TIT2", append([]byte{id3EncodingUTF8}, metadata.Title...))...)
+	}
+
+	keys := make([]string, 0, len(metadata.Values))
+	for key := range metadata.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		content := append([]byte{id3EncodingUTF8}, key...)
+		content = append(content, 0x00)
+		content = append(content, metadata.Values[key]...)
+		frames = append(frames, getID3Frame("TXXX", content)...)
+	}
+
+	tag := append([]byte{'I', 'D', '3', 0x04, 0x00, 0x00}, getSyncsafeInteger(len(frames))...)
+	return append(tag, frames...)
+}
+
+func getID3Frame(id string, content []byte) []byte {
+	frame := append([]byte(id), getSyncsafeInteger(len(content))...)
+	frame = append(frame, 0x00, 0x00)
+
+	return append(frame, content...)
+}
+
+// getSyncsafeInteger will return the size as ID3 writes it, with seven bits
+// in each byte.
+func getSyncsafeInteger(size int) []byte {
+	return []byte{
+		byte(size>>21) & 0x7F,
+		byte(size>>14) & 0x7F,
+		byte(size>>7) & 0x7F,
+		byte(size) & 0x7F,
+	}
+}
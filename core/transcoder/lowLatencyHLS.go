@@ -0,0 +1,486 @@
+package transcoder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafov/m3u8"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+// Low-latency HLS playlists are built by us from the short segments written
+// by the transcoder. Each of them is a partial segment, and they are joined
+// together into full segments so players that do not support low-latency HLS
+// can continue to play the stream.
+
+// The number of the most recent full segments that keep listing their parts.
+const partialSegmentsListed = 2
+
+var (
+	_lowLatencyPlaylists map[int]*lowLatencyPlaylist
+	_lowLatencyLock      sync.Mutex
+)
+
+var (
+	// ErrInvalidPlaylistRequest is returned when a playlist is requested for
+	// a segment too far ahead of the live stream to wait for.
+	ErrInvalidPlaylistRequest = errors.New("the requested segment is too far ahead of the live stream")
+
+	// ErrPlaylistRequestTimeout is returned when the requested segment did
+	// not become available in time.
+	ErrPlaylistRequestTimeout = errors.New("timed out waiting for the requested segment")
+)
+
+type lowLatencyPart struct {
+	uri         string
+	duration    float64
+	independent bool
+}
+
+type lowLatencySegment struct {
+	sequence      int
+	uri           string
+	duration      float64
+	discontinuity bool
+	parts         []lowLatencyPart
+}
+
+// lowLatencyPlaylist is the low-latency playlist of a single stream variant.
+type lowLatencyPlaylist struct {
+	privateDirectory string
+	playlistPath     string
+	partTarget       float64
+	segmentTarget    float64
+	segmentCount     int
+
+	segments              []*lowLatencySegment
+	current               *lowLatencySegment
+	nextSequence          int
+	discontinuitySequence int
+	discontinuity         bool
+	preloadHint           string
+
+	processed      map[string]bool
+	processedOrder []string
+
+	segmentWritten func(localFilePath string)
+	updated        chan struct{}
+	lock           sync.Mutex
+}
+
+func newLowLatencyPlaylist(variantIndex int, level models.LatencyLevel) *lowLatencyPlaylist {
+	return &lowLatencyPlaylist{
+		privateDirectory: filepath.Join(config.PrivateHLSStoragePath, strconv.Itoa(variantIndex)),
+		playlistPath:     filepath.Join(config.PublicHLSStoragePath, strconv.Itoa(variantIndex), "stream.m3u8"),
+		partTarget:       level.SecondsPerPart,
+		segmentTarget:    float64(level.SecondsPerSegment),
+		segmentCount:     level.SegmentCount,
+		processed:        make(map[string]bool),
+		updated:          make(chan struct{}),
+	}
+}
+
+// startLowLatencyHLS will set up the low-latency playlists for a new run of
+// the transcoder, or remove them if low-latency HLS is not being used.
+func startLowLatencyHLS(level models.LatencyLevel, variantCount int) {
+	_lowLatencyLock.Lock()
+	defer _lowLatencyLock.Unlock()
+
+	previous := _lowLatencyPlaylists
+	if !level.IsLowLatencyHLS() {
+		_lowLatencyPlaylists = nil
+		return
+	}
+
+	_lowLatencyPlaylists = make(map[int]*lowLatencyPlaylist)
+	for index := 0; index < variantCount; index++ {
+		playlist := newLowLatencyPlaylist(index, level)
+
+		// Previous content has been removed, but the media sequence has to
+		// keep counting up for players that are already watching.
+		if p, ok := previous[index]; ok {
+			p.lock.Lock()
+			playlist.nextSequence = p.nextSequence + 1
+			playlist.discontinuitySequence = p.discontinuitySequence + 1
+			p.lock.Unlock()
+		}
+
+		_lowLatencyPlaylists[index] = playlist
+	}
+}
+
+// IsLowLatencyHLSActive will return if low-latency HLS playlists are being written.
+func IsLowLatencyHLSActive() bool {
+	_lowLatencyLock.Lock()
+	defer _lowLatencyLock.Unlock()
+
+	return _lowLatencyPlaylists != nil
+}
+
+func getLowLatencyPlaylist(variantIndex int) *lowLatencyPlaylist {
+	_lowLatencyLock.Lock()
+	defer _lowLatencyLock.Unlock()
+
+	return _lowLatencyPlaylists[variantIndex]
+}
+
+func getLowLatencyPlaylistForPath(localFilePath string) *lowLatencyPlaylist {
+	variantIndex, err := strconv.Atoi(utils.GetIndexFromFilePath(localFilePath))
+	if err != nil {
+		return nil
+	}
+
+	return getLowLatencyPlaylist(variantIndex)
+}
+
+// WaitForLowLatencyPlaylist will wait until the playlist of the variant lists
+// the media segment msn, or the partial segment of it when part is not negative.
+func WaitForLowLatencyPlaylist(variantIndex int, msn int, part int) error {
+	p := getLowLatencyPlaylist(variantIndex)
+	if p == nil {
+		return nil
+	}
+
+	p.lock.Lock()
+	tooFarAhead := msn > p.nextSequence+1
+	p.lock.Unlock()
+
+	if tooFarAhead {
+		return ErrInvalidPlaylistRequest
+	}
+
+	if !p.waitFor(func() bool { return p.hasSegment(msn, part) }) {
+		return ErrPlaylistRequestTimeout
+	}
+
+	return nil
+}
+
+// WaitForLowLatencyPart will wait for the partial segment that players were
+// hinted would be next, returning false if it did not become available.
+func WaitForLowLatencyPart(variantIndex int, filename string) bool {
+	p := getLowLatencyPlaylist(variantIndex)
+	if p == nil {
+		return false
+	}
+
+	p.lock.Lock()
+	isHinted := filename == p.preloadHint
+	p.lock.Unlock()
+
+	if !isHinted {
+		return false
+	}
+
+	return p.waitFor(func() bool { return p.preloadHint != filename })
+}
+
+// AppendLowLatencyOfflineSegment will end the low-latency playlist of a
+// variant with the offline content once the stream has stopped.
+func AppendLowLatencyOfflineSegment(variantIndex int, filename string, duration float64) {
+	p := getLowLatencyPlaylist(variantIndex)
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closeSegment()
+	p.appendSegment(&lowLatencySegment{
+		uri:           filename,
+		duration:      duration,
+		discontinuity: true,
+	})
+	p.discontinuity = true
+	p.preloadHint = ""
+
+	p.publish()
+}
+
+// update will add the parts found in the transcoder's playlist at localFilePath.
+func (p *lowLatencyPlaylist) update(localFilePath string, segmentWritten func(localFilePath string)) {
+	f, err := os.Open(localFilePath) // nolint
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	defer f.Close()
+
+	playlist, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != m3u8.MEDIA {
+		log.Errorln("unable to read transcoder playlist", localFilePath, err)
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.segmentWritten = segmentWritten
+
+	added := false
+	for _, segment := range playlist.(*m3u8.MediaPlaylist).Segments {
+		if segment == nil {
+			continue
+		}
+
+		uri := filepath.Base(segment.URI)
+		if p.processed[uri] {
+			continue
+		}
+		p.setProcessed(uri)
+
+		p.addPart(lowLatencyPart{
+			uri:         uri,
+			duration:    segment.Duration,
+			independent: isIndependentPart(filepath.Join(p.privateDirectory, uri)),
+		})
+		p.preloadHint = getNextPartFilename(uri)
+		added = true
+	}
+
+	if added {
+		p.publish()
+	}
+}
+
+// setProcessed will remember a part was added, forgetting those long gone
+// from the transcoder's playlist.
+func (p *lowLatencyPlaylist) setProcessed(uri string) {
+	p.processed[uri] = true
+	p.processedOrder = append(p.processedOrder, uri)
+
+	if len(p.processedOrder) > 100 {
+		delete(p.processed, p.processedOrder[0])
+		p.processedOrder = p.processedOrder[1:]
+	}
+}
+
+func (p *lowLatencyPlaylist) addPart(part lowLatencyPart) {
+	if p.current != nil {
+		// A segment has to start with a part that can be decoded on its own.
+		isFull := part.independent && p.current.duration >= p.segmentTarget-p.partTarget/2
+		if p.discontinuity || isFull || p.current.duration >= p.segmentTarget*3 {
+			p.closeSegment()
+		}
+	}
+
+	if p.current == nil {
+		p.current = &lowLatencySegment{
+			sequence:      p.nextSequence,
+			uri:           strings.Replace(part.uri, "stream-", "segment-", 1),
+			discontinuity: p.discontinuity,
+		}
+		p.discontinuity = false
+	}
+
+	p.current.parts = append(p.current.parts, part)
+	p.current.duration += part.duration
+}
+
+// closeSegment will join the parts of the segment being built into a full segment.
+func (p *lowLatencyPlaylist) closeSegment() {
+	segment := p.current
+	if segment == nil {
+		return
+	}
+	p.current = nil
+
+	var content bytes.Buffer
+	for _, part := range segment.parts {
+		partContent, err := ioutil.ReadFile(filepath.Join(p.privateDirectory, part.uri))
+		if err != nil {
+			log.Errorln("unable to read partial segment", err)
+			return
+		}
+		content.Write(partContent)
+	}
+
+	segmentPath := filepath.Join(p.privateDirectory, segment.uri)
+	if err := ioutil.WriteFile(segmentPath, content.Bytes(), 0600); err != nil {
+		log.Errorln("unable to write segment", err)
+		return
+	}
+
+	if p.segmentWritten != nil {
+		p.segmentWritten(segmentPath)
+	}
+
+	p.appendSegment(segment)
+}
+
+func (p *lowLatencyPlaylist) appendSegment(segment *lowLatencySegment) {
+	segment.sequence = p.nextSequence
+	p.nextSequence++
+	p.segments = append(p.segments, segment)
+
+	if len(p.segments) > p.segmentCount {
+		if p.segments[0].discontinuity {
+			p.discontinuitySequence++
+		}
+		p.segments = p.segments[1:]
+	}
+}
+
+// hasSegment will return if the playlist lists the media segment msn, or
+// the partial segment of it when part is not negative.
+func (p *lowLatencyPlaylist) hasSegment(msn int, part int) bool {
+	if msn < p.nextSequence {
+		return true
+	}
+
+	return p.current != nil && msn == p.current.sequence && part >= 0 && part < len(p.current.parts)
+}
+
+// waitFor will wait for ready to return true, checking it each time the
+// playlist is updated. ready is called with the lock held.
+func (p *lowLatencyPlaylist) waitFor(ready func() bool) bool {
+	timeout := time.NewTimer(p.getBlockingTimeout())
+	defer timeout.Stop()
+
+	for {
+		p.lock.Lock()
+		if ready() {
+			p.lock.Unlock()
+			return true
+		}
+		updated := p.updated
+		p.lock.Unlock()
+
+		select {
+		case <-updated:
+		case <-timeout.C:
+			return false
+		}
+	}
+}
+
+func (p *lowLatencyPlaylist) getBlockingTimeout() time.Duration {
+	return time.Duration(p.getTargetDuration()*3) * time.Second
+}
+
+func (p *lowLatencyPlaylist) getTargetDuration() int {
+	targetDuration := int(math.Ceil(p.segmentTarget))
+	for _, segment := range p.segments {
+		if duration := int(math.Round(segment.duration)); duration > targetDuration {
+			targetDuration = duration
+		}
+	}
+
+	return targetDuration
+}
+
+// publish will write the playlist and let anybody waiting on it know it changed.
+func (p *lowLatencyPlaylist) publish() {
+	if err := p.write(); err != nil {
+		log.Errorln("unable to write low-latency playlist", err)
+	}
+
+	close(p.updated)
+	p.updated = make(chan struct{})
+}
+
+func (p *lowLatencyPlaylist) write() error {
+	if len(p.segments) == 0 && p.current == nil {
+		return nil
+	}
+
+	// Write to a temporary file first so a partially written playlist is never served.
+	temporaryPath := p.playlistPath + ".tmp"
+	if err := ioutil.WriteFile(temporaryPath, p.encode(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, p.playlistPath)
+}
+
+func (p *lowLatencyPlaylist) encode() []byte {
+	var b bytes.Buffer
+
+	mediaSequence := p.nextSequence
+	if len(p.segments) > 0 {
+		mediaSequence = p.segments[0].sequence
+	}
+
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:6\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", p.getTargetDuration())
+	fmt.Fprintf(&b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", p.partTarget*3)
+	fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", p.partTarget)
+	fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence)
+	fmt.Fprintf(&b, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", p.discontinuitySequence)
+
+	for index, segment := range p.segments {
+		if segment.discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if index >= len(p.segments)-partialSegmentsListed {
+			encodeParts(&b, segment.parts)
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%s\n", segment.duration, segment.uri)
+	}
+
+	if p.current != nil {
+		if p.current.discontinuity {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		encodeParts(&b, p.current.parts)
+	}
+
+	if p.preloadHint != "" {
+		fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", p.preloadHint)
+	}
+
+	return b.Bytes()
+}
+
+func encodeParts(b *bytes.Buffer, parts []lowLatencyPart) {
+	for _, part := range parts {
+		fmt.Fprintf(b, "#EXT-X-PART:DURATION=%.3f,URI=\"%s\"", part.duration, part.uri)
+		if part.independent {
+			b.WriteString(",INDEPENDENT=YES")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// getNextPartFilename will return the name the transcoder gives the part
+// after filename, as they end in an increasing number.
+func getNextPartFilename(filename string) string {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	separator := strings.LastIndex(name, "-")
+	if separator < 0 {
+		return ""
+	}
+
+	number, err := strconv.Atoi(name[separator+1:])
+	if err != nil {
+		return ""
+	}
+
+	return name[:separator+1] + strconv.Itoa(number+1) + filepath.Ext(filename)
+}
+
+func isIndependentPart(localFilePath string) bool {
+	content, err := ioutil.ReadFile(localFilePath) // nolint
+	if err != nil {
+		log.Warnln(err)
+		return false
+	}
+
+	return startsWithKeyframe(content)
+}
@@ -0,0 +1,194 @@
+package transcoder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/core/mpegts"
+	"github.com/owncast/owncast/models"
+)
+
+func TestFFmpegLowLatencyCommand(t *testing.T) {
+	codec := Libx264Codec{}
+
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetInput("fakecontent.flv")
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec(codec.Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(5)
+
+	variant := HLSVariant{}
+	variant.videoBitrate = 1200
+	variant.isAudioPassthrough = true
+	variant.SetVideoFramerate(30)
+	transcoder.AddVariant(variant)
+
+	cmd := transcoder.getString()
+
+	for _, expected := range []string{
+		"-g:v:0 60 -keyint_min:v:0 60",
+		"-hls_time 0.5 -hls_list_size 20 -hls_flags split_by_time",
+		"-hls_segment_filename http://127.0.0.1:8123/%v/stream-jdofFGg-%d.ts",
+	} {
+		if !strings.Contains(cmd, expected) {
+			t.Errorf("ffmpeg command does not contain %s.\nGot %s", expected, cmd)
+		}
+	}
+
+	if strings.Contains(cmd, "-strftime") {
+		t.Errorf("partial segments should be numbered instead of timestamped.\nGot %s", cmd)
+	}
+}
+
+func TestLowLatencyFallback(t *testing.T) {
+	lowest := models.GetLowestLatencyLevel()
+	if lowest.Level != 0 {
+		t.Fatalf("expected level 0 to have the lowest latency, got level %d", lowest.Level)
+	}
+
+	tests := []struct {
+		name            string
+		codec           Codec
+		audioCodec      string
+		externalStorage bool
+		expected        int
+	}{
+		{"h264", &Libx264Codec{}, "", false, 5},
+		{"h264 with external storage", &Libx264Codec{}, "", true, lowest.Level},
+		{"h264 with opus", &Libx264Codec{}, models.AudioCodecOpus, false, lowest.Level},
+		{"hevc", &Libx265Codec{}, "", false, lowest.Level},
+		{"av1", &SvtAv1Codec{}, "", false, lowest.Level},
+	}
+
+	for _, test := range tests {
+		transcoder := new(Transcoder)
+		transcoder.SetCodec(test.codec.Name())
+
+		variant := HLSVariant{}
+		variant.videoBitrate = 1200
+		variant.SetAudioCodec(test.audioCodec)
+		transcoder.AddVariant(variant)
+
+		level := transcoder.getSupportedLatencyLevel(models.GetLatencyLevel(5), test.externalStorage)
+		if level.Level != test.expected {
+			t.Errorf("%s: expected latency level %d, got %d", test.name, test.expected, level.Level)
+		}
+	}
+}
+
+func TestLowLatencyPlaylist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "owncast-llhls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newLowLatencyPlaylist(0, models.GetLatencyLevel(5))
+	p.privateDirectory = dir
+	p.playlistPath = filepath.Join(dir, "public.m3u8")
+
+	var written []string
+	p.segmentWritten = func(localFilePath string) {
+		written = append(written, filepath.Base(localFilePath))
+	}
+
+	// Keyframes every four parts, and the last part starts the next segment.
+	for i := 0; i < 9; i++ {
+		uri := "stream-abc-" + string(rune('0'+i)) + ".ts"
+		if err := ioutil.WriteFile(filepath.Join(dir, uri), []byte{byte(i)}, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		p.addPart(lowLatencyPart{uri: uri, duration: 0.5, independent: i%4 == 0})
+		p.preloadHint = getNextPartFilename(uri)
+	}
+	p.publish()
+
+	if len(p.segments) != 2 || len(p.current.parts) != 1 {
+		t.Fatalf("expected 2 segments and 1 part in progress, got %d and %d", len(p.segments), len(p.current.parts))
+	}
+
+	if len(written) != 2 || written[1] != "segment-abc-4.ts" {
+		t.Fatalf("unexpected segments written %v", written)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "segment-abc-4.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string([]byte{4, 5, 6, 7}) {
+		t.Errorf("segment should be the parts joined together, got %v", content)
+	}
+
+	playlist, err := ioutil.ReadFile(p.playlistPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=1.500\n",
+		"#EXT-X-PART-INF:PART-TARGET=0.500\n",
+		"#EXT-X-MEDIA-SEQUENCE:0\n",
+		"#EXT-X-PART:DURATION=0.500,URI=\"stream-abc-4.ts\",INDEPENDENT=YES\n#EXT-X-PART:DURATION=0.500,URI=\"stream-abc-5.ts\"\n",
+		"#EXTINF:2.000,\nsegment-abc-4.ts\n#EXT-X-PART:DURATION=0.500,URI=\"stream-abc-8.ts\",INDEPENDENT=YES\n",
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"stream-abc-9.ts\"\n",
+	} {
+		if !strings.Contains(string(playlist), expected) {
+			t.Errorf("playlist does not contain %s.\nGot %s", expected, playlist)
+		}
+	}
+
+	if !p.hasSegment(1, -1) || !p.hasSegment(2, 0) {
+		t.Error("playlist should have the completed segments and the part in progress")
+	}
+	if p.hasSegment(2, -1) || p.hasSegment(2, 1) {
+		t.Error("playlist should not have the segment or part still to come")
+	}
+}
+
+func TestStartsWithKeyframe(t *testing.T) {
+	pat := newTestTSPacket(0x0000, 0x10, []byte{
+		0x00, // pointer
+		0x00, 0xB0, 0x0D, 0x00, 0x01, 0xC1, 0x00, 0x00,
+		0x00, 0x01, 0xF0, 0x00, // program 1 on pid 0x1000
+		0x00, 0x00, 0x00, 0x00, // crc
+	})
+	pmt := newTestTSPacket(0x1000, 0x10, []byte{
+		0x00, // pointer
+		0x02, 0xB0, 0x17, 0x00, 0x01, 0xC1, 0x00, 0x00,
+		0xE1, 0x00, 0xF0, 0x00,
+		0x0F, 0xE1, 0x01, 0xF0, 0x00, // aac on pid 0x101
+		0x1B, 0xE1, 0x00, 0xF0, 0x00, // h.264 on pid 0x100
+		0x00, 0x00, 0x00, 0x00, // crc
+	})
+	keyframe := newTestTSPacket(0x0100, 0x30, []byte{0x07, 0x40})
+	frame := newTestTSPacket(0x0100, 0x30, []byte{0x07, 0x00})
+
+	stream := append(append([]byte{}, pat...), pmt...)
+	if !startsWithKeyframe(append(append([]byte{}, stream...), keyframe...)) {
+		t.Error("stream starting with a keyframe was not independent")
+	}
+	if startsWithKeyframe(append(append([]byte{}, stream...), frame...)) {
+		t.Error("stream starting without a keyframe was independent")
+	}
+}
+
+func newTestTSPacket(pid int, adaptationControl byte, payload []byte) []byte {
+	pkt := make([]byte, mpegts.PacketSize)
+	for i := range pkt {
+		pkt[i] = 0xFF
+	}
+
+	pkt[0] = mpegts.SyncByte
+	pkt[1] = 0x40 | byte(pid>>8)
+	pkt[2] = byte(pid)
+	pkt[3] = adaptationControl
+	copy(pkt[4:], payload)
+
+	return pkt
+}
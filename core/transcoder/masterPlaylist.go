@@ -0,0 +1,218 @@
+package transcoder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/owncast/owncast/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// The master playlist written by the transcoder is kept so it can be
+// written again as alternate audio tracks and captions come and go.
+var (
+	_masterPlaylist        string
+	_masterPlaylistPath    string
+	_masterPlaylistStorage models.StorageProvider
+	_masterPlaylistCodecs  []variantCodecs
+	_masterPlaylistLock    sync.Mutex
+)
+
+var codecsAttributeRegex = regexp.MustCompile(`CODECS="([^"]*)"`)
+
+// The group of the stream's own audio and its alternate audio tracks.
+const alternateAudioGroup = "alternate-audio"
+
+// variantCodecs are the CODECS attribute values of a variant's video and audio.
+type variantCodecs struct {
+	video string
+	audio string
+}
+
+// setMasterPlaylistCodecs will set the codecs of each variant to be written
+// in the master playlist.
+func setMasterPlaylistCodecs(t *Transcoder) {
+	_masterPlaylistLock.Lock()
+	defer _masterPlaylistLock.Unlock()
+
+	_masterPlaylist = ""
+	_masterPlaylistCodecs = make([]variantCodecs, len(t.variants))
+	for index := range t.variants {
+		variant := &t.variants[index]
+
+		// Passthrough video is H.264 and audio is AAC from the inbound stream.
+		codecs := variantCodecs{video: getCodecTag(&Libx264Codec{}, variant), audio: getAudioCodecTag(models.AudioCodecAAC)}
+		if variant.isAudioOnly {
+			codecs.video = ""
+		} else if !variant.isVideoPassthrough {
+			codecs.video = getCodecTag(variant.getCodec(t), variant)
+		}
+		if !variant.isAudioPassthrough {
+			codecs.audio = getAudioCodecTag(variant.getAudioCodec())
+		}
+		_masterPlaylistCodecs[index] = codecs
+	}
+}
+
+// masterPlaylistWritten will keep the master playlist written by the
+// transcoder, and write it with what the transcoder leaves out.
+func masterPlaylistWritten(localFilePath string, storage models.StorageProvider) {
+	content, err := ioutil.ReadFile(localFilePath) // nolint
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	_masterPlaylistLock.Lock()
+	_masterPlaylist = string(content)
+	_masterPlaylistPath = localFilePath
+	_masterPlaylistStorage = storage
+	_masterPlaylistLock.Unlock()
+
+	writeMasterPlaylist()
+}
+
+// writeMasterPlaylist will write the master playlist with the codecs of each
+// variant, and the alternate audio tracks and captions that can be played.
+func writeMasterPlaylist() {
+	_masterPlaylistLock.Lock()
+	defer _masterPlaylistLock.Unlock()
+
+	if _masterPlaylist == "" {
+		return
+	}
+
+	playlist, _ := getMasterPlaylistWithCodecs(_masterPlaylist, _masterPlaylistCodecs)
+	playlist = getMasterPlaylistWithAudioTracks(playlist, getReadyAudioTracks())
+	playlist = getMasterPlaylistWithCaptions(playlist, getReadyCaptions())
+
+	if err := ioutil.WriteFile(_masterPlaylistPath, []byte(playlist), 0600); err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	_masterPlaylistStorage.MasterPlaylistWritten(_masterPlaylistPath)
+}
+
+// getMasterPlaylistWithAudioTracks will list the alternate audio tracks in
+// an audio group along with the audio each variant has.
+func getMasterPlaylistWithAudioTracks(playlist string, tracks []audioTrack) string {
+	if len(tracks) == 0 {
+		return playlist
+	}
+
+	// The stream's own audio is in the variants, so it has no URI.
+	renditions := []string{
+		fmt.Sprintf(`#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="%s",NAME="Main",DEFAULT=YES,AUTOSELECT=YES`, alternateAudioGroup),
+	}
+	for _, track := range tracks {
+		rendition := fmt.Sprintf(`#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="%s",NAME="%s"`, alternateAudioGroup, getQuotedStringValue(track.track.Name))
+		if track.track.Language != "" {
+			rendition += fmt.Sprintf(`,LANGUAGE="%s"`, getQuotedStringValue(track.track.Language))
+		}
+		rendition += fmt.Sprintf(`,DEFAULT=NO,AUTOSELECT=YES,URI="%s/stream.m3u8"`, getAudioTrackDirectory(track.index))
+		renditions = append(renditions, rendition)
+	}
+
+	// Variants listed with their own audio group are audio-only variants.
+	return addMasterPlaylistRenditions(playlist, renditions, "AUDIO", alternateAudioGroup)
+}
+
+// getMasterPlaylistWithCaptions will list the live captions as the
+// subtitles of each variant.
+func getMasterPlaylistWithCaptions(playlist string, captions *models.Captions) string {
+	if captions == nil {
+		return playlist
+	}
+
+	rendition := fmt.Sprintf(`#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="%s",NAME="%s"`, captionsGroup, getQuotedStringValue(captions.GetName()))
+	if captions.Language != "" {
+		rendition += fmt.Sprintf(`,LANGUAGE="%s"`, getQuotedStringValue(captions.Language))
+	}
+	rendition += fmt.Sprintf(`,DEFAULT=NO,AUTOSELECT=YES,FORCED=NO,URI="%s/stream.m3u8"`, captionsDirectory)
+
+	return addMasterPlaylistRenditions(playlist, []string{rendition}, "SUBTITLES", captionsGroup)
+}
+
+// addMasterPlaylistRenditions will list the renditions ahead of the
+// variants, and add the group to the variants that don't already have a
+// group of that type.
+func addMasterPlaylistRenditions(playlist string, renditions []string, attribute string, group string) string {
+	lines := strings.Split(playlist, "\n")
+	result := make([]string, 0, len(lines)+len(renditions))
+	added := false
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#EXT-X-STREAM-INF:") && !strings.Contains(line, attribute+"=") {
+			line += fmt.Sprintf(`,%s="%s"`, attribute, group)
+		}
+
+		if !added && (strings.HasPrefix(line, "#EXT-X-STREAM-INF:") || strings.HasPrefix(line, "#EXT-X-MEDIA:")) {
+			result = append(result, renditions...)
+			added = true
+		}
+
+		result = append(result, line)
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// getQuotedStringValue will return the value with the characters a playlist
+// quoted string can't contain removed.
+func getQuotedStringValue(value string) string {
+	return strings.NewReplacer(`"`, "", "\r", "", "\n", "").Replace(value)
+}
+
+func getMasterPlaylistWithCodecs(playlist string, codecs []variantCodecs) (string, bool) {
+	lines := strings.Split(playlist, "\n")
+	changed := false
+
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#EXT-X-STREAM-INF:") || i+1 >= len(lines) {
+			continue
+		}
+
+		// Not every variant is listed, so they are found by their playlist.
+		index, err := strconv.Atoi(path.Dir(lines[i+1]))
+		if err != nil || index < 0 || index >= len(codecs) {
+			continue
+		}
+
+		match := codecsAttributeRegex.FindStringSubmatch(line)
+		if match == nil {
+			values := []string{codecs[index].audio}
+			if codecs[index].video != "" {
+				values = []string{codecs[index].video, codecs[index].audio}
+			}
+			lines[i] = line + `,CODECS="` + strings.Join(values, ",") + `"`
+			changed = true
+			continue
+		}
+
+		if codecs[index].video == "" {
+			continue
+		}
+
+		// The video is compared by its format, as the transcoder knows the
+		// profile and level of what it writes. The audio it lists is left as
+		// is, as the inbound stream may not have any.
+		if strings.Contains(match[1], codecs[index].video[:4]) {
+			continue
+		}
+
+		attribute := `CODECS="` + codecs[index].video
+		if match[1] != "" {
+			attribute += "," + match[1]
+		}
+		lines[i] = strings.Replace(line, match[0], attribute+`"`, 1)
+		changed = true
+	}
+
+	return strings.Join(lines, "\n"), changed
+}
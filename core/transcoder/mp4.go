@@ -0,0 +1,215 @@
+package transcoder
+
+import (
+	"encoding/binary"
+)
+
+// fmp4Track is the track of an fMP4 initialization segment that segment
+// times are read from.
+type fmp4Track struct {
+	id        uint32
+	timescale uint32
+}
+
+// The scheme of event messages carrying ID3 timed metadata.
+const id3EventMessageScheme = "https://aomedia.org/emsg/ID3"
+
+// forEachBox will call handler with the type and payload of each box in b.
+func forEachBox(b []byte, handler func(boxType string, payload []byte)) {
+	for len(b) >= 8 {
+		boxType, headerSize, size, ok := readBoxHeader(b)
+		if !ok {
+			return
+		}
+
+		handler(boxType, b[headerSize:size])
+		b = b[size:]
+	}
+}
+
+// readBoxHeader will return the type, header size and size of the box at
+// the start of b.
+func readBoxHeader(b []byte) (string, uint64, uint64, bool) {
+	size := uint64(binary.BigEndian.Uint32(b[0:4]))
+	boxType := string(b[4:8])
+	headerSize := uint64(8)
+
+	switch size {
+	case 0:
+		size = uint64(len(b))
+	case 1:
+		if len(b) < 16 {
+			return "", 0, 0, false
+		}
+		size = binary.BigEndian.Uint64(b[8:16])
+		headerSize = 16
+	}
+
+	if size < headerSize || size > uint64(len(b)) {
+		return "", 0, 0, false
+	}
+
+	return boxType, headerSize, size, true
+}
+
+// insertBeforeBox will insert boxes ahead of the first box of a type in b.
+func insertBeforeBox(b []byte, boxType string, boxes []byte) ([]byte, bool) {
+	offset := uint64(0)
+	for offset+8 <= uint64(len(b)) {
+		currentType, _, size, ok := readBoxHeader(b[offset:])
+		if !ok {
+			return b, false
+		}
+
+		if currentType == boxType {
+			result := make([]byte, 0, len(b)+len(boxes))
+			result = append(result, b[:offset]...)
+			result = append(result, boxes...)
+			return append(result, b[offset:]...), true
+		}
+		offset += size
+	}
+
+	return b, false
+}
+
+// getID3EventMessage will return an event message box carrying an ID3 tag
+// shown at a presentation time in the timescale.
+func getID3EventMessage(id uint32, timescale uint32, presentationTime uint64, tag []byte) []byte {
+	box := make([]byte, 32, 32+len(id3EventMessageScheme)+2+len(tag))
+	copy(box[4:8], "emsg")
+	box[8] = 1 // Version 1 has the presentation time rather than a delta.
+	binary.BigEndian.PutUint32(box[12:16], timescale)
+	binary.BigEndian.PutUint64(box[16:24], presentationTime)
+	binary.BigEndian.PutUint32(box[24:28], 0) // The event has no duration
+	binary.BigEndian.PutUint32(box[28:32], id)
+
+	box = append(box, id3EventMessageScheme...)
+	box = append(box, 0x00, 0x00) // The scheme and an empty value
+	box = append(box, tag...)
+	binary.BigEndian.PutUint32(box[0:4], uint32(len(box)))
+
+	return box
+}
+
+// getInitSegmentTrack will return the video track of an initialization
+// segment, or the first track if there is no video.
+func getInitSegmentTrack(b []byte) (fmp4Track, bool) {
+	var tracks []fmp4Track
+	videoTrack := -1
+
+	forEachBox(b, func(boxType string, moov []byte) {
+		if boxType != "moov" {
+			return
+		}
+
+		forEachBox(moov, func(boxType string, trak []byte) {
+			if boxType != "trak" {
+				return
+			}
+
+			track, isVideo, ok := parseTrack(trak)
+			if !ok {
+				return
+			}
+
+			if isVideo && videoTrack < 0 {
+				videoTrack = len(tracks)
+			}
+			tracks = append(tracks, track)
+		})
+	})
+
+	if len(tracks) == 0 {
+		return fmp4Track{}, false
+	}
+
+	if videoTrack >= 0 {
+		return tracks[videoTrack], true
+	}
+
+	return tracks[0], true
+}
+
+func parseTrack(trak []byte) (fmp4Track, bool, bool) {
+	var track fmp4Track
+	var isVideo bool
+
+	forEachBox(trak, func(boxType string, payload []byte) {
+		switch boxType {
+		case "tkhd":
+			// The track id follows the creation and modification times.
+			offset := 12
+			if len(payload) > 0 && payload[0] == 1 {
+				offset = 20
+			}
+			if len(payload) >= offset+4 {
+				track.id = binary.BigEndian.Uint32(payload[offset : offset+4])
+			}
+		case "mdia":
+			forEachBox(payload, func(boxType string, payload []byte) {
+				switch boxType {
+				case "mdhd":
+					offset := 12
+					if len(payload) > 0 && payload[0] == 1 {
+						offset = 20
+					}
+					if len(payload) >= offset+4 {
+						track.timescale = binary.BigEndian.Uint32(payload[offset : offset+4])
+					}
+				case "hdlr":
+					isVideo = len(payload) >= 12 && string(payload[8:12]) == "vide"
+				}
+			})
+		}
+	})
+
+	return track, isVideo, track.id != 0 && track.timescale != 0
+}
+
+// getSegmentDecodeTime will return the time, in the track's timescale, the
+// track starts at in a fMP4 segment.
+func getSegmentDecodeTime(b []byte, trackID uint32) (uint64, bool) {
+	var decodeTime uint64
+	found := false
+
+	forEachBox(b, func(boxType string, moof []byte) {
+		if boxType != "moof" || found {
+			return
+		}
+
+		forEachBox(moof, func(boxType string, traf []byte) {
+			if boxType != "traf" || found {
+				return
+			}
+
+			var id uint32
+			var time uint64
+			hasTime := false
+
+			forEachBox(traf, func(boxType string, payload []byte) {
+				switch boxType {
+				case "tfhd":
+					if len(payload) >= 8 {
+						id = binary.BigEndian.Uint32(payload[4:8])
+					}
+				case "tfdt":
+					if len(payload) >= 12 && payload[0] == 1 {
+						time = binary.BigEndian.Uint64(payload[4:12])
+						hasTime = true
+					} else if len(payload) >= 8 {
+						time = uint64(binary.BigEndian.Uint32(payload[4:8]))
+						hasTime = true
+					}
+				}
+			})
+
+			if id == trackID && hasTime {
+				decodeTime = time
+				found = true
+			}
+		})
+	})
+
+	return decodeTime, found
+}
@@ -0,0 +1,128 @@
+package transcoder
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owncast/owncast/models"
+)
+
+// How long to wait for a remote source to respond before giving up.
+const probeTimeout = 20 * time.Second
+
+var (
+	probeVideoStreamRegex = regexp.MustCompile(`Stream #\d+:\d+.*?: Video: (\w+)`)
+	probeAudioStreamRegex = regexp.MustCompile(`Stream #\d+:\d+.*?: Audio: (\w+)`)
+	probeVideoSizeRegex   = regexp.MustCompile(`, (\d{2,5})x(\d{2,5})`)
+	probeFramerateRegex   = regexp.MustCompile(`([\d.]+) fps`)
+)
+
+var probeCodecNames = map[string]string{
+	"h264": "H.264",
+	"hevc": "H.265",
+	"aac":  "AAC",
+	"mp3":  "MP3",
+	"opus": "Opus",
+}
+
+// ProbeInput will connect to a remote source and return the details of its streams.
+func ProbeInput(ffmpegPath string, sourceURL string) (models.InboundStreamDetails, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	args := []string{"-hide_banner"}
+	args = append(args, strings.Fields(getInputFlagsForURL(sourceURL))...)
+	args = append(args, "-i", sourceURL)
+
+	// Without an output ffmpeg exits after reading the input details, which
+	// is all we need. The exit status is always an error so it is ignored.
+	output, _ := exec.CommandContext(ctx, ffmpegPath, args...).CombinedOutput() //nolint:gosec
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return models.InboundStreamDetails{}, errors.New("timed out connecting to " + redactURL(sourceURL))
+	}
+
+	return parseProbeOutput(string(output))
+}
+
+func parseProbeOutput(output string) (models.InboundStreamDetails, error) {
+	details := models.InboundStreamDetails{}
+
+	video := probeVideoStreamRegex.FindStringSubmatch(output)
+	audio := probeAudioStreamRegex.FindStringSubmatch(output)
+	if video == nil && audio == nil {
+		return details, errors.New("no audio or video streams found in source")
+	}
+
+	if video != nil {
+		details.VideoCodec = getProbeCodecName(video[1])
+
+		// Only look at the line of the video stream for its details.
+		videoLine := output[strings.Index(output, video[0]):]
+		if end := strings.Index(videoLine, "\n"); end != -1 {
+			videoLine = videoLine[:end]
+		}
+
+		if size := probeVideoSizeRegex.FindStringSubmatch(videoLine); size != nil {
+			details.Width, _ = strconv.Atoi(size[1])
+			details.Height, _ = strconv.Atoi(size[2])
+		}
+
+		if framerate := probeFramerateRegex.FindStringSubmatch(videoLine); framerate != nil {
+			fps, _ := strconv.ParseFloat(framerate[1], 32)
+			details.VideoFramerate = float32(fps)
+		}
+	}
+
+	if audio != nil {
+		details.AudioCodec = getProbeCodecName(audio[1])
+	} else {
+		details.AudioCodec = "No audio"
+		details.VideoOnly = true
+	}
+
+	return details, nil
+}
+
+func getProbeCodecName(codec string) string {
+	if name, ok := probeCodecNames[codec]; ok {
+		return name
+	}
+
+	return strings.ToUpper(codec)
+}
+
+// getInputFlagsForURL will return the ffmpeg input options suited to the source protocol.
+func getInputFlagsForURL(sourceURL string) string {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return ""
+	}
+
+	switch u.Scheme {
+	case "rtsp", "rtsps":
+		// Most IP cameras are more reliable over TCP than UDP.
+		return "-rtsp_transport tcp"
+	case "http", "https":
+		return "-reconnect 1 -reconnect_streamed 1 -reconnect_delay_max 5"
+	}
+
+	return ""
+}
+
+// redactURL will remove any credentials from a URL so it can be logged.
+func redactURL(sourceURL string) string {
+	u, err := url.Parse(sourceURL)
+	if err != nil || u.User == nil {
+		return sourceURL
+	}
+
+	u.User = url.User("redacted")
+	return u.String()
+}
@@ -0,0 +1,60 @@
+package transcoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	output := `Input #0, rtsp, from 'rtsp://camera.local/stream':
+  Metadata:
+    title           : Session streamed by "camera"
+  Duration: N/A, start: 0.000000, bitrate: N/A
+    Stream #0:0: Video: h264 (Main), yuv420p(progressive), 1920x1080, 25 fps, 25 tbr, 90k tbn, 50 tbc
+    Stream #0:1: Audio: aac (LC), 16000 Hz, mono, fltp
+At least one output file must be specified
+`
+
+	details, err := parseProbeOutput(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if details.VideoCodec != "H.264" || details.AudioCodec != "AAC" {
+		t.Errorf("unexpected codecs %s and %s", details.VideoCodec, details.AudioCodec)
+	}
+
+	if details.Width != 1920 || details.Height != 1080 {
+		t.Errorf("unexpected size %dx%d", details.Width, details.Height)
+	}
+
+	if details.VideoFramerate != 25 {
+		t.Errorf("unexpected framerate %f", details.VideoFramerate)
+	}
+
+	if _, err := parseProbeOutput("rtsp://camera.local/stream: Connection refused"); err == nil {
+		t.Error("expected an error when no streams are found")
+	}
+}
+
+func TestPremiereInput(t *testing.T) {
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetPremiereInput("data/premieres/it's live.mp4")
+	transcoder.SetOutputPath("fakeOutput")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec((&Libx264Codec{}).Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+	transcoder.AddVariant(HLSVariant{isVideoPassthrough: true, isAudioPassthrough: true})
+
+	expected := `-fflags +genpts -re -i  'data/premieres/it'\''s live.mp4'`
+	if command := transcoder.getString(); !strings.Contains(command, expected) {
+		t.Errorf("expected the premiere to be read at its native frame rate with %s, got %s", expected, command)
+	}
+
+	if transcoder.isOfflineContent {
+		t.Error("expected a premiere to be aired as a live stream")
+	}
+}
@@ -0,0 +1,304 @@
+package transcoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+)
+
+// A remote transcoder worker runs ffmpeg on another server. Owncast connects
+// to the worker and sends it ffmpeg's arguments and the inbound stream, and
+// the worker sends back what ffmpeg writes, over a single connection. The
+// files ffmpeg writes are put to the file writer as if it ran here.
+//
+// The connection is not encrypted, so the secret and the stream can be read
+// by anyone between the two servers. A worker must only be reachable over a
+// trusted private network.
+const (
+	remoteFrameHello    = 'H' // The secret the worker is set up with
+	remoteFrameReady    = 'R' // The port ffmpeg sends its output to on the worker
+	remoteFrameCommand  = 'C' // The arguments to run ffmpeg with
+	remoteFrameInput    = 'I' // Part of the inbound stream, or its end if empty
+	remoteFrameMessage  = 'M' // Part of what ffmpeg writes to stderr
+	remoteFrameProgress = 'P' // Part of what ffmpeg writes with -progress
+	remoteFrameFile     = 'F' // A file ffmpeg wrote
+	remoteFrameExit     = 'E' // ffmpeg exited, with its error if it failed
+
+	// The largest frame read, which fits the largest segment.
+	maxRemoteFrameSize = 64 << 20
+
+	// The largest frame read before a connection is authenticated.
+	maxRemoteHelloSize = 4096
+
+	remoteTranscoderTimeout = 10 * time.Second
+	remoteInputChunkSize    = 32 * 1024
+)
+
+type remoteHello struct {
+	Secret string `json:"secret"`
+}
+
+type remoteReady struct {
+	Port string `json:"port"`
+}
+
+type remoteCommand struct {
+	Arguments []string `json:"arguments"`
+}
+
+type remoteFrameWriter struct {
+	w    io.Writer
+	lock sync.Mutex
+}
+
+func (f *remoteFrameWriter) write(frameType byte, payload []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	header := make([]byte, 5)
+	header[0] = frameType
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+
+	if _, err := f.w.Write(header); err != nil {
+		return err
+	}
+	_, err := f.w.Write(payload)
+	return err
+}
+
+func readRemoteFrame(r io.Reader, maxSize uint32) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxSize {
+		return 0, nil, fmt.Errorf("remote transcoder frame of %d bytes is too large", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+// getRemoteFilePayload will return a file ffmpeg wrote as the payload of a frame.
+func getRemoteFilePayload(filePath string, content []byte) []byte {
+	payload := make([]byte, 2, 2+len(filePath)+len(content))
+	binary.BigEndian.PutUint16(payload, uint16(len(filePath)))
+	payload = append(payload, filePath...)
+	return append(payload, content...)
+}
+
+// parseRemoteFilePayload will return the path and content of a file frame.
+// The path is kept within the directory files are written to.
+func parseRemoteFilePayload(payload []byte) (string, []byte, error) {
+	if len(payload) < 2 {
+		return "", nil, errors.New("invalid remote transcoder file")
+	}
+
+	pathLength := int(binary.BigEndian.Uint16(payload))
+	if len(payload) < 2+pathLength {
+		return "", nil, errors.New("invalid remote transcoder file")
+	}
+
+	filePath := path.Clean("/" + string(payload[2:2+pathLength]))
+	return filePath, payload[2+pathLength:], nil
+}
+
+// getRemoteCommandArguments will return the arguments ffmpeg is run with in
+// a command, without the environment it is run with or the path to ffmpeg.
+func getRemoteCommandArguments(command string) []string {
+	arguments := splitShellArguments(command)
+	for len(arguments) > 0 && !strings.HasPrefix(arguments[0], "-") && strings.Contains(arguments[0], "=") {
+		arguments = arguments[1:]
+	}
+
+	if len(arguments) == 0 {
+		return nil
+	}
+
+	return arguments[1:]
+}
+
+// RemoteTranscoderBackend runs ffmpeg on a transcoder worker on another server.
+type RemoteTranscoderBackend struct {
+	Address string
+	Secret  string
+}
+
+type remoteTranscoderProcess struct {
+	conn     net.Conn
+	frames   *remoteFrameWriter
+	messages *io.PipeReader
+	progress *io.PipeReader
+	done     chan struct{}
+	err      error
+}
+
+// Start will connect to the worker and run the command on it.
+func (b RemoteTranscoderBackend) Start(getCommand func(outputPort string) string, stdin io.Reader) (TranscoderProcess, error) {
+	conn, err := net.DialTimeout("tcp", b.Address, remoteTranscoderTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the remote transcoder at %s: %w", b.Address, err)
+	}
+
+	frames := &remoteFrameWriter{w: conn}
+
+	hello, _ := json.Marshal(remoteHello{Secret: b.Secret})
+	if err := frames.write(remoteFrameHello, hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The worker only answers once it is ready to run the command.
+	_ = conn.SetReadDeadline(time.Now().Add(remoteTranscoderTimeout))
+	frameType, payload, err := readRemoteFrame(conn, maxRemoteHelloSize)
+	if err != nil || frameType != remoteFrameReady {
+		conn.Close()
+		return nil, fmt.Errorf("the remote transcoder at %s did not accept the connection. check its secret", b.Address)
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	var ready remoteReady
+	if err := json.Unmarshal(payload, &ready); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The worker runs its own copy of ffmpeg with the arguments.
+	command, _ := json.Marshal(remoteCommand{Arguments: getRemoteCommandArguments(getCommand(ready.Port))})
+	if err := frames.write(remoteFrameCommand, command); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	messages, messagesWriter := io.Pipe()
+	progress, progressWriter := io.Pipe()
+
+	p := &remoteTranscoderProcess{
+		conn:     conn,
+		frames:   frames,
+		messages: messages,
+		progress: progress,
+		done:     make(chan struct{}),
+	}
+
+	if stdin != nil {
+		go p.sendInput(stdin)
+	}
+
+	go p.receive(messagesWriter, progressWriter)
+
+	return p, nil
+}
+
+// sendInput will send the inbound stream to the worker until it ends.
+func (p *remoteTranscoderProcess) sendInput(stdin io.Reader) {
+	buffer := make([]byte, remoteInputChunkSize)
+	for {
+		n, err := stdin.Read(buffer)
+		if n > 0 {
+			if writeErr := p.frames.write(remoteFrameInput, buffer[:n]); writeErr != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			_ = p.frames.write(remoteFrameInput, nil)
+			return
+		}
+	}
+}
+
+// receive will pass on what the worker sends until ffmpeg exits.
+func (p *remoteTranscoderProcess) receive(messages *io.PipeWriter, progress *io.PipeWriter) {
+	defer close(p.done)
+	defer p.conn.Close()
+	defer messages.Close()
+	defer progress.Close()
+
+	for {
+		frameType, payload, err := readRemoteFrame(p.conn, maxRemoteFrameSize)
+		if err != nil {
+			p.err = fmt.Errorf("lost the connection to the remote transcoder: %w", err)
+			return
+		}
+
+		switch frameType {
+		case remoteFrameMessage:
+			_, _ = messages.Write(payload)
+		case remoteFrameProgress:
+			_, _ = progress.Write(payload)
+		case remoteFrameFile:
+			if err := putRemoteFile(payload); err != nil {
+				log.Errorln("unable to write a file from the remote transcoder", err)
+			}
+		case remoteFrameExit:
+			if len(payload) > 0 {
+				p.err = errors.New(string(payload))
+			}
+			return
+		}
+	}
+}
+
+// putRemoteFile will put a file ffmpeg wrote on the worker to the file writer.
+func putRemoteFile(payload []byte) error {
+	filePath, content, err := parseRemoteFilePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	url := "http://127.0.0.1:" + config.InternalHLSListenerPort + filePath
+	request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s was not written: %s", filePath, response.Status)
+	}
+
+	return nil
+}
+
+func (p *remoteTranscoderProcess) Messages() io.Reader {
+	return p.messages
+}
+
+func (p *remoteTranscoderProcess) Progress() io.Reader {
+	return p.progress
+}
+
+func (p *remoteTranscoderProcess) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// Kill will close the connection, which ends ffmpeg on the worker.
+func (p *remoteTranscoderProcess) Kill() error {
+	return p.conn.Close()
+}
@@ -0,0 +1,206 @@
+package transcoder
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+// startTestTranscoderWorker will start a worker whose ffmpeg passes on the
+// stream as its progress and writes its arguments as its messages.
+func startTestTranscoderWorker(t *testing.T, secret string) string {
+	ffmpegPath := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := ioutil.WriteFile(ffmpegPath, []byte("#!/bin/sh\ncat\necho progress=end\necho \"$@\" >&2\nexit 3\n"), 0700); err != nil { // nolint
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		_ = serveTranscoderWorker(listener, secret, ffmpegPath)
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestRemoteTranscoderBackend(t *testing.T) {
+	address := startTestTranscoderWorker(t, "secret")
+	backend := RemoteTranscoderBackend{Address: address, Secret: "secret"}
+
+	outputPort := ""
+	process, err := backend.Start(func(port string) string {
+		outputPort = port
+		return `FFREPORT=file="data/logs/transcoder.log":level=32 /usr/bin/ffmpeg -progress pipe:1 -i  pipe:0 -x264-params:v:0 "scenecut=0:open_gop=0" -f hls http://127.0.0.1:` + port + `/%v/stream.m3u8`
+	}, strings.NewReader("frame=1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outputPort == "" {
+		t.Error("expected the command to be built with the worker's output port")
+	}
+
+	messages := make(chan string)
+	go func() {
+		output, _ := ioutil.ReadAll(process.Messages())
+		messages <- string(output)
+	}()
+
+	progress, _ := ioutil.ReadAll(process.Progress())
+	if string(progress) != "frame=1\nprogress=end\n" {
+		t.Errorf("unexpected progress %q", progress)
+	}
+
+	expected := "-progress pipe:1 -i pipe:0 -x264-params:v:0 scenecut=0:open_gop=0 -f hls http://127.0.0.1:" + outputPort + "/%v/stream.m3u8\n"
+	if message := <-messages; message != expected {
+		t.Errorf("expected ffmpeg to be run with the arguments %q, got %q", expected, message)
+	}
+
+	if err := process.Wait(); err == nil || err.Error() != "exit status 3" {
+		t.Errorf("expected the transcoder to exit with status 3, got %v", err)
+	}
+}
+
+func TestRemoteTranscoderBackendSecret(t *testing.T) {
+	address := startTestTranscoderWorker(t, "secret")
+	backend := RemoteTranscoderBackend{Address: address, Secret: "wrong"}
+
+	if _, err := backend.Start(func(port string) string { return "true" }, nil); err == nil {
+		t.Error("expected a worker with a different secret to refuse the connection")
+	}
+}
+
+func TestRemoteTranscoderBackendRejectsArguments(t *testing.T) {
+	address := startTestTranscoderWorker(t, "secret")
+	backend := RemoteTranscoderBackend{Address: address, Secret: "secret"}
+
+	process, err := backend.Start(func(port string) string {
+		return "ffmpeg -i pipe:0 -f hls http://127.0.0.1:" + port + "/%v/stream.m3u8; rm -rf /"
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := process.Wait(); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected the worker to refuse the arguments, got %v", err)
+	}
+}
+
+func TestValidateTranscoderWorkerArguments(t *testing.T) {
+	output := "http://127.0.0.1:8123/%v/stream.m3u8"
+
+	valid := [][]string{
+		{"-i", "pipe:0", "-f", "hls", output},
+		{"-rtsp_transport", "tcp", "-i", "rtsp://camera.local/stream", "-c:v:0", "libx264", output},
+		{"-re", "-i", "srt://source.local:9000", "-hls_segment_filename", "http://127.0.0.1:8123/%v/stream-abc%s.ts", output},
+		{"-vaapi_device", "/dev/dri/renderD128", "-i", "pipe:0", "-filter:v:0", "scale=1280:-2,format=nv12,hwupload", output},
+		{"-i", "pipe:0", "-x265-params:v:0", "scenecut=0:open-gop=0:log-level=warning", output},
+	}
+	for _, arguments := range valid {
+		if err := validateTranscoderWorkerArguments(arguments, "8123"); err != nil {
+			t.Errorf("expected %v to be allowed, got %v", arguments, err)
+		}
+	}
+
+	invalid := [][]string{
+		{"-i", "/etc/passwd", "-f", "hls", output},
+		{"-i", "file:///etc/passwd", output},
+		{"-i", "pipe:0", "-f", "hls", "/tmp/stream.m3u8"},
+		{"-i", "pipe:0", "-hide_banner", "/tmp/stream.m3u8", output},
+		{"-i", "pipe:0", "-hls_segment_filename", "/tmp/%d.ts", output},
+		{"-i", "pipe:0", "-hls_fmp4_init_filename", "../../init.mp4", output},
+		{"-i", "pipe:0", "-vstats_file", "/tmp/stats", output},
+		{"-i", "pipe:0", "-filter:v:0", "movie=/etc/passwd", output},
+		{"-i", "pipe:0", "-filter:v:0", "drawtext=textfile=/etc/passwd", output},
+		{"-i", "pipe:0", "-filter:v:0", "scale=1280:-2,subtitles=passwd", output},
+		{"-i", "pipe:0", "-filter:v:0", "scale='1280,lut3d=file=lut'", output},
+		{"-i", "pipe:0", "-filter:v:0", "sendcmd=f=commands", output},
+		{"-i", "pipe:0", "-x264-params:v:0", "scenecut=0:stats=x264.log", output},
+		{"-i", "pipe:0", "-x265-params:v:0", "csv=x265.csv", output},
+		{"-vaapi_device", "/dev/dri/../../etc/passwd", "-i", "pipe:0", output},
+		{"-i", "pipe:0", "-progress", "/tmp/progress", output},
+		{"-i", "pipe:0", output, "-f"},
+		{"-i", "pipe:0"},
+		{"-i", "pipe:0", "http://127.0.0.1:9999/%v/stream.m3u8"},
+	}
+	for _, arguments := range invalid {
+		if err := validateTranscoderWorkerArguments(arguments, "8123"); err == nil {
+			t.Errorf("expected %v to be refused", arguments)
+		}
+	}
+}
+
+// The commands the transcoder builds can all be run by a worker.
+func TestTranscoderWorkerArgumentsForEachCodec(t *testing.T) {
+	codecs := []Codec{&Libx264Codec{}, &OmxCodec{}, &VaapiCodec{}, &NvencCodec{}, &QuicksyncCodec{}, &Video4Linux{}, &Libx265Codec{}, &SvtAv1Codec{}}
+
+	for _, codec := range codecs {
+		for level := range models.GetLatencyConfigs() {
+			for _, segmentFormat := range []string{models.SegmentFormatMPEGTS, models.SegmentFormatFMP4} {
+				transcoder := new(Transcoder)
+				transcoder.ffmpegPath = "ffmpeg"
+				transcoder.SetPullInput("rtsp://camera.local/stream")
+				transcoder.SetIdentifier("jdofFGg")
+				transcoder.SetInternalHTTPPort("8123")
+				transcoder.SetCodec(codec.Name())
+				transcoder.currentLatencyLevel = models.GetLatencyLevel(level)
+				transcoder.segmentFormat = segmentFormat
+				transcoder.startNumber = 5
+
+				variant := HLSVariant{}
+				variant.videoBitrate = 1200
+				variant.SetVideoScalingWidth(1280)
+				variant.SetAudioBitrate("128k")
+				variant.SetVideoFramerate(30)
+				variant.SetCPUUsageLevel(2)
+				transcoder.AddVariant(variant)
+
+				passthrough := HLSVariant{}
+				passthrough.isAudioPassthrough = true
+				passthrough.isVideoPassthrough = true
+				transcoder.AddVariant(passthrough)
+
+				arguments := getRemoteCommandArguments(transcoder.getString())
+				if err := validateTranscoderWorkerArguments(arguments, "8123"); err != nil {
+					t.Errorf("expected the %s command at latency level %d with %s segments to be allowed, got %v", codec.Name(), level, segmentFormat, err)
+				}
+			}
+		}
+	}
+}
+
+func TestSplitShellArguments(t *testing.T) {
+	arguments := splitShellArguments(`FFREPORT=file="a b":level=32  ffmpeg -x "scenecut=0" -i 'rtsp://a/it'\''s' -m a:0?`)
+	expected := []string{"FFREPORT=file=a b:level=32", "ffmpeg", "-x", "scenecut=0", "-i", "rtsp://a/it's", "-m", "a:0?"}
+
+	if strings.Join(arguments, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %q, got %q", expected, arguments)
+	}
+}
+
+func TestRemoteFilePayload(t *testing.T) {
+	filePath, content, err := parseRemoteFilePayload(getRemoteFilePayload("/hls/../../etc/0/stream.m3u8", []byte("#EXTM3U")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filePath != "/etc/0/stream.m3u8" {
+		t.Errorf("expected the path to be cleaned, got %s", filePath)
+	}
+
+	if string(content) != "#EXTM3U" {
+		t.Errorf("unexpected content %q", content)
+	}
+
+	if _, _, err := parseRemoteFilePayload([]byte{0, 10, 'a'}); err == nil {
+		t.Error("expected a truncated file to be invalid")
+	}
+}
@@ -0,0 +1,381 @@
+package transcoder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafov/m3u8"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/config"
+	"github.com/owncast/owncast/logging"
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+// The transcoder reports its progress as it runs. One that exits, or falls
+// behind the live stream, is restarted while its input is still available.
+const (
+	// Live input arrives unevenly, so a transcoder is only stalled once it
+	// is clearly slower than 1x.
+	stalledTranscoderSpeed = 0.9
+
+	// How long the speed of the transcoder is measured over, and how long it
+	// can go without reporting its progress.
+	transcoderStallWindow = 15 * time.Second
+
+	// A transcoder that runs for this long has recovered, and the restarts
+	// before it no longer count toward giving up.
+	healthyTranscoderDuration = time.Minute
+
+	maxTranscoderRestarts     = 5
+	maxTranscoderRestartDelay = 30 * time.Second
+
+	// The variant the media sequence of the stream is read from.
+	mediaSequenceVariantIndex = "0"
+)
+
+// The media sequence number of the next segment, so a restarted transcoder
+// carries on from where the last one stopped.
+var (
+	_nextMediaSequence     uint64
+	_nextMediaSequenceLock sync.Mutex
+)
+
+type transcoderProgress struct {
+	frames           int
+	framerate        float64
+	droppedFrames    int
+	duplicatedFrames int
+	outTime          time.Duration
+	speed            float64
+	ended            bool
+}
+
+// transcoderMonitor follows the progress of a transcoder process to tell
+// when it has stalled.
+type transcoderMonitor struct {
+	windowStart   time.Time
+	windowOutTime time.Duration
+	lastProgress  time.Time
+}
+
+func newTranscoderMonitor(now time.Time) *transcoderMonitor {
+	return &transcoderMonitor{lastProgress: now}
+}
+
+// update will add a progress report, returning the speed of the transcoder
+// across the last window once it is measured, and if it was too slow.
+func (m *transcoderMonitor) update(progress transcoderProgress, now time.Time) (float64, bool) {
+	m.lastProgress = now
+
+	if m.windowStart.IsZero() {
+		m.windowStart = now
+		m.windowOutTime = progress.outTime
+		return 0, false
+	}
+
+	elapsed := now.Sub(m.windowStart)
+	if elapsed < transcoderStallWindow {
+		return 0, false
+	}
+
+	speed := float64(progress.outTime-m.windowOutTime) / float64(elapsed)
+	m.windowStart = now
+	m.windowOutTime = progress.outTime
+
+	return speed, speed < stalledTranscoderSpeed
+}
+
+// isSilent will return true if the transcoder has stopped reporting its progress.
+func (m *transcoderMonitor) isSilent(now time.Time) bool {
+	return now.Sub(m.lastProgress) > transcoderStallWindow
+}
+
+// readTranscoderProgress will call report with each update the transcoder
+// writes with -progress.
+func readTranscoderProgress(r io.Reader, report func(transcoderProgress)) {
+	progress := transcoderProgress{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		components := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(components) != 2 {
+			continue
+		}
+
+		key, value := components[0], strings.TrimSpace(components[1])
+		switch key {
+		case "frame":
+			progress.frames, _ = strconv.Atoi(value)
+		case "fps":
+			progress.framerate, _ = strconv.ParseFloat(value, 64)
+		case "drop_frames":
+			progress.droppedFrames, _ = strconv.Atoi(value)
+		case "dup_frames":
+			progress.duplicatedFrames, _ = strconv.Atoi(value)
+		case "out_time_us":
+			if microseconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				progress.outTime = time.Duration(microseconds) * time.Microsecond
+			}
+		case "speed":
+			progress.speed, _ = strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+		case "progress":
+			progress.ended = value == "end"
+			report(progress)
+			progress = transcoderProgress{}
+		}
+	}
+}
+
+// getTranscoderRestartDelay will return how long to wait before restarting
+// the transcoder, which doubles with each restart that follows a failure.
+func getTranscoderRestartDelay(restarts int) time.Duration {
+	delay := time.Second
+	for i := 0; i < restarts && delay < maxTranscoderRestartDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxTranscoderRestartDelay {
+		return maxTranscoderRestartDelay
+	}
+
+	return delay
+}
+
+// run will run the transcoder until it is stopped or its input ends. It is
+// restarted when it exits or stalls while its input is still available.
+func (t *Transcoder) run() error {
+	for {
+		started := time.Now()
+		err := t.runProcess()
+
+		if t.isStopped() || t.RestartInput == nil {
+			return err
+		}
+
+		t.lock.Lock()
+		if time.Since(started) >= healthyTranscoderDuration {
+			t.recentRestarts = 0
+		}
+		restarts := t.recentRestarts
+		t.lock.Unlock()
+
+		if restarts >= maxTranscoderRestarts {
+			log.Errorln("The transcoder failed to recover after", restarts, "restarts. Ending the stream. See", logging.GetTranscoderLogFilePath(), "for full output to debug.")
+			return err
+		}
+
+		// The input ending is the stream ending.
+		if !t.RestartInput(t) {
+			return err
+		}
+
+		delay := getTranscoderRestartDelay(restarts)
+		log.Warnf("The transcoder stopped while the stream is live. Restarting it in %s.", delay)
+
+		select {
+		case <-t.stopping:
+			return err
+		case <-time.After(delay):
+		}
+
+		t.lock.Lock()
+		t.recentRestarts++
+		t.health.Restarts++
+		t.health.Stalled = false
+		t.lock.Unlock()
+
+		t.prepareRestart()
+	}
+}
+
+// prepareRestart will set up the transcoder to carry on the stream. Its
+// segments are named apart from the last transcoder's, and numbered after them.
+func (t *Transcoder) prepareRestart() {
+	_lastTranscoderLogMessage = ""
+	t.segmentIdentifier = ""
+
+	// Low-latency playlists are written by us, and carry on by themselves.
+	if !t.currentLatencyLevel.IsLowLatencyHLS() {
+		t.startNumber = getNextMediaSequence()
+	}
+}
+
+// runProcess will run a single transcoder process until it exits.
+func (t *Transcoder) runProcess() error {
+	var stdin io.Reader
+	if t.stdin != nil {
+		stdin = t.stdin
+	}
+
+	process, err := t.getBackend().Start(func(outputPort string) string {
+		t.internalListenerPort = outputPort
+		command := t.getString()
+		if config.EnableDebugFeatures {
+			log.Println(command)
+		}
+		return command
+	}, stdin)
+	if err != nil {
+		log.Errorln("Transcoder error.  See ", logging.GetTranscoderLogFilePath(), " for full output to debug.")
+		log.Errorln(err)
+		return err
+	}
+	_transcoderProcess = process
+
+	// The transcoder may have been stopped while it was restarting.
+	if t.isStopped() {
+		_ = process.Kill()
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(process.Messages())
+		for scanner.Scan() {
+			line := scanner.Text()
+			handleTranscoderMessage(line)
+		}
+	}()
+
+	monitor := newTranscoderMonitor(time.Now())
+	go readTranscoderProgress(process.Progress(), func(p transcoderProgress) {
+		t.progressReported(process, monitor, p)
+	})
+
+	done := make(chan struct{})
+	go t.watchForSilence(process, monitor, done)
+
+	err = process.Wait()
+	close(done)
+
+	return err
+}
+
+// progressReported will update the health of the transcoder with its
+// latest progress.
+func (t *Transcoder) progressReported(process TranscoderProcess, monitor *transcoderMonitor, progress transcoderProgress) {
+	now := time.Now()
+
+	t.lock.Lock()
+	t.health.Speed = progress.speed
+	t.health.Framerate = progress.framerate
+	t.health.Frames = progress.frames
+	t.health.DroppedFrames = progress.droppedFrames
+	t.health.DuplicatedFrames = progress.duplicatedFrames
+	t.health.LastUpdated = now
+	speed, stalled := monitor.update(progress, now)
+	t.lock.Unlock()
+
+	if stalled && !progress.ended {
+		t.restartStalled(process, fmt.Sprintf("running at %.2fx", speed))
+	}
+}
+
+// watchForSilence will restart the transcoder if it stops reporting its
+// progress before done is closed.
+func (t *Transcoder) watchForSilence(process TranscoderProcess, monitor *transcoderMonitor, done chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			t.lock.Lock()
+			silent := monitor.isSilent(now)
+			t.lock.Unlock()
+
+			if silent {
+				t.restartStalled(process, "not making progress")
+				return
+			}
+		}
+	}
+}
+
+// restartStalled will end a transcoder process that has stalled so it is
+// restarted. One that keeps stalling is left running, as restarting it
+// again would not help.
+func (t *Transcoder) restartStalled(process TranscoderProcess, reason string) {
+	t.lock.Lock()
+	wasStalled := t.health.Stalled
+	t.health.Stalled = true
+	canRestart := t.RestartInput != nil && t.recentRestarts < maxTranscoderRestarts
+	t.lock.Unlock()
+
+	if !canRestart {
+		if !wasStalled {
+			log.Warnln("The transcoder is", reason, "and can not keep up with the stream. Try lowering the quality of your stream output variants.")
+		}
+		return
+	}
+
+	log.Warnln("The transcoder is", reason, "and can not keep up with the stream. Restarting it.")
+	if err := process.Kill(); err != nil {
+		log.Debugln(err)
+	}
+}
+
+func (t *Transcoder) isStopped() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.stopped
+}
+
+// GetHealth will return how well the transcoder is keeping up with the stream.
+func (t *Transcoder) GetHealth() models.TranscoderHealth {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.health
+}
+
+// mediaSequencePlaylistWritten will keep the media sequence number of the
+// next segment from a variant playlist.
+func mediaSequencePlaylistWritten(localFilePath string) {
+	if utils.GetIndexFromFilePath(localFilePath) != mediaSequenceVariantIndex {
+		return
+	}
+
+	f, err := os.Open(localFilePath)
+	if err != nil {
+		log.Debugln(err)
+		return
+	}
+	defer f.Close()
+
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != m3u8.MEDIA {
+		return
+	}
+	playlist := p.(*m3u8.MediaPlaylist)
+
+	_nextMediaSequenceLock.Lock()
+	defer _nextMediaSequenceLock.Unlock()
+
+	if next := playlist.SeqNo + uint64(playlist.Count()); next > _nextMediaSequence {
+		_nextMediaSequence = next
+	}
+}
+
+func getNextMediaSequence() uint64 {
+	_nextMediaSequenceLock.Lock()
+	defer _nextMediaSequenceLock.Unlock()
+
+	return _nextMediaSequence
+}
+
+func resetNextMediaSequence() {
+	_nextMediaSequenceLock.Lock()
+	defer _nextMediaSequenceLock.Unlock()
+
+	_nextMediaSequence = 0
+}
@@ -0,0 +1,89 @@
+package transcoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscoderProgress(t *testing.T) {
+	output := strings.Join([]string{
+		"frame=120",
+		"fps=29.97",
+		"stream_0_0_q=23.0",
+		"bitrate=N/A",
+		"out_time_us=4000000",
+		"out_time=00:00:04.000000",
+		"dup_frames=2",
+		"drop_frames=1",
+		"speed=0.98x",
+		"progress=continue",
+		"frame=135",
+		"fps=N/A",
+		"speed=N/A",
+		"progress=end",
+	}, "\n")
+
+	reports := []transcoderProgress{}
+	readTranscoderProgress(strings.NewReader(output), func(p transcoderProgress) {
+		reports = append(reports, p)
+	})
+
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 progress reports, got %d", len(reports))
+	}
+
+	expected := transcoderProgress{
+		frames:           120,
+		framerate:        29.97,
+		droppedFrames:    1,
+		duplicatedFrames: 2,
+		outTime:          4 * time.Second,
+		speed:            0.98,
+	}
+	if reports[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, reports[0])
+	}
+
+	if !reports[1].ended || reports[1].frames != 135 || reports[1].speed != 0 {
+		t.Errorf("expected the final report without a speed, got %+v", reports[1])
+	}
+}
+
+func TestTranscoderStall(t *testing.T) {
+	start := time.Now()
+	monitor := newTranscoderMonitor(start)
+
+	if _, stalled := monitor.update(transcoderProgress{outTime: 0}, start); stalled {
+		t.Error("the first report can not be stalled")
+	}
+
+	// Keeping up with the stream.
+	now := start.Add(transcoderStallWindow)
+	if speed, stalled := monitor.update(transcoderProgress{outTime: transcoderStallWindow}, now); stalled || speed != 1 {
+		t.Errorf("expected a speed of 1x, got %.2fx", speed)
+	}
+
+	// Falling behind.
+	outTime := transcoderStallWindow + transcoderStallWindow/2
+	now = now.Add(transcoderStallWindow)
+	if speed, stalled := monitor.update(transcoderProgress{outTime: outTime}, now); !stalled || speed != 0.5 {
+		t.Errorf("expected a stall at 0.5x, got %.2fx", speed)
+	}
+
+	if monitor.isSilent(now.Add(transcoderStallWindow)) {
+		t.Error("expected the transcoder to still be reporting its progress")
+	}
+	if !monitor.isSilent(now.Add(transcoderStallWindow + time.Second)) {
+		t.Error("expected the transcoder to have stopped reporting its progress")
+	}
+}
+
+func TestTranscoderRestartDelay(t *testing.T) {
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for restarts, delay := range expected {
+		if actual := getTranscoderRestartDelay(restarts); actual != delay {
+			t.Errorf("expected a delay of %s after %d restarts, got %s", delay, restarts, actual)
+		}
+	}
+}
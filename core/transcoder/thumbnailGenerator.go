@@ -2,6 +2,7 @@ package transcoder
 
 import (
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path"
 	"strconv"
@@ -17,6 +18,10 @@ import (
 
 var _timer *time.Ticker
 
+// The logo and the time it was changed when the thumbnail was last made from
+// it, so it is only made again once the logo changes.
+var _logoThumbnailSource string
+
 func StopThumbnailGenerator() {
 	if _timer != nil {
 		_timer.Stop()
@@ -28,6 +33,7 @@ func StartThumbnailGenerator(chunkPath string, variantIndex int) {
 	// Every 20 seconds create a thumbnail from the most
 	// recent video segment.
 	_timer = time.NewTicker(20 * time.Second)
+	_logoThumbnailSource = ""
 	quit := make(chan struct{})
 
 	go func() {
@@ -51,6 +57,11 @@ func fireThumbnailGenerator(segmentPath string, variantIndex int) error {
 	outputFile := path.Join(config.WebRoot, "thumbnail.jpg")
 	previewGifFile := path.Join(config.WebRoot, "preview.gif")
 
+	// Without video the logo is used as the thumbnail.
+	if data.GetRadioModeEnabled() || isAudioOnlyVariant(variantIndex) {
+		return makeThumbnailFromLogo(outputFile)
+	}
+
 	framePath := path.Join(segmentPath, strconv.Itoa(variantIndex))
 	files, err := ioutil.ReadDir(framePath)
 	if err != nil {
@@ -59,8 +70,15 @@ func fireThumbnailGenerator(segmentPath string, variantIndex int) error {
 
 	var modTime time.Time
 	var names []string
+	var initSegment string
+	var initModTime time.Time
 	for _, fi := range files {
-		if path.Ext(fi.Name()) != ".ts" {
+		if path.Ext(fi.Name()) == ".mp4" && fi.ModTime().After(initModTime) {
+			initSegment = fi.Name()
+			initModTime = fi.ModTime()
+		}
+
+		if !utils.IsVideoSegment(fi.Name()) {
 			continue
 		}
 
@@ -80,6 +98,17 @@ func fireThumbnailGenerator(segmentPath string, variantIndex int) error {
 	}
 
 	mostRecentFile := path.Join(framePath, names[0])
+
+	// fMP4 segments can only be decoded along with their initialization segment.
+	if path.Ext(mostRecentFile) == ".m4s" && initSegment != "" {
+		joinedFile, err := joinInitSegment(path.Join(framePath, initSegment), mostRecentFile)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(joinedFile)
+		mostRecentFile = joinedFile
+	}
+
 	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
 
 	thumbnailCmdFlags := []string{
@@ -106,6 +135,81 @@ func fireThumbnailGenerator(segmentPath string, variantIndex int) error {
 	return nil
 }
 
+// isAudioOnlyVariant will return if the variant has no video.
+func isAudioOnlyVariant(variantIndex int) bool {
+	outputSettings := data.GetStreamOutputVariants()
+	return variantIndex < len(outputSettings) && outputSettings[variantIndex].IsAudioOnly
+}
+
+// makeThumbnailFromLogo will write the logo as the thumbnail. A SVG logo can't
+// be converted, so the thumbnail is removed and the logo is used in its place.
+func makeThumbnailFromLogo(outputFile string) error {
+	logoPath := path.Join("data", data.GetLogoPath())
+	if path.Ext(logoPath) == ".svg" || !utils.DoesFileExists(logoPath) {
+		if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	info, err := os.Stat(logoPath)
+	if err != nil {
+		return err
+	}
+
+	source := logoPath + " " + info.ModTime().String()
+	if source == _logoThumbnailSource && utils.DoesFileExists(outputFile) {
+		return nil
+	}
+
+	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
+
+	thumbnailCmdFlags := []string{
+		ffmpegPath,
+		"-y",           // Overwrite file
+		"-threads 1",   // Low priority processing
+		"-i", logoPath, // Input
+		"-f image2",  // format
+		"-vframes 1", // Single frame
+		outputFile,
+	}
+
+	ffmpegCmd := strings.Join(thumbnailCmdFlags, " ")
+	if _, err := exec.Command("sh", "-c", ffmpegCmd).Output(); err != nil {
+		return err
+	}
+
+	_logoThumbnailSource = source
+	return nil
+}
+
+// joinInitSegment will write an fMP4 segment after its initialization segment
+// in a temporary file that can be read on its own.
+func joinInitSegment(initSegmentPath string, segmentPath string) (string, error) {
+	initSegment, err := ioutil.ReadFile(initSegmentPath) // nolint
+	if err != nil {
+		return "", err
+	}
+
+	segment, err := ioutil.ReadFile(segmentPath) // nolint
+	if err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile("", "owncast-thumbnail-*.mp4")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(initSegment, segment...)); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
 func makeAnimatedGifPreview(sourceFile string, outputFile string) {
 	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
 
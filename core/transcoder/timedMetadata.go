@@ -0,0 +1,249 @@
+package transcoder
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/models"
+	"github.com/owncast/owncast/utils"
+)
+
+// Timed metadata is added to the segments of every stream variant as they
+// are written, at its time in the stream, so players can show it in sync
+// with the video.
+var (
+	_timedMetadata     *timedMetadataStream
+	_timedMetadataLock sync.Mutex
+)
+
+var (
+	// ErrTimedMetadataUnavailable is returned when metadata is sent while
+	// the stream is not live.
+	ErrTimedMetadataUnavailable = errors.New("metadata can only be added while the stream is live")
+
+	// ErrTimedMetadataEmpty is returned when metadata has no title or values.
+	ErrTimedMetadataEmpty = errors.New("metadata must have a title or values")
+
+	// ErrTimedMetadataTooLarge is returned when metadata is too large to be
+	// added to the stream.
+	ErrTimedMetadataTooLarge = errors.New("metadata is too large to be added to the stream")
+)
+
+// The size an ID3 tag has to fit in a single PES packet.
+const maxID3TagSize = 60000
+
+type timedMetadataItem struct {
+	id       uint32
+	time     float64 // The number of seconds into the stream
+	tag      []byte
+	inserted map[string]bool // The variants the metadata has been added to
+}
+
+// timedMetadataVariant is what is known about the segments of a variant.
+type timedMetadataVariant struct {
+	offset    float64 // The time the variant's first segment starts at
+	hasOffset bool
+	track     fmp4Track
+	hasTrack  bool
+}
+
+type timedMetadataStream struct {
+	startTime       time.Time
+	variantCount    int
+	segmentDuration float64
+
+	nextID   uint32
+	items    []*timedMetadataItem
+	variants map[string]*timedMetadataVariant
+}
+
+// startTimedMetadata will start accepting metadata for the stream started
+// at startTime, if it is live.
+func startTimedMetadata(live bool, startTime time.Time, variantCount int, segmentDuration float64) {
+	_timedMetadataLock.Lock()
+	defer _timedMetadataLock.Unlock()
+
+	_timedMetadata = nil
+	if !live {
+		return
+	}
+
+	_timedMetadata = &timedMetadataStream{
+		startTime:       startTime,
+		variantCount:    variantCount,
+		segmentDuration: segmentDuration,
+		variants:        make(map[string]*timedMetadataVariant),
+	}
+}
+
+// stopTimedMetadata will stop accepting metadata for the stream started at
+// startTime.
+func stopTimedMetadata(startTime time.Time) {
+	_timedMetadataLock.Lock()
+	defer _timedMetadataLock.Unlock()
+
+	if _timedMetadata != nil && _timedMetadata.startTime.Equal(startTime) {
+		_timedMetadata = nil
+	}
+}
+
+// AddTimedMetadata will add ID3 metadata to the live stream.
+func AddTimedMetadata(metadata models.TimedMetadata) error {
+	if metadata.Title == "" && len(metadata.Values) == 0 {
+		return ErrTimedMetadataEmpty
+	}
+
+	tag := getID3Tag(metadata)
+	if len(tag) > maxID3TagSize {
+		return ErrTimedMetadataTooLarge
+	}
+
+	_timedMetadataLock.Lock()
+	defer _timedMetadataLock.Unlock()
+
+	if _timedMetadata == nil {
+		return ErrTimedMetadataUnavailable
+	}
+
+	item := &timedMetadataItem{
+		id:       _timedMetadata.nextID,
+		time:     time.Since(_timedMetadata.startTime).Seconds(),
+		tag:      tag,
+		inserted: make(map[string]bool),
+	}
+	if metadata.Start != nil {
+		item.time = *metadata.Start
+	}
+
+	_timedMetadata.nextID++
+	_timedMetadata.items = append(_timedMetadata.items, item)
+
+	return nil
+}
+
+// timedMetadataSegmentWritten will add the metadata that is due to a
+// segment of a stream variant.
+func timedMetadataSegmentWritten(localFilePath string) {
+	variant := utils.GetIndexFromFilePath(localFilePath)
+	if _, err := strconv.Atoi(variant); err != nil {
+		return
+	}
+
+	_timedMetadataLock.Lock()
+	defer _timedMetadataLock.Unlock()
+
+	if _timedMetadata == nil {
+		return
+	}
+
+	// Once the time a variant starts at is known, its segments are only read
+	// when there is something to add to them. fMP4 segment times are read
+	// with the track of its initialization segment.
+	extension := path.Ext(localFilePath)
+	v := _timedMetadata.variants[variant]
+	if extension != ".mp4" && len(_timedMetadata.items) == 0 && v != nil && v.hasOffset {
+		return
+	}
+
+	content, err := ioutil.ReadFile(localFilePath) // nolint
+	if err != nil {
+		log.Debugln(err)
+		return
+	}
+
+	updated, changed := _timedMetadata.addToSegment(variant, extension, content)
+	if !changed {
+		return
+	}
+
+	if err := ioutil.WriteFile(localFilePath, updated, 0600); err != nil {
+		log.Errorln(err)
+	}
+}
+
+// addToSegment will return the segment with the metadata that is due to it
+// added, and if it was changed.
+func (s *timedMetadataStream) addToSegment(variant string, extension string, content []byte) ([]byte, bool) {
+	v := s.variants[variant]
+	if v == nil {
+		v = &timedMetadataVariant{}
+		s.variants[variant] = v
+	}
+
+	var start uint64
+	var timescale uint32
+	var ok bool
+
+	switch extension {
+	case ".mp4":
+		v.track, v.hasTrack = getInitSegmentTrack(content)
+		return content, false
+	case ".ts":
+		start, ok = getTSStartTime(content)
+		timescale = tsClockRate
+	case ".m4s":
+		if v.hasTrack {
+			start, ok = getSegmentDecodeTime(content, v.track.id)
+			timescale = v.track.timescale
+		}
+	}
+
+	if !ok {
+		return content, false
+	}
+
+	// The stream starts with the first segment of each variant.
+	segmentTime := float64(start) / float64(timescale)
+	if !v.hasOffset {
+		v.offset = segmentTime
+		v.hasOffset = true
+	}
+	segmentStart := segmentTime - v.offset
+	segmentEnd := segmentStart + s.segmentDuration
+
+	var tsItems []tsMetadata
+	var eventMessages []byte
+
+	for _, item := range s.items {
+		if item.inserted[variant] || item.time >= segmentEnd {
+			continue
+		}
+
+		// Metadata from before the segment is shown as soon as it can be.
+		itemTime := math.Max(item.time, segmentStart) + v.offset
+		presentationTime := uint64(math.Round(itemTime * float64(timescale)))
+
+		if extension == ".ts" {
+			tsItems = append(tsItems, tsMetadata{pts: presentationTime, tag: item.tag})
+		} else {
+			eventMessages = append(eventMessages, getID3EventMessage(item.id, timescale, presentationTime, item.tag)...)
+		}
+		item.inserted[variant] = true
+	}
+
+	// Metadata is kept until it has been added to every variant.
+	items := s.items[:0]
+	for _, item := range s.items {
+		if len(item.inserted) < s.variantCount {
+			items = append(items, item)
+		}
+	}
+	s.items = items
+
+	if len(tsItems) > 0 {
+		return insertTSMetadata(content, tsItems)
+	}
+
+	if len(eventMessages) > 0 {
+		return insertBeforeBox(content, "moof", eventMessages)
+	}
+
+	return content, false
+}
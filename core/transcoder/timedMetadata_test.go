@@ -0,0 +1,134 @@
+package transcoder
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/owncast/owncast/core/mpegts"
+	"github.com/owncast/owncast/models"
+)
+
+func TestID3Tag(t *testing.T) {
+	tag := getID3Tag(models.TimedMetadata{Title: "Hi", Values: map[string]string{"poll": "1"}})
+
+	expected := []byte{
+		'I', 'D', '3', 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1E,
+		'T', 'I', 'T', '2', 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 'H', 'i',
+		'T', 'X', 'X', 'X', 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x03, 'p', 'o', 'l', 'l', 0x00, '1',
+	}
+	if !bytes.Equal(tag, expected) {
+		t.Errorf("ID3 tag does not match expected.\nGot %x\n, want: %x", tag, expected)
+	}
+}
+
+func TestInsertTSMetadata(t *testing.T) {
+	segment, err := ioutil.ReadFile("../../static/offline.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start, ok := getTSStartTime(segment)
+	if !ok {
+		t.Fatal("unable to read the start time of the segment")
+	}
+
+	tag := getID3Tag(models.TimedMetadata{Title: "Now playing"})
+	updated, ok := insertTSMetadata(segment, []tsMetadata{{pts: start + tsClockRate, tag: tag}})
+	if !ok {
+		t.Fatal("metadata was not added to the segment")
+	}
+
+	if len(updated) != len(segment)+mpegts.PacketSize {
+		t.Errorf("expected the metadata to be added as one packet, the segment grew by %d bytes", len(updated)-len(segment))
+	}
+
+	if updatedStart, _ := getTSStartTime(updated); updatedStart != start {
+		t.Errorf("start time changed from %d to %d", start, updatedStart)
+	}
+
+	if startsWithKeyframe(updated) != startsWithKeyframe(segment) {
+		t.Error("adding metadata changed how the segment starts")
+	}
+
+	// Find the updated program map table and the metadata stream it lists.
+	pmtPID := -1
+	metadataPID := -1
+	for b := updated; len(b) >= mpegts.PacketSize && metadataPID < 0; b = b[mpegts.PacketSize:] {
+		pkt := b[:mpegts.PacketSize]
+		pid := mpegts.GetPID(pkt)
+		section := mpegts.GetSection(pkt)
+		if !mpegts.IsUnitStart(pkt) || section == nil {
+			continue
+		}
+
+		if pid == 0 {
+			pmtPID = mpegts.GetPMTPID(section)
+			continue
+		}
+
+		if pid != pmtPID {
+			continue
+		}
+
+		// The CRC of a table with its CRC is zero.
+		if crc := getMPEGCRC32(section[:mpegts.GetSectionEnd(section)+4]); crc != 0 {
+			t.Errorf("program map table has an invalid CRC %x", crc)
+		}
+
+		streams, _ := mpegts.GetElementaryStreams(section)
+		for _, stream := range streams {
+			if stream.StreamType == tsMetadataStreamType {
+				metadataPID = stream.PID
+			}
+		}
+	}
+
+	if metadataPID < 0 {
+		t.Fatal("program map table does not list the metadata stream")
+	}
+
+	for b := updated; len(b) >= mpegts.PacketSize; b = b[mpegts.PacketSize:] {
+		pkt := b[:mpegts.PacketSize]
+		if mpegts.GetPID(pkt) != metadataPID {
+			continue
+		}
+
+		payload := mpegts.GetPayload(pkt)
+		if pts, ok := getPESPresentationTime(payload); !ok || pts != start+tsClockRate {
+			t.Errorf("metadata presentation time is %d, want %d", pts, start+tsClockRate)
+		}
+		if !bytes.HasSuffix(payload, tag) {
+			t.Error("metadata packet does not carry the tag")
+		}
+		return
+	}
+
+	t.Error("metadata packet was not found")
+}
+
+func TestInsertEventMessage(t *testing.T) {
+	segment := []byte{
+		0x00, 0x00, 0x00, 0x08, 's', 't', 'y', 'p',
+		0x00, 0x00, 0x00, 0x08, 'm', 'o', 'o', 'f',
+		0x00, 0x00, 0x00, 0x08, 'm', 'd', 'a', 't',
+	}
+
+	tag := getID3Tag(models.TimedMetadata{Title: "Chapter 2"})
+	updated, ok := insertBeforeBox(segment, "moof", getID3EventMessage(1, 90000, 180000, tag))
+	if !ok {
+		t.Fatal("event message was not added to the segment")
+	}
+
+	boxes := []string{}
+	forEachBox(updated, func(boxType string, payload []byte) {
+		boxes = append(boxes, boxType)
+		if boxType == "emsg" && !bytes.HasSuffix(payload, append([]byte(id3EventMessageScheme+"\x00\x00"), tag...)) {
+			t.Error("event message does not carry the tag")
+		}
+	})
+
+	if len(boxes) != 4 || boxes[1] != "emsg" || boxes[2] != "moof" {
+		t.Errorf("event message is not ahead of the segment's fragment: %v", boxes)
+	}
+}
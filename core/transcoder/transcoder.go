@@ -1,12 +1,12 @@
 package transcoder
 
 import (
-	"bufio"
 	"fmt"
 	"io"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/teris-io/shortid"
@@ -18,11 +18,12 @@ import (
 	"github.com/owncast/owncast/utils"
 )
 
-var _commandExec *exec.Cmd
+var _transcoderProcess TranscoderProcess
 
 // Transcoder is a single instance of a video transcoder.
 type Transcoder struct {
 	input                string
+	inputFlags           string
 	stdin                *io.PipeReader
 	segmentOutputPath    string
 	playlistOutputPath   string
@@ -32,25 +33,48 @@ type Transcoder struct {
 	segmentIdentifier    string
 	internalListenerPort string
 	codec                Codec
+	segmentFormat        string
+	dashEnabled          bool
+	audioTracks          []models.AudioTrack
+	captions             models.Captions
+	startTime            time.Time
+	isOfflineContent     bool
+	backend              TranscoderBackend
+	startNumber          uint64 // The media sequence number of the first segment
 
 	currentStreamOutputSettings []models.StreamOutputVariant
 	currentLatencyLevel         models.LatencyLevel
 
+	lock           sync.Mutex
+	stopped        bool
+	stopping       chan struct{}
+	recentRestarts int
+	health         models.TranscoderHealth
+
 	TranscoderCompleted func(error)
+
+	// RestartInput is called before the transcoder is restarted, and returns
+	// false if its input has ended.
+	RestartInput func(*Transcoder) bool
 }
 
 // HLSVariant is a combination of settings that results in a single HLS stream.
 type HLSVariant struct {
-	index int
+	index      int
+	videoIndex int // The index of this variant's video among the output video streams
 
 	videoSize          VideoSize // Resizes the video via scaling
 	framerate          int       // The output framerate
 	videoBitrate       int       // The output bitrate
 	isVideoPassthrough bool      // Override all settings and just copy the video stream
+	isAudioOnly        bool      // Leave out the video stream
 
 	audioBitrate       string // The audio bitrate
 	isAudioPassthrough bool   // Override all settings and just copy the audio stream
 
+	codec      Codec  // The video codec, when it is different to the transcoder's
+	audioCodec string // The audio codec
+
 	cpuUsageLevel int // The amount of hardware to use for encoding a stream
 }
 
@@ -78,49 +102,53 @@ func (v *VideoSize) getString() string {
 
 func (t *Transcoder) Stop() {
 	log.Traceln("Transcoder STOP requested.")
-	err := _commandExec.Process.Kill()
+
+	t.lock.Lock()
+	if !t.stopped && t.stopping != nil {
+		close(t.stopping)
+	}
+	t.stopped = true
+	t.lock.Unlock()
+
+	if _transcoderProcess == nil {
+		return
+	}
+
+	err := _transcoderProcess.Kill()
 	if err != nil {
 		log.Errorln(err)
 	}
+
+	stopAudioTracks()
 }
 
 // Start will execute the transcoding process with the settings previously set.
 func (t *Transcoder) Start() {
 	_lastTranscoderLogMessage = ""
 
-	command := t.getString()
+	t.lock.Lock()
+	t.stopping = make(chan struct{})
+	t.lock.Unlock()
+
+	t.startTime = time.Now()
 	log.Infof("Video transcoder started using %s with %d stream variants.", t.codec.DisplayName(), len(t.variants))
 	createVariantDirectories()
+	resetNextMediaSequence()
+	startLowLatencyHLS(t.currentLatencyLevel, len(t.variants))
+	startDASHManifest(t.dashEnabled, t.currentStreamOutputSettings, t.currentLatencyLevel)
+	setMasterPlaylistCodecs(t)
+
+	// Nothing can be added to the offline content, as it is not live.
+	captions := t.captions
+	captions.Enabled = captions.Enabled && !t.isOfflineContent
+	startCaptions(captions, t.startTime)
+	startTimedMetadata(!t.isOfflineContent, t.startTime, len(t.variants), t.getSegmentDuration())
+	startTranscoderErrorSession(!t.isOfflineContent)
+
+	err := t.run()
+	stopCaptions(t.startTime)
+	stopTimedMetadata(t.startTime)
 
-	if config.EnableDebugFeatures {
-		log.Println(command)
-	}
-
-	_commandExec = exec.Command("sh", "-c", command)
-
-	if t.stdin != nil {
-		_commandExec.Stdin = t.stdin
-	}
-
-	stdout, err := _commandExec.StderrPipe()
-	if err != nil {
-		panic(err)
-	}
-
-	if err := _commandExec.Start(); err != nil {
-		log.Errorln("Transcoder error.  See ", logging.GetTranscoderLogFilePath(), " for full output to debug.")
-		log.Panicln(err, command)
-	}
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			handleTranscoderMessage(line)
-		}
-	}()
-
-	err = _commandExec.Wait()
 	if t.TranscoderCompleted != nil {
 		t.TranscoderCompleted(err)
 	}
@@ -144,17 +172,40 @@ func (t *Transcoder) getString() string {
 		t.segmentIdentifier = shortid.MustGenerate()
 	}
 
+	segmentDuration := strconv.Itoa(t.currentLatencyLevel.SecondsPerSegment)
+	playlistLength := t.currentLatencyLevel.SegmentCount
+	segmentExtension, segmentTypeFlags := t.getSegmentTypeFlags("stream-" + t.segmentIdentifier)
+	strftimeFlag := "-strftime 1" // Support the use of strftime in filenames
+
+	segmentFilename := "/%v/stream-" + t.segmentIdentifier + "%s" + segmentExtension
+
+	// For low-latency HLS the transcoder writes the partial segments. They are
+	// numbered so the name of the next one can be hinted to players ahead of time.
+	if t.currentLatencyLevel.IsLowLatencyHLS() {
+		hlsOptionFlags = append(hlsOptionFlags, "split_by_time")
+		segmentDuration = strconv.FormatFloat(t.currentLatencyLevel.SecondsPerPart, 'f', -1, 64)
+		playlistLength = (t.currentLatencyLevel.SegmentCount + 1) * t.currentLatencyLevel.GetPartsPerSegment()
+		segmentFilename = "/%v/stream-" + t.segmentIdentifier + "-%d" + segmentExtension
+		strftimeFlag = ""
+	}
+
 	hlsOptionsString := ""
 	if len(hlsOptionFlags) > 0 {
 		hlsOptionsString = "-hls_flags " + strings.Join(hlsOptionFlags, "+")
 	}
+
+	// A restarted transcoder numbers its segments after the last one's.
+	if t.startNumber > 0 {
+		hlsOptionsString = strings.TrimSpace(hlsOptionsString + " -start_number " + strconv.FormatUint(t.startNumber, 10))
+	}
 	ffmpegFlags := []string{
 		fmt.Sprintf(`FFREPORT=file="%s":level=32`, logging.GetTranscoderLogFilePath()),
 		t.ffmpegPath,
 		"-hide_banner",
 		"-loglevel warning",
-		t.codec.GlobalFlags(),
-		"-fflags +genpts", // Generate presentation time stamp if missing
+		"-progress pipe:1", // Report progress to the supervisor
+		t.getGlobalFlagsString(),
+		"-fflags +genpts" + t.getInputFlagsString(), // Generate presentation time stamp if missing
 		"-i ", t.input,
 
 		t.getVariantsString(),
@@ -162,21 +213,20 @@ func (t *Transcoder) getString() string {
 		// HLS Output
 		"-f", "hls",
 
-		"-hls_time", strconv.Itoa(t.currentLatencyLevel.SecondsPerSegment), // Length of each segment
-		"-hls_list_size", strconv.Itoa(t.currentLatencyLevel.SegmentCount), // Max # in variant playlist
+		"-hls_time", segmentDuration, // Length of each segment
+		"-hls_list_size", strconv.Itoa(playlistLength), // Max # in variant playlist
 		hlsOptionsString,
-		"-segment_format_options", "mpegts_flags=+initial_discontinuity:mpegts_copyts=1",
+		segmentTypeFlags,
 
 		// Video settings
-		t.codec.ExtraArguments(),
-		"-pix_fmt", t.codec.PixelFormat(),
+		t.getCodecArgumentsString(),
 		"-sc_threshold", "0", // Disable scene change detection for creating segments
 
 		// Filenames
 		"-master_pl_name", "stream.m3u8",
-		"-strftime 1", // Support the use of strftime in filenames
+		strftimeFlag,
 
-		"-hls_segment_filename", localListenerAddress + "/%v/stream-" + t.segmentIdentifier + "%s.ts", // Send HLS segments back to us over HTTP
+		"-hls_segment_filename", localListenerAddress + segmentFilename, // Send HLS segments back to us over HTTP
 		"-max_muxing_queue_size", "400", // Workaround for Too many packets error: https://trac.ffmpeg.org/ticket/6375?cversion=0
 
 		"-method PUT -http_persistent 0",         // HLS results sent back to us will be over PUTs
@@ -186,6 +236,27 @@ func (t *Transcoder) getString() string {
 	return strings.Join(ffmpegFlags, " ")
 }
 
+// getSegmentDuration will return the number of seconds each segment the
+// transcoder writes is, which are partial segments for low-latency HLS.
+func (t *Transcoder) getSegmentDuration() float64 {
+	if t.currentLatencyLevel.IsLowLatencyHLS() {
+		return t.currentLatencyLevel.SecondsPerPart
+	}
+
+	return float64(t.currentLatencyLevel.SecondsPerSegment)
+}
+
+// getSegmentTypeFlags will return the extension and flags of the segments
+// the transcoder writes for the segment format.
+func (t *Transcoder) getSegmentTypeFlags(name string) (string, string) {
+	// fMP4 segments share an initialization segment written alongside them.
+	if t.segmentFormat == models.SegmentFormatFMP4 {
+		return ".m4s", "-hls_segment_type fmp4 -hls_fmp4_init_filename " + name + "-init.mp4"
+	}
+
+	return ".ts", "-segment_format_options mpegts_flags=+initial_discontinuity:mpegts_copyts=1"
+}
+
 func getVariantFromConfigQuality(quality models.StreamOutputVariant, index int) HLSVariant {
 	variant := HLSVariant{}
 	variant.index = index
@@ -197,6 +268,15 @@ func getVariantFromConfigQuality(quality models.StreamOutputVariant, index int)
 		variant.isAudioPassthrough = true
 	}
 
+	variant.SetAudioCodec(quality.AudioCodec)
+
+	// Variants without video only need their audio settings.
+	if quality.IsAudioOnly {
+		variant.isAudioOnly = true
+		variant.SetAudioBitrate(strconv.Itoa(quality.AudioBitrate) + "k")
+		return variant
+	}
+
 	if quality.VideoBitrate == 0 {
 		quality.VideoBitrate = 1200
 	}
@@ -218,21 +298,86 @@ func getVariantFromConfigQuality(quality models.StreamOutputVariant, index int)
 	variant.SetVideoScalingHeight(quality.ScaledHeight)
 	variant.SetVideoFramerate(quality.GetFramerate())
 
+	if quality.VideoCodec != "" {
+		variant.SetCodec(quality.VideoCodec)
+	}
+
 	return variant
 }
 
 // NewTranscoder will return a new Transcoder, populated by the config.
 func NewTranscoder() *Transcoder {
+	return NewTranscoderWithOutputVariants(data.GetStreamOutputVariants())
+}
+
+// NewTranscoderWithOutputVariants will return a new Transcoder, populated by
+// the config, that transcodes to the stream output variants provided.
+func NewTranscoderWithOutputVariants(outputVariants []models.StreamOutputVariant) *Transcoder {
 	ffmpegPath := utils.ValidatedFfmpegPath(data.GetFfMpegPath())
 
 	transcoder := new(Transcoder)
 	transcoder.ffmpegPath = ffmpegPath
 	transcoder.internalListenerPort = config.InternalHLSListenerPort
 
-	transcoder.currentStreamOutputSettings = data.GetStreamOutputVariants()
+	// The worker runs its own copy of ffmpeg, set up when it is started.
+	if remoteTranscoder := data.GetRemoteTranscoder(); remoteTranscoder.Enabled {
+		transcoder.backend = RemoteTranscoderBackend{Address: remoteTranscoder.Address, Secret: remoteTranscoder.Secret}
+		transcoder.ffmpegPath = "ffmpeg"
+	}
+
+	transcoder.currentStreamOutputSettings = outputVariants
+
+	// In radio mode the inbound stream may not have any video.
+	if data.GetRadioModeEnabled() {
+		transcoder.currentStreamOutputSettings = getAudioOnlyOutputSettings(transcoder.currentStreamOutputSettings)
+	}
+
 	transcoder.currentLatencyLevel = data.GetStreamLatencyLevel()
 	transcoder.codec = getCodec(data.GetVideoCodec())
 
+	for index, quality := range transcoder.currentStreamOutputSettings {
+		variant := getVariantFromConfigQuality(quality, index)
+		transcoder.AddVariant(variant)
+	}
+
+	transcoder.currentLatencyLevel = transcoder.getSupportedLatencyLevel(transcoder.currentLatencyLevel, data.GetS3Config().Enabled)
+
+	transcoder.segmentFormat = data.GetSegmentFormat()
+	transcoder.dashEnabled = data.GetDASHEnabled()
+
+	if transcoder.requiresFMP4Segments() {
+		transcoder.segmentFormat = models.SegmentFormatFMP4
+	}
+
+	// The DASH manifest is built from fMP4 segments.
+	if transcoder.dashEnabled {
+		transcoder.segmentFormat = models.SegmentFormatFMP4
+	}
+
+	// Low-latency playlists are built from MPEG-TS partial segments.
+	if transcoder.currentLatencyLevel.IsLowLatencyHLS() && transcoder.segmentFormat != models.SegmentFormatMPEGTS {
+		log.Warnln("Low-latency HLS uses MPEG-TS segments. fMP4 segments and the DASH manifest will not be used.")
+		transcoder.segmentFormat = models.SegmentFormatMPEGTS
+		transcoder.dashEnabled = false
+	}
+
+	transcoder.audioTracks = data.GetAudioTracks()
+
+	// Alternate audio tracks are not part of low-latency playlists.
+	if transcoder.currentLatencyLevel.IsLowLatencyHLS() && len(transcoder.audioTracks) > 0 {
+		log.Warnln("Alternate audio tracks are not available with low-latency HLS.")
+		transcoder.audioTracks = nil
+	}
+
+	transcoder.captions = data.GetCaptions()
+
+	// Captions follow full segments, which low-latency playlists are not
+	// built from.
+	if transcoder.currentLatencyLevel.IsLowLatencyHLS() && transcoder.captions.Enabled {
+		log.Warnln("Live captions are not available with low-latency HLS.")
+		transcoder.captions.Enabled = false
+	}
+
 	var outputPath string
 	if data.GetS3Config().Enabled {
 		// Segments are not available via the local HTTP server
@@ -248,16 +393,28 @@ func NewTranscoder() *Transcoder {
 
 	transcoder.input = "pipe:0" // stdin
 
-	for index, quality := range transcoder.currentStreamOutputSettings {
-		variant := getVariantFromConfigQuality(quality, index)
-		transcoder.AddVariant(variant)
+	return transcoder
+}
+
+// getAudioOnlyOutputSettings will return the output settings with the video
+// left out of every variant.
+func getAudioOnlyOutputSettings(outputSettings []models.StreamOutputVariant) []models.StreamOutputVariant {
+	audioOnlySettings := make([]models.StreamOutputVariant, len(outputSettings))
+	for index, quality := range outputSettings {
+		quality.IsAudioOnly = true
+		audioOnlySettings[index] = quality
 	}
 
-	return transcoder
+	return audioOnlySettings
 }
 
 // Uses `map` https://www.ffmpeg.org/ffmpeg-all.html#Stream-specifiers-1 https://www.ffmpeg.org/ffmpeg-all.html#Advanced-options
 func (v *HLSVariant) getVariantString(t *Transcoder) string {
+	if v.isAudioOnly {
+		return v.getAudioQualityString()
+	}
+
+	codec := v.getCodec(t)
 	variantEncoderCommands := []string{
 		v.getVideoQualityString(t),
 		v.getAudioQualityString(),
@@ -268,34 +425,159 @@ func (v *HLSVariant) getVariantString(t *Transcoder) string {
 		filters := []string{
 			v.getScalingString(),
 		}
-		if t.codec.ExtraFilters() != "" {
-			filters = append(filters, t.codec.ExtraFilters())
+		if codec.ExtraFilters() != "" {
+			filters = append(filters, codec.ExtraFilters())
 		}
 		scalingAlgorithm := "bilinear"
-		filterString := fmt.Sprintf("-sws_flags %s -filter:v:%d \"%s\"", scalingAlgorithm, v.index, strings.Join(filters, ","))
+		filterString := fmt.Sprintf("-sws_flags %s -filter:v:%d \"%s\"", scalingAlgorithm, v.videoIndex, strings.Join(filters, ","))
 		variantEncoderCommands = append(variantEncoderCommands, filterString)
-	} else if t.codec.ExtraFilters() != "" && !v.isVideoPassthrough {
-		filterString := fmt.Sprintf("-filter:v:%d \"%s\"", v.index, t.codec.ExtraFilters())
+	} else if codec.ExtraFilters() != "" && !v.isVideoPassthrough {
+		filterString := fmt.Sprintf("-filter:v:%d \"%s\"", v.videoIndex, codec.ExtraFilters())
 		variantEncoderCommands = append(variantEncoderCommands, filterString)
 	}
 
-	preset := t.codec.GetPresetForLevel(v.cpuUsageLevel)
-	if preset != "" {
+	preset := codec.GetPresetForLevel(v.cpuUsageLevel)
+	if preset != "" && t.hasMixedCodecs() {
+		// Presets are named differently by each codec.
+		variantEncoderCommands = append(variantEncoderCommands, fmt.Sprintf("-preset:v:%d %s", v.videoIndex, preset))
+	} else if preset != "" {
 		variantEncoderCommands = append(variantEncoderCommands, fmt.Sprintf("-preset %s", preset))
 	}
 
 	return strings.Join(variantEncoderCommands, " ")
 }
 
+// hasMixedCodecs will return if the variants are not all encoded with the
+// transcoder's video codec.
+func (t *Transcoder) hasMixedCodecs() bool {
+	for _, variant := range t.variants {
+		if variant.encodesVideo() && variant.getCodec(t).Name() != t.codec.Name() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCodecs will return the distinct video codecs used by the transcoder.
+func (t *Transcoder) getCodecs() []Codec {
+	codecs := []Codec{t.codec}
+	names := map[string]bool{t.codec.Name(): true}
+
+	for _, variant := range t.variants {
+		codec := variant.getCodec(t)
+		if !variant.encodesVideo() || names[codec.Name()] {
+			continue
+		}
+
+		codecs = append(codecs, codec)
+		names[codec.Name()] = true
+	}
+
+	return codecs
+}
+
+// getGlobalFlagsString will return the flags every video codec in use needs
+// before the input.
+func (t *Transcoder) getGlobalFlagsString() string {
+	flags := []string{}
+	added := map[string]bool{}
+
+	for _, codec := range t.getCodecs() {
+		if codec.GlobalFlags() != "" && !added[codec.GlobalFlags()] {
+			flags = append(flags, codec.GlobalFlags())
+			added[codec.GlobalFlags()] = true
+		}
+	}
+
+	return strings.Join(flags, " ")
+}
+
+// getCodecArgumentsString will return the video codec arguments that apply
+// to every variant. When the variants use different codecs they are set
+// for each variant instead.
+func (t *Transcoder) getCodecArgumentsString() string {
+	if t.hasMixedCodecs() || !t.encodesVideo() {
+		return ""
+	}
+
+	return strings.Join([]string{
+		t.codec.ExtraArguments(),
+		"-pix_fmt", t.codec.PixelFormat(),
+	}, " ")
+}
+
+// encodesVideo will return if any of the variants encode video.
+func (t *Transcoder) encodesVideo() bool {
+	for _, variant := range t.variants {
+		if variant.encodesVideo() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requiresFMP4Segments will return if any of the variants use a codec that
+// can only be delivered in fMP4 segments.
+func (t *Transcoder) requiresFMP4Segments() bool {
+	for _, variant := range t.variants {
+		if variant.encodesVideo() && requiresFMP4Segments(variant.getCodec(t)) {
+			return true
+		}
+		if !variant.isAudioPassthrough && variant.getAudioCodec() == models.AudioCodecOpus {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSupportedLatencyLevel will return level, or the lowest latency level
+// when low-latency HLS can not be used with the storage or the variants.
+func (t *Transcoder) getSupportedLatencyLevel(level models.LatencyLevel, externalStorage bool) models.LatencyLevel {
+	if !level.IsLowLatencyHLS() {
+		return level
+	}
+
+	// Low-latency playlists have to be served by us so requests can be held
+	// until the segments they ask for are available.
+	if externalStorage {
+		log.Warnln("Low-latency HLS is not available when using external storage. Using the lowest latency level instead.")
+		return models.GetLowestLatencyLevel()
+	}
+
+	// HEVC, AV1 and Opus are only delivered in fMP4 segments, and low-latency
+	// playlists are built from MPEG-TS.
+	if t.requiresFMP4Segments() {
+		log.Warnln("Low-latency HLS is not available with the selected codecs. Using the lowest latency level instead.")
+		return models.GetLowestLatencyLevel()
+	}
+
+	return level
+}
+
 // Get the command flags for the variants.
 func (t *Transcoder) getVariantsString() string {
 	var variantsCommandFlags = ""
 	var variantsStreamMaps = " -var_stream_map \""
 
+	hasAudioRendition := false
+
 	for _, variant := range t.variants {
 		variantsCommandFlags = variantsCommandFlags + " " + variant.getVariantString(t)
 		singleVariantMap := ""
-		singleVariantMap = fmt.Sprintf("v:%d,a:%d ", variant.index, variant.index)
+		if variant.isAudioOnly {
+			// Variants without video are listed as audio renditions.
+			singleVariantMap = fmt.Sprintf("a:%d,agroup:audio", variant.index)
+			if !hasAudioRendition {
+				singleVariantMap += ",default:yes"
+			}
+			singleVariantMap += " "
+			hasAudioRendition = true
+		} else {
+			singleVariantMap = fmt.Sprintf("v:%d,a:%d ", variant.videoIndex, variant.index)
+		}
 		variantsStreamMaps = variantsStreamMaps + singleVariantMap
 	}
 	variantsCommandFlags = variantsCommandFlags + " " + variantsStreamMaps + "\""
@@ -331,7 +613,7 @@ func (v *HLSVariant) SetVideoBitrate(bitrate int) {
 
 func (v *HLSVariant) getVideoQualityString(t *Transcoder) string {
 	if v.isVideoPassthrough {
-		return fmt.Sprintf("-map v:0 -c:v:%d copy", v.index)
+		return fmt.Sprintf("-map v:0 -c:v:%d copy", v.videoIndex)
 	}
 
 	gop := v.framerate * t.currentLatencyLevel.SecondsPerSegment // force an i-frame every segment
@@ -342,21 +624,62 @@ func (v *HLSVariant) getVideoQualityString(t *Transcoder) string {
 	// Adjust the max & buffer size until the output bitrate doesn't exceed the ~+10% that Apple's media validator
 	// complains about.
 	maxBitrate := int(float64(v.videoBitrate) * 1.06) // Max is a ~+10% over specified bitrate.
+	codec := v.getCodec(t)
 
 	cmd := []string{
 		"-map v:0",
-		fmt.Sprintf("-c:v:%d %s", v.index, t.codec.Name()),    // Video codec used for this variant
-		fmt.Sprintf("-b:v:%d %dk", v.index, v.videoBitrate),   // The average bitrate for this variant
-		fmt.Sprintf("-maxrate:v:%d %dk", v.index, maxBitrate), // The max bitrate allowed for this variant
-		fmt.Sprintf("-g:v:%d %d", v.index, gop),               // Suggested interval where i-frames are encoded into the segments
-		fmt.Sprintf("-keyint_min:v:%d %d", v.index, gop),      // minimum i-keyframe interval
-		fmt.Sprintf("-r:v:%d %d", v.index, v.framerate),
-		t.codec.VariantFlags(v),
+		fmt.Sprintf("-c:v:%d %s", v.videoIndex, codec.Name()),      // Video codec used for this variant
+		fmt.Sprintf("-b:v:%d %dk", v.videoIndex, v.videoBitrate),   // The average bitrate for this variant
+		fmt.Sprintf("-maxrate:v:%d %dk", v.videoIndex, maxBitrate), // The max bitrate allowed for this variant
+		fmt.Sprintf("-g:v:%d %d", v.videoIndex, gop),               // Suggested interval where i-frames are encoded into the segments
+		fmt.Sprintf("-keyint_min:v:%d %d", v.videoIndex, gop),      // minimum i-keyframe interval
+		fmt.Sprintf("-r:v:%d %d", v.videoIndex, v.framerate),
+		codec.VariantFlags(v),
+	}
+
+	// Codec arguments only apply to this variant's video when the variants
+	// use different codecs.
+	if t.hasMixedCodecs() {
+		cmd = append(cmd,
+			getStreamArguments(codec.ExtraArguments(), v.videoIndex),
+			fmt.Sprintf("-pix_fmt:v:%d %s", v.videoIndex, codec.PixelFormat()),
+		)
 	}
 
 	return strings.Join(cmd, " ")
 }
 
+// SetCodec will set the video codec of this variant, in place of the
+// transcoder's codec.
+func (v *HLSVariant) SetCodec(codecName string) {
+	v.codec = getCodec(codecName)
+}
+
+// encodesVideo will return if this variant's video is encoded by the transcoder.
+func (v *HLSVariant) encodesVideo() bool {
+	return !v.isVideoPassthrough && !v.isAudioOnly
+}
+
+func (v *HLSVariant) getCodec(t *Transcoder) Codec {
+	if v.codec != nil {
+		return v.codec
+	}
+
+	return t.codec
+}
+
+// getStreamArguments will limit codec arguments to the video stream of a variant.
+func getStreamArguments(arguments string, index int) string {
+	fields := strings.Fields(arguments)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "-") {
+			fields[i] = fmt.Sprintf("%s:v:%d", field, index)
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
 // SetVideoFramerate will set the output framerate of this variant's video.
 func (v *HLSVariant) SetVideoFramerate(framerate int) {
 	v.framerate = framerate
@@ -379,20 +702,77 @@ func (v *HLSVariant) getAudioQualityString() string {
 		return fmt.Sprintf("-map a:0? -c:a:%d copy", v.index)
 	}
 
-	// libfdk_aac is not a part of every ffmpeg install, so use "aac" instead
-	encoderCodec := "aac"
-	return fmt.Sprintf("-map a:0? -c:a:%d %s -b:a:%d %s", v.index, encoderCodec, v.index, v.audioBitrate)
+	return fmt.Sprintf("-map a:0? -c:a:%d %s -b:a:%d %s", v.index, v.getAudioCodec(), v.index, v.audioBitrate)
+}
+
+// SetAudioCodec will set the audio codec of this variant.
+func (v *HLSVariant) SetAudioCodec(codec string) {
+	v.audioCodec = codec
+}
+
+func (v *HLSVariant) getAudioCodec() string {
+	// libfdk_aac is not a part of every ffmpeg install, so use "aac" by default
+	if v.audioCodec == "" {
+		return models.AudioCodecAAC
+	}
+
+	return v.audioCodec
 }
 
 // AddVariant adds a new HLS variant to include in the output.
 func (t *Transcoder) AddVariant(variant HLSVariant) {
 	variant.index = len(t.variants)
+
+	// Variants without video don't have an output video stream.
+	variant.videoIndex = 0
+	for _, v := range t.variants {
+		if !v.isAudioOnly {
+			variant.videoIndex++
+		}
+	}
+
 	t.variants = append(t.variants, variant)
 }
 
 // SetInput sets the input stream on the filesystem.
 func (t *Transcoder) SetInput(input string) {
 	t.input = input
+
+	// Files on disk are the offline content, which is not live.
+	t.isOfflineContent = true
+
+	// A transcoder worker can't read files on this server.
+	if t.backend != nil {
+		t.backend = nil
+		t.ffmpegPath = utils.ValidatedFfmpegPath(data.GetFfMpegPath())
+	}
+}
+
+// SetPremiereInput sets the input to be a video file on the filesystem that
+// is aired as a live stream, read at its native frame rate.
+func (t *Transcoder) SetPremiereInput(filePath string) {
+	t.input = quoteShellArgument(filePath)
+	t.inputFlags = "-re"
+
+	// A transcoder worker can't read files on this server.
+	if t.backend != nil {
+		t.backend = nil
+		t.ffmpegPath = utils.ValidatedFfmpegPath(data.GetFfMpegPath())
+	}
+}
+
+// SetPullInput sets the input to be a remote source ffmpeg connects to.
+func (t *Transcoder) SetPullInput(sourceURL string) {
+	t.input = quoteShellArgument(sourceURL)
+	t.inputFlags = getInputFlagsForURL(sourceURL)
+}
+
+func (t *Transcoder) getInputFlagsString() string {
+	if t.inputFlags == "" {
+		return ""
+	}
+
+	return " " + t.inputFlags
 }
 
 // SetStdin sets the Stdin of the ffmpeg command.
@@ -0,0 +1,173 @@
+package transcoder
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/teris-io/shortid"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/webhooks"
+	"github.com/owncast/owncast/models"
+)
+
+// Errors the transcoder reports during a stream are saved with the stream's
+// session, and sent to webhooks the first time they are reported.
+var (
+	_transcoderErrorSession *transcoderErrorSession
+	_transcoderErrorsLock   sync.Mutex
+)
+
+const (
+	// The code of an error that is not one of the known errors.
+	unknownTranscoderErrorCode = "TRANSCODER_ERROR"
+
+	// The number of different errors saved for a session, so a flood of
+	// messages can not fill the database.
+	maxTranscoderErrorsPerSession = 100
+
+	// How long the errors of past sessions are kept.
+	transcoderErrorRetention = 30 * 24 * time.Hour
+
+	// How much of a message is saved.
+	maxTranscoderErrorMessageLength = 500
+)
+
+type transcoderErrorType struct {
+	match        string
+	code         string
+	severity     string
+	suggestedFix string
+}
+
+// The errors the transcoder is known to report, checked in order so the
+// specific errors are matched before the generic ones.
+var transcoderErrorTypes = []transcoderErrorType{
+	{"Unrecognized option 'vaapi_device'", "VAAPI_UNSUPPORTED", models.TranscoderErrorSeverityCritical, "you are likely trying to utilize a vaapi codec, but your version of ffmpeg or your hardware doesn't support it. change your codec to libx264 and restart your stream"},
+	{"unable to open display", "FFMPEG_SNAP_INSTALL", models.TranscoderErrorSeverityCritical, "your copy of ffmpeg is likely installed via snap packages. please uninstall and re-install via a non-snap method.  https://owncast.online/docs/troubleshooting/#misc-video-issues"},
+	{"Failed to open file 'http://127.0.0.1", "FFMPEG_INCOMPATIBLE", models.TranscoderErrorSeverityCritical, "error transcoding. make sure your version of ffmpeg is compatible with your selected codec or is recent enough https://owncast.online/docs/troubleshooting/#codecs"},
+	{"can't configure encoder", "ENCODER_CONFIGURATION_FAILED", models.TranscoderErrorSeverityCritical, "error with codec. if your copy of ffmpeg or your hardware does not support your selected codec you may need to select another"},
+	{"OpenEncodeSessionEx failed: out of memory", "NVENC_SESSION_LIMIT", models.TranscoderErrorSeverityCritical, "your NVIDIA gpu is limiting the number of concurrent stream qualities you can support. remove a stream output variant and try again."},
+	{"No VA display found for device", "VAAPI_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "vaapi not enabled. either your copy of ffmpeg does not support it, your hardware does not support it, or you need to install additional drivers for your hardware."},
+	{"Could not find a valid device", "HARDWARE_DEVICE_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "your codec is either not supported or not configured properly"},
+	{"H.264 bitstream error", "INPUT_BITSTREAM_ERROR", models.TranscoderErrorSeverityWarning, "transcoding content error playback issues may arise. you may want to use the default codec if you are not already."},
+	{"intel_enc_hw_context_init: Assertion 'encoder_context->mfc_context' failed", "INTEL_DRIVER_MISSING", models.TranscoderErrorSeverityCritical, "if you are using Intel graphics you may be missing the i965-va-driver-shader drivers"},
+	{"Unknown encoder 'h264_qsv'", "ENCODER_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "your copy of ffmpeg does not have support for Intel QuickSync encoding (h264_qsv). change the selected codec in your video settings"},
+	{"Unknown encoder 'h264_vaapi'", "ENCODER_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "your copy of ffmpeg does not have support for VA-API encoding (h264_vaapi). change the selected codec in your video settings"},
+	{"Unknown encoder 'h264_nvenc'", "ENCODER_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "your copy of ffmpeg does not have support for NVIDIA hardware encoding (h264_nvenc). change the selected codec in your video settings"},
+	{"Unknown encoder 'h264_x264'", "ENCODER_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "your copy of ffmpeg does not have support for the default x264 codec (h264_x264). download a version of ffmpeg that supports this."},
+	{"Unrecognized option 'x264-params", "ENCODER_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "your copy of ffmpeg does not have support for the default libx264 codec (h264_x264). download a version of ffmpeg that supports this."},
+	{"Failed to set value '/dev/dri/renderD128' for option 'vaapi_device': Invalid argument", "VAAPI_DEVICE_UNAVAILABLE", models.TranscoderErrorSeverityCritical, "failed to set va-api device to /dev/dri/renderD128. your system is likely not properly configured for va-api"},
+
+	// Generic error for a codec
+	{"Unrecognized option", "CODEC_OPTION_UNSUPPORTED", models.TranscoderErrorSeverityCritical, "error with codec. if your copy of ffmpeg or your hardware does not support your selected codec you may need to select another"},
+}
+
+type transcoderErrorSession struct {
+	id     string
+	errors map[string]*models.TranscoderError // By the code and message
+}
+
+// startTranscoderErrorSession will start saving the errors of a new stream,
+// if it is live.
+func startTranscoderErrorSession(live bool) {
+	_transcoderErrorsLock.Lock()
+	defer _transcoderErrorsLock.Unlock()
+
+	_transcoderErrorSession = nil
+	if !live {
+		return
+	}
+
+	_transcoderErrorSession = &transcoderErrorSession{
+		id:     shortid.MustGenerate(),
+		errors: make(map[string]*models.TranscoderError),
+	}
+
+	if err := data.DeleteTranscoderErrorsBefore(time.Now().Add(-transcoderErrorRetention)); err != nil {
+		log.Warnln(err)
+	}
+}
+
+// classifyTranscoderMessage will return the error a message from the
+// transcoder reports.
+func classifyTranscoderMessage(message string) models.TranscoderError {
+	if len(message) > maxTranscoderErrorMessageLength {
+		message = message[:maxTranscoderErrorMessageLength]
+	}
+
+	transcoderError := models.TranscoderError{
+		Code:     unknownTranscoderErrorCode,
+		Severity: models.TranscoderErrorSeverityError,
+		Message:  message,
+		Count:    1,
+	}
+
+	for _, errorType := range transcoderErrorTypes {
+		if strings.Contains(message, errorType.match) {
+			transcoderError.Code = errorType.code
+			transcoderError.Severity = errorType.severity
+			transcoderError.SuggestedFix = errorType.suggestedFix
+			break
+		}
+	}
+
+	return transcoderError
+}
+
+// reportTranscoderError will save an error for the current session. An
+// error already reported in the session is counted instead.
+func reportTranscoderError(transcoderError models.TranscoderError) {
+	_transcoderErrorsLock.Lock()
+	defer _transcoderErrorsLock.Unlock()
+
+	session := _transcoderErrorSession
+	if session == nil {
+		return
+	}
+
+	now := time.Now()
+	key := transcoderError.Code + "\n" + transcoderError.Message
+
+	if existing, ok := session.errors[key]; ok {
+		existing.Count++
+		existing.LastSeen = now
+		if err := data.SetTranscoderErrorRepeated(existing.ID, existing.Count, existing.LastSeen); err != nil {
+			log.Debugln(err)
+		}
+		return
+	}
+
+	if len(session.errors) >= maxTranscoderErrorsPerSession {
+		return
+	}
+
+	transcoderError.Session = session.id
+	transcoderError.FirstSeen = now
+	transcoderError.LastSeen = now
+
+	id, err := data.InsertTranscoderError(transcoderError)
+	if err != nil {
+		log.Debugln(err)
+		return
+	}
+	transcoderError.ID = id
+	session.errors[key] = &transcoderError
+
+	go webhooks.SendTranscoderErrorEvent(transcoderError)
+}
+
+// GetTranscoderErrorSession will return the session the errors of the
+// current stream are saved with, or nothing if there is no stream.
+func GetTranscoderErrorSession() string {
+	_transcoderErrorsLock.Lock()
+	defer _transcoderErrorsLock.Unlock()
+
+	if _transcoderErrorSession == nil {
+		return ""
+	}
+
+	return _transcoderErrorSession.id
+}
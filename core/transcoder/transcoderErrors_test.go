@@ -0,0 +1,32 @@
+package transcoder
+
+import (
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestClassifyTranscoderMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		code     string
+		severity string
+	}{
+		{"[h264_nvenc @ 0x55d] Unknown encoder 'h264_nvenc'", "ENCODER_UNAVAILABLE", models.TranscoderErrorSeverityCritical},
+		{"Unrecognized option 'vaapi_device'.", "VAAPI_UNSUPPORTED", models.TranscoderErrorSeverityCritical},
+		{"Unrecognized option 'foo'.", "CODEC_OPTION_UNSUPPORTED", models.TranscoderErrorSeverityCritical},
+		{"[h264 @ 0x55d] H.264 bitstream error", "INPUT_BITSTREAM_ERROR", models.TranscoderErrorSeverityWarning},
+		{"Something unexpected", unknownTranscoderErrorCode, models.TranscoderErrorSeverityError},
+	}
+
+	for _, test := range tests {
+		transcoderError := classifyTranscoderMessage(test.message)
+		if transcoderError.Code != test.code || transcoderError.Severity != test.severity {
+			t.Errorf("expected %q to be %s %s, got %s %s", test.message, test.severity, test.code, transcoderError.Severity, transcoderError.Code)
+		}
+
+		if transcoderError.Message != test.message || transcoderError.Count != 1 {
+			t.Errorf("expected %q to be reported once, got %+v", test.message, transcoderError)
+		}
+	}
+}
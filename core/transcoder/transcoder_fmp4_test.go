@@ -0,0 +1,42 @@
+package transcoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestFFmpegFMP4Command(t *testing.T) {
+	codec := Libx264Codec{}
+
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetInput("fakecontent.flv")
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec(codec.Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+	transcoder.segmentFormat = models.SegmentFormatFMP4
+
+	variant := HLSVariant{}
+	variant.isAudioPassthrough = true
+	variant.isVideoPassthrough = true
+	transcoder.AddVariant(variant)
+
+	cmd := transcoder.getString()
+
+	expected := "-hls_time 3 -hls_list_size 3  -hls_segment_type fmp4 -hls_fmp4_init_filename stream-jdofFGg-init.mp4"
+	if !strings.Contains(cmd, expected) {
+		t.Errorf("ffmpeg command does not contain %s.\nGot %s", expected, cmd)
+	}
+
+	expected = "-hls_segment_filename http://127.0.0.1:8123/%v/stream-jdofFGg%s.m4s"
+	if !strings.Contains(cmd, expected) {
+		t.Errorf("ffmpeg command does not contain %s.\nGot %s", expected, cmd)
+	}
+
+	if strings.Contains(cmd, "mpegts_flags") {
+		t.Errorf("fMP4 segments should not be given MPEG-TS options.\nGot %s", cmd)
+	}
+}
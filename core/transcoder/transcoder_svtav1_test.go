@@ -0,0 +1,69 @@
+package transcoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestFFmpegsvtav1Command(t *testing.T) {
+	codec := SvtAv1Codec{}
+
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetInput("fakecontent.flv")
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec(codec.Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+	transcoder.segmentFormat = models.SegmentFormatFMP4
+
+	variant := HLSVariant{}
+	variant.videoBitrate = 1200
+	variant.isAudioPassthrough = true
+	variant.SetVideoFramerate(30)
+	variant.SetCPUUsageLevel(2)
+	transcoder.AddVariant(variant)
+
+	cmd := transcoder.getString()
+
+	for _, flag := range []string{
+		"-c:v:0 libsvtav1",
+		`-svtav1-params:v:0 "scd=0"`,
+		"-preset 10",
+		"-hls_segment_type fmp4",
+	} {
+		if !strings.Contains(cmd, flag) {
+			t.Errorf("expected the ffmpeg command to contain %s, got %s", flag, cmd)
+		}
+	}
+
+	// The x264 and x265 tuning options are not understood by SVT-AV1.
+	if strings.Contains(cmd, "-tune") || strings.Contains(cmd, "-profile:v:0") {
+		t.Errorf("expected no x264 or x265 options in the ffmpeg command, got %s", cmd)
+	}
+}
+
+func TestAV1CodecTag(t *testing.T) {
+	tests := []struct {
+		width     int
+		height    int
+		framerate int
+		bitrate   int
+		expected  string
+	}{
+		{1280, 720, 30, 1200, "av01.0.05M.08"},
+		{0, 720, 30, 1200, "av01.0.05M.08"},
+		{1920, 1080, 30, 3500, "av01.0.08M.08"},
+		{1920, 1080, 60, 3500, "av01.0.09M.08"},
+		{3840, 2160, 30, 15000, "av01.0.12M.08"},
+	}
+
+	for _, test := range tests {
+		variant := HLSVariant{videoSize: VideoSize{Width: test.width, Height: test.height}, framerate: test.framerate, videoBitrate: test.bitrate}
+		if tag := getCodecTag(&SvtAv1Codec{}, &variant); tag != test.expected {
+			t.Errorf("expected %dx%d at %dfps and %dk to be %s, got %s", test.width, test.height, test.framerate, test.bitrate, test.expected, tag)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package transcoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestFFmpegMixedCodecsCommand(t *testing.T) {
+	codec := Libx264Codec{}
+
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetInput("fakecontent.flv")
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec(codec.Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+
+	variant := HLSVariant{}
+	variant.videoBitrate = 1200
+	variant.isAudioPassthrough = true
+	variant.SetVideoFramerate(30)
+	variant.SetCPUUsageLevel(2)
+	transcoder.AddVariant(variant)
+
+	variant2 := HLSVariant{}
+	variant2.videoBitrate = 3500
+	variant2.SetAudioBitrate("128k")
+	variant2.SetAudioCodec(models.AudioCodecOpus)
+	variant2.SetVideoFramerate(30)
+	variant2.SetCPUUsageLevel(4)
+	variant2.SetCodec((&SvtAv1Codec{}).Name())
+	transcoder.AddVariant(variant2)
+
+	if !transcoder.requiresFMP4Segments() {
+		t.Error("AV1 and Opus variants should require fMP4 segments")
+	}
+
+	cmd := transcoder.getString()
+
+	for _, expected := range []string{
+		"-c:v:0 libx264",
+		"-profile:v:0 high -tune:v:0 zerolatency -pix_fmt:v:0 yuv420p",
+		"-preset:v:0 veryfast",
+		"-c:v:1 libsvtav1",
+		"-bufsize:v:1 4200k  -pix_fmt:v:1 yuv420p",
+		"-c:a:1 libopus -b:a:1 128k",
+		"-preset:v:1 8",
+	} {
+		if !strings.Contains(cmd, expected) {
+			t.Errorf("ffmpeg command does not contain %s.\nGot %s", expected, cmd)
+		}
+	}
+
+	if strings.Contains(cmd, "-preset ") || strings.Contains(cmd, "-tune zerolatency") {
+		t.Errorf("codec arguments should only apply to the variants using the codec.\nGot %s", cmd)
+	}
+}
+
+func TestFFmpegAudioOnlyCommand(t *testing.T) {
+	codec := Libx264Codec{}
+
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetInput("fakecontent.flv")
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec(codec.Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+
+	audio := getVariantFromConfigQuality(models.StreamOutputVariant{IsAudioOnly: true, AudioBitrate: 64}, 0)
+	transcoder.AddVariant(audio)
+
+	video := HLSVariant{}
+	video.videoBitrate = 1200
+	video.isAudioPassthrough = true
+	video.SetVideoFramerate(30)
+	transcoder.AddVariant(video)
+
+	cmd := transcoder.getString()
+
+	for _, expected := range []string{
+		"-map a:0? -c:a:0 aac -b:a:0 64k -map v:0 -c:v:0 libx264",
+		"-map a:0? -c:a:1 copy",
+		`-var_stream_map "a:0,agroup:audio,default:yes v:0,a:1 "`,
+	} {
+		if !strings.Contains(cmd, expected) {
+			t.Errorf("ffmpeg command does not contain %s.\nGot %s", expected, cmd)
+		}
+	}
+
+	radio := new(Transcoder)
+	radio.ffmpegPath = "/fake/path/ffmpeg"
+	radio.SetInput("fakecontent.flv")
+	radio.SetCodec(codec.Name())
+	radio.currentLatencyLevel = models.GetLatencyLevel(2)
+	for index, quality := range getAudioOnlyOutputSettings([]models.StreamOutputVariant{{VideoBitrate: 1200, AudioBitrate: 128}}) {
+		radio.AddVariant(getVariantFromConfigQuality(quality, index))
+	}
+
+	cmd = radio.getString()
+	if strings.Contains(cmd, "-map v:0") || strings.Contains(cmd, "-pix_fmt") {
+		t.Errorf("radio mode should not have any video.\nGot %s", cmd)
+	}
+}
@@ -0,0 +1,104 @@
+package transcoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owncast/owncast/models"
+)
+
+func TestFFmpegx265Command(t *testing.T) {
+	codec := Libx265Codec{}
+
+	transcoder := new(Transcoder)
+	transcoder.ffmpegPath = "/fake/path/ffmpeg"
+	transcoder.SetInput("fakecontent.flv")
+	transcoder.SetIdentifier("jdofFGg")
+	transcoder.SetInternalHTTPPort("8123")
+	transcoder.SetCodec(codec.Name())
+	transcoder.currentLatencyLevel = models.GetLatencyLevel(2)
+	transcoder.segmentFormat = models.SegmentFormatFMP4
+
+	variant := HLSVariant{}
+	variant.videoBitrate = 1200
+	variant.isAudioPassthrough = true
+	variant.SetVideoFramerate(30)
+	variant.SetCPUUsageLevel(2)
+	transcoder.AddVariant(variant)
+
+	cmd := transcoder.getString()
+
+	for _, flag := range []string{
+		"-c:v:0 libx265",
+		`-x265-params:v:0 "scenecut=0:open-gop=0:log-level=warning"`,
+		"-profile:v:0 main",
+		"-tag:v:0 hvc1",
+		"-preset veryfast",
+		"-tune zerolatency",
+		"-hls_segment_type fmp4",
+	} {
+		if !strings.Contains(cmd, flag) {
+			t.Errorf("expected the ffmpeg command to contain %s, got %s", flag, cmd)
+		}
+	}
+}
+
+func TestHEVCCodecTag(t *testing.T) {
+	tests := []struct {
+		width     int
+		height    int
+		framerate int
+		bitrate   int
+		expected  string
+	}{
+		{640, 360, 30, 800, "hvc1.1.6.L63.90"},
+		{1280, 720, 30, 1200, "hvc1.1.6.L93.90"},
+		{1280, 0, 30, 1200, "hvc1.1.6.L93.90"},
+		{1920, 1080, 30, 3500, "hvc1.1.6.L120.90"},
+		{0, 0, 30, 3500, "hvc1.1.6.L120.90"},
+		{1920, 1080, 60, 3500, "hvc1.1.6.L123.90"},
+		{1920, 1080, 30, 15000, "hvc1.1.6.L123.90"},
+		{3840, 2160, 30, 15000, "hvc1.1.6.L150.90"},
+	}
+
+	for _, test := range tests {
+		variant := HLSVariant{videoSize: VideoSize{Width: test.width, Height: test.height}, framerate: test.framerate, videoBitrate: test.bitrate}
+		if tag := getCodecTag(&Libx265Codec{}, &variant); tag != test.expected {
+			t.Errorf("expected %dx%d at %dfps and %dk to be %s, got %s", test.width, test.height, test.framerate, test.bitrate, test.expected, tag)
+		}
+	}
+}
+
+func TestMasterPlaylistCodecs(t *testing.T) {
+	playlist := "#EXTM3U\n#EXT-X-VERSION:7\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720\n0/stream.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=3800000,RESOLUTION=1920x1080,CODECS=\"mp4a.40.2\"\n1/stream.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=5000000,CODECS=\"avc1.64001f,mp4a.40.2\"\n2/stream.m3u8\n"
+
+	codec := Libx265Codec{}
+	hd := HLSVariant{videoSize: VideoSize{Width: 1280, Height: 720}, framerate: 30, videoBitrate: 1200}
+	fullHD := HLSVariant{videoSize: VideoSize{Width: 1920, Height: 1080}, framerate: 30, videoBitrate: 3500}
+	codecs := []variantCodecs{
+		{video: getCodecTag(&codec, &hd), audio: "mp4a.40.2"},
+		{video: getCodecTag(&codec, &fullHD), audio: "mp4a.40.2"},
+		{video: "avc1.640028", audio: "mp4a.40.2"},
+	}
+
+	updated, changed := getMasterPlaylistWithCodecs(playlist, codecs)
+	if !changed {
+		t.Fatal("master playlist was not changed")
+	}
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:7\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1400000,RESOLUTION=1280x720,CODECS=\"hvc1.1.6.L93.90,mp4a.40.2\"\n0/stream.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=3800000,RESOLUTION=1920x1080,CODECS=\"hvc1.1.6.L120.90,mp4a.40.2\"\n1/stream.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=5000000,CODECS=\"avc1.64001f,mp4a.40.2\"\n2/stream.m3u8\n"
+
+	if updated != expected {
+		t.Errorf("master playlist does not match expected.\nGot %s\n, want: %s", updated, expected)
+	}
+
+	if _, changed := getMasterPlaylistWithCodecs(expected, codecs); changed {
+		t.Error("master playlist that has its codecs should not be changed")
+	}
+}
@@ -0,0 +1,353 @@
+package transcoder
+
+import "github.com/owncast/owncast/core/mpegts"
+
+// MPEG-TS stream types of the video codecs the transcoder can write.
+var videoStreamTypes = map[byte]bool{
+	0x02: true, // MPEG-2
+	0x10: true, // MPEG-4
+	0x1B: true, // H.264
+	0x24: true, // H.265
+}
+
+// startsWithKeyframe will return if the first video frame of a transport
+// stream is a keyframe, so it can be decoded without what came before it.
+// A stream without video can always be decoded on its own.
+func startsWithKeyframe(b []byte) bool {
+	pmtPID := -1
+	videoPID := -1
+
+	for ; len(b) >= mpegts.PacketSize; b = b[mpegts.PacketSize:] {
+		pkt := b[:mpegts.PacketSize]
+		if pkt[0] != mpegts.SyncByte {
+			return false
+		}
+
+		// Only packets that start a table or a frame are of interest.
+		if !mpegts.IsUnitStart(pkt) {
+			continue
+		}
+
+		pid := mpegts.GetPID(pkt)
+		switch {
+		case pid == videoPID:
+			// The random access indicator is set on packets starting a keyframe.
+			hasAdaptationField := pkt[3]&0x20 != 0
+			return hasAdaptationField && pkt[4] > 0 && pkt[5]&0x40 != 0
+		case pid == 0 && pmtPID < 0:
+			if section := mpegts.GetSection(pkt); section != nil {
+				pmtPID = mpegts.GetPMTPID(section)
+			}
+		case pid == pmtPID && videoPID < 0:
+			if section := mpegts.GetSection(pkt); section != nil {
+				var isProgramMap bool
+				videoPID, isProgramMap = getVideoPID(section)
+				if isProgramMap && videoPID < 0 {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// getVideoPID will return the pid of the first video stream in a program map
+// table, and false if the section is not a program map table.
+func getVideoPID(section []byte) (int, bool) {
+	streams, ok := mpegts.GetElementaryStreams(section)
+	if !ok {
+		return -1, false
+	}
+
+	for _, stream := range streams {
+		if videoStreamTypes[stream.StreamType] {
+			return stream.PID, true
+		}
+	}
+
+	return -1, true
+}
+
+const (
+	// The stream type and PES stream id timed ID3 metadata is carried with.
+	tsMetadataStreamType = 0x15
+	tsMetadataStreamID   = 0xBD
+
+	// The descriptors that identify the timed metadata stream as ID3, as
+	// described in Apple's Timed Metadata for HTTP Live Streaming.
+	tsMetadataPointerDescriptorTag = 0x25
+	tsMetadataDescriptorTag        = 0x26
+
+	tsClockRate = 90000
+)
+
+// The format of ID3 timed metadata, followed by its service id.
+var id3MetadataFormat = []byte{0xFF, 0xFF, 'I', 'D', '3', ' ', 0xFF, 'I', 'D', '3', ' ', 0x00}
+
+// tsMetadata is an ID3 tag to be shown at a presentation time, in 90kHz units.
+type tsMetadata struct {
+	pts uint64
+	tag []byte
+}
+
+// getTSStartTime will return the earliest presentation time, in 90kHz
+// units, of the frames in a transport stream.
+func getTSStartTime(b []byte) (uint64, bool) {
+	var start uint64
+	found := false
+
+	for ; len(b) >= mpegts.PacketSize; b = b[mpegts.PacketSize:] {
+		pkt := b[:mpegts.PacketSize]
+		if pkt[0] != mpegts.SyncByte {
+			break
+		}
+
+		// Only packets that start a frame have its presentation time.
+		if !mpegts.IsUnitStart(pkt) {
+			continue
+		}
+
+		if pts, ok := getPESPresentationTime(mpegts.GetPayload(pkt)); ok && (!found || pts < start) {
+			start = pts
+			found = true
+		}
+	}
+
+	return start, found
+}
+
+func getPESPresentationTime(pes []byte) (uint64, bool) {
+	if len(pes) < 14 || pes[0] != 0x00 || pes[1] != 0x00 || pes[2] != 0x01 || pes[7]&0x80 == 0 {
+		return 0, false
+	}
+
+	pts := uint64(pes[9]>>1&0x07)<<30 |
+		uint64(pes[10])<<22 |
+		uint64(pes[11]>>1)<<15 |
+		uint64(pes[12])<<7 |
+		uint64(pes[13]>>1)
+
+	return pts, true
+}
+
+// insertTSMetadata will add a timed ID3 metadata stream to the program of a
+// transport stream, carrying the tags after its first program map table.
+func insertTSMetadata(b []byte, metadata []tsMetadata) ([]byte, bool) {
+	pmtPID := -1
+	var pmt []byte
+	var metadataPID int
+
+	for i := 0; i+mpegts.PacketSize <= len(b) && pmt == nil; i += mpegts.PacketSize {
+		pkt := b[i : i+mpegts.PacketSize]
+		if pkt[0] != mpegts.SyncByte {
+			return b, false
+		}
+
+		pid := mpegts.GetPID(pkt)
+		if !mpegts.IsUnitStart(pkt) {
+			continue
+		}
+
+		switch {
+		case pid == 0 && pmtPID < 0:
+			if section := mpegts.GetSection(pkt); section != nil {
+				pmtPID = mpegts.GetPMTPID(section)
+			}
+		case pid == pmtPID:
+			var ok bool
+			if pmt, metadataPID, ok = getPMTWithMetadata(pkt); !ok {
+				return b, false
+			}
+		}
+	}
+
+	if pmt == nil {
+		return b, false
+	}
+
+	var packets []byte
+	continuityCounter := 0
+	for _, item := range metadata {
+		pes, count := getTSPackets(metadataPID, getID3PES(item.pts, item.tag), continuityCounter)
+		packets = append(packets, pes...)
+		continuityCounter += count
+	}
+
+	result := make([]byte, 0, len(b)+len(packets))
+	inserted := false
+	for ; len(b) >= mpegts.PacketSize; b = b[mpegts.PacketSize:] {
+		pkt := b[:mpegts.PacketSize]
+		if mpegts.GetPID(pkt) != pmtPID {
+			result = append(result, pkt...)
+			continue
+		}
+
+		// Each copy of the table keeps its own continuity counter.
+		updated := append([]byte{}, pmt...)
+		updated[3] = updated[3]&0xF0 | pkt[3]&0x0F
+		result = append(result, updated...)
+
+		if !inserted {
+			result = append(result, packets...)
+			inserted = true
+		}
+	}
+
+	return append(result, b...), true
+}
+
+// getPMTWithMetadata will return a packet carrying the program map table
+// with a timed metadata stream added, and the pid of the stream.
+func getPMTWithMetadata(pkt []byte) ([]byte, int, bool) {
+	payload := mpegts.GetPayload(pkt)
+	if payload == nil || payload[0] != 0 {
+		return nil, 0, false
+	}
+
+	section := payload[1:]
+	if len(section) < 16 || section[0] != 0x02 {
+		return nil, 0, false
+	}
+
+	// The whole table has to be in the packet to be rewritten.
+	sectionLength := 3 + (int(section[1]&0x0F)<<8 | int(section[2]))
+	if sectionLength > len(section) {
+		return nil, 0, false
+	}
+
+	end := sectionLength - 4
+	programInfoLength := int(section[10]&0x0F)<<8 | int(section[11])
+	streamsStart := 12 + programInfoLength
+	if streamsStart > end {
+		return nil, 0, false
+	}
+
+	// The metadata stream follows the highest pid in the program.
+	metadataPID := int(section[8]&0x1F)<<8 | int(section[9])
+	for i := streamsStart; i+5 <= end; {
+		if section[i] == tsMetadataStreamType {
+			return nil, 0, false
+		}
+
+		if pid := int(section[i+1]&0x1F)<<8 | int(section[i+2]); pid > metadataPID {
+			metadataPID = pid
+		}
+
+		esInfoLength := int(section[i+3]&0x0F)<<8 | int(section[i+4])
+		i += 5 + esInfoLength
+	}
+	metadataPID++
+
+	programNumber := section[3:5]
+	pointerDescriptor := append([]byte{tsMetadataPointerDescriptorTag, byte(len(id3MetadataFormat) + 3)}, id3MetadataFormat...)
+	pointerDescriptor = append(pointerDescriptor, 0x1F, programNumber[0], programNumber[1])
+	metadataDescriptor := append([]byte{tsMetadataDescriptorTag, byte(len(id3MetadataFormat) + 1)}, id3MetadataFormat...)
+	metadataDescriptor = append(metadataDescriptor, 0x0F)
+
+	updated := append([]byte{}, section[:10]...)
+	programInfoLength += len(pointerDescriptor)
+	updated = append(updated, 0xF0|byte(programInfoLength>>8), byte(programInfoLength))
+	updated = append(updated, section[12:streamsStart]...)
+	updated = append(updated, pointerDescriptor...)
+	updated = append(updated, section[streamsStart:end]...)
+	updated = append(updated, tsMetadataStreamType, 0xE0|byte(metadataPID>>8), byte(metadataPID), 0xF0, byte(len(metadataDescriptor)))
+	updated = append(updated, metadataDescriptor...)
+
+	length := len(updated) - 3 + 4
+	updated[1] = updated[1]&0xF0 | byte(length>>8)&0x0F
+	updated[2] = byte(length)
+	crc := getMPEGCRC32(updated)
+	updated = append(updated, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+
+	if 4+1+len(updated) > mpegts.PacketSize {
+		return nil, 0, false
+	}
+
+	result := []byte{mpegts.SyncByte, pkt[1], pkt[2], 0x10 | pkt[3]&0x0F, 0x00}
+	result = append(result, updated...)
+	for len(result) < mpegts.PacketSize {
+		result = append(result, 0xFF)
+	}
+
+	return result, metadataPID, true
+}
+
+// getID3PES will return a PES packet carrying an ID3 tag shown at pts.
+func getID3PES(pts uint64, tag []byte) []byte {
+	length := 3 + 5 + len(tag)
+	pts &= 0x1FFFFFFFF
+
+	pes := []byte{
+		0x00, 0x00, 0x01, tsMetadataStreamID,
+		byte(length >> 8), byte(length),
+		0x84, // The tag starts with the packet
+		0x80, // Only a presentation time
+		0x05,
+		0x20 | byte(pts>>29)&0x0E | 0x01,
+		byte(pts >> 22),
+		byte(pts>>14)&0xFE | 0x01,
+		byte(pts >> 7),
+		byte(pts<<1)&0xFE | 0x01,
+	}
+
+	return append(pes, tag...)
+}
+
+// getTSPackets will split a PES packet into transport stream packets,
+// returning them and the number of them.
+func getTSPackets(pid int, pes []byte, continuityCounter int) ([]byte, int) {
+	var result []byte
+	count := 0
+	first := true
+
+	for len(pes) > 0 {
+		pkt := []byte{mpegts.SyncByte, byte(pid>>8) & 0x1F, byte(pid), 0}
+		if first {
+			pkt[1] |= 0x40
+			first = false
+		}
+
+		size := len(pes)
+		if size >= mpegts.PacketSize-4 {
+			size = mpegts.PacketSize - 4
+			pkt[3] = 0x10 | byte(continuityCounter)&0x0F
+		} else {
+			// The last packet is padded with the adaptation field.
+			pkt[3] = 0x30 | byte(continuityCounter)&0x0F
+			adaptationLength := mpegts.PacketSize - 4 - size - 1
+			pkt = append(pkt, byte(adaptationLength))
+			if adaptationLength > 0 {
+				pkt = append(pkt, 0x00)
+				for i := 1; i < adaptationLength; i++ {
+					pkt = append(pkt, 0xFF)
+				}
+			}
+		}
+
+		result = append(result, pkt...)
+		result = append(result, pes[:size]...)
+		pes = pes[size:]
+		continuityCounter++
+		count++
+	}
+
+	return result, count
+}
+
+// getMPEGCRC32 will return the CRC used by transport stream tables.
+func getMPEGCRC32(b []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+	for _, v := range b {
+		crc ^= uint32(v) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
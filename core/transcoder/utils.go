@@ -16,29 +16,6 @@ import (
 var _lastTranscoderLogMessage = ""
 var l = &sync.RWMutex{}
 
-var errorMap = map[string]string{
-	"Unrecognized option 'vaapi_device'":        "you are likely trying to utilize a vaapi codec, but your version of ffmpeg or your hardware doesn't support it. change your codec to libx264 and restart your stream",
-	"unable to open display":                    "your copy of ffmpeg is likely installed via snap packages. please uninstall and re-install via a non-snap method.  https://owncast.online/docs/troubleshooting/#misc-video-issues",
-	"Failed to open file 'http://127.0.0.1":     "error transcoding. make sure your version of ffmpeg is compatible with your selected codec or is recent enough https://owncast.online/docs/troubleshooting/#codecs",
-	"can't configure encoder":                   "error with codec. if your copy of ffmpeg or your hardware does not support your selected codec you may need to select another",
-	"Unable to parse option value":              "you are likely trying to utilize a specific codec, but your version of ffmpeg or your hardware doesn't support it. either fix your ffmpeg install or try changing your codec to libx264 and restart your stream",
-	"OpenEncodeSessionEx failed: out of memory": "your NVIDIA gpu is limiting the number of concurrent stream qualities you can support. remove a stream output variant and try again.",
-	"Cannot use rename on non file protocol, this may lead to races and temporary partial files": "",
-	"No VA display found for device": "vaapi not enabled. either your copy of ffmpeg does not support it, your hardware does not support it, or you need to install additional drivers for your hardware.",
-	"Could not find a valid device":  "your codec is either not supported or not configured properly",
-	"H.264 bitstream error":          "transcoding content error playback issues may arise. you may want to use the default codec if you are not already.",
-	"intel_enc_hw_context_init: Assertion 'encoder_context->mfc_context' failed": "if you are using Intel graphics you may be missing the i965-va-driver-shader drivers",
-	`Unknown encoder 'h264_qsv'`:       "your copy of ffmpeg does not have support for Intel QuickSync encoding (h264_qsv). change the selected codec in your video settings",
-	`Unknown encoder 'h264_vaapi'`:     "your copy of ffmpeg does not have support for VA-API encoding (h264_vaapi). change the selected codec in your video settings",
-	`Unknown encoder 'h264_nvenc'`:     "your copy of ffmpeg does not have support for NVIDIA hardware encoding (h264_nvenc). change the selected codec in your video settings",
-	`Unknown encoder 'h264_x264'`:      "your copy of ffmpeg does not have support for the default x264 codec (h264_x264). download a version of ffmpeg that supports this.",
-	`Unrecognized option 'x264-params`: "your copy of ffmpeg does not have support for the default libx264 codec (h264_x264). download a version of ffmpeg that supports this.",
-	`Failed to set value '/dev/dri/renderD128' for option 'vaapi_device': Invalid argument`: "failed to set va-api device to /dev/dri/renderD128. your system is likely not properly configured for va-api",
-
-	// Generic error for a codec
-	"Unrecognized option": "error with codec. if your copy of ffmpeg or your hardware does not support your selected codec you may need to select another",
-}
-
 var ignoredErrors = []string{
 	"Duplicated segment filename detected",
 	"Error while opening encoder for output stream",
@@ -68,16 +45,11 @@ func handleTranscoderMessage(message string) {
 		}
 	}
 
-	// Convert specific transcoding messages to human-readable messages.
-	for error, displayMessage := range errorMap {
-		if strings.Contains(message, error) {
-			message = displayMessage
-			break
-		}
-	}
-
-	if message == "" {
-		return
+	// Known errors are logged with how to fix them.
+	transcoderError := classifyTranscoderMessage(message)
+	reportTranscoderError(transcoderError)
+	if transcoderError.SuggestedFix != "" {
+		message = transcoderError.SuggestedFix
 	}
 
 	// No good comes from a flood of repeated messages.
@@ -119,3 +91,66 @@ func createVariantDirectories() {
 		}
 	}
 }
+
+// quoteShellArgument will single quote a value so it is passed to the
+// transcoder's shell as a single argument.
+func quoteShellArgument(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// splitShellArguments will split a command into its arguments the way the
+// transcoder's shell would, removing the quotes around them.
+func splitShellArguments(command string) []string {
+	var arguments []string
+	var argument strings.Builder
+	inArgument := false
+	var quote rune
+	escaped := false
+
+	for _, c := range command {
+		switch {
+		case escaped:
+			// Within double quotes only a few characters can be escaped.
+			if quote == '"' && !strings.ContainsRune("$`\"\\", c) {
+				argument.WriteRune('\\')
+			}
+			argument.WriteRune(c)
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				argument.WriteRune(c)
+			}
+		case c == '\\' && quote == '"':
+			escaped = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				argument.WriteRune(c)
+			}
+		case c == '\\':
+			escaped = true
+			inArgument = true
+		case c == '\'' || c == '"':
+			quote = c
+			inArgument = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArgument {
+				arguments = append(arguments, argument.String())
+				argument.Reset()
+				inArgument = false
+			}
+		default:
+			argument.WriteRune(c)
+			inArgument = true
+		}
+	}
+
+	if inArgument {
+		arguments = append(arguments, argument.String())
+	}
+
+	return arguments
+}
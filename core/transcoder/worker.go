@@ -0,0 +1,406 @@
+package transcoder
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/logging"
+	"github.com/owncast/owncast/models"
+)
+
+// RunTranscoderWorker will run ffmpeg for the Owncast servers that connect
+// to the address with the secret, sending them back what it writes. The
+// connection is not encrypted, so the worker must only be reachable over a
+// trusted private network.
+func RunTranscoderWorker(address string, secret string, ffmpegPath string) error {
+	if secret == "" {
+		return errors.New("a transcoder worker must have a secret")
+	}
+
+	if ffmpegPath == "" {
+		var err error
+		if ffmpegPath, err = exec.LookPath("ffmpeg"); err != nil {
+			return errors.New("unable to find ffmpeg. set the path to it with -transcoderworkerffmpeg")
+		}
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	// ffmpeg writes its report next to where Owncast keeps its logs.
+	if err := os.MkdirAll(filepath.Dir(logging.GetTranscoderLogFilePath()), 0700); err != nil {
+		log.Warnln(err)
+	}
+
+	log.Infof("Transcoder worker is accepting connections on %s.", listener.Addr())
+	log.Warnln("Transcoder worker connections are not encrypted. Only run the worker on a trusted private network.")
+
+	return serveTranscoderWorker(listener, secret, ffmpegPath)
+}
+
+func serveTranscoderWorker(listener net.Listener, secret string, ffmpegPath string) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go handleTranscoderWorkerConn(conn, secret, ffmpegPath)
+	}
+}
+
+func handleTranscoderWorkerConn(conn net.Conn, secret string, ffmpegPath string) {
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(remoteTranscoderTimeout))
+	frameType, payload, err := readRemoteFrame(conn, maxRemoteHelloSize)
+	if err != nil || frameType != remoteFrameHello {
+		return
+	}
+
+	var hello remoteHello
+	if err := json.Unmarshal(payload, &hello); err != nil || subtle.ConstantTimeCompare([]byte(hello.Secret), []byte(secret)) != 1 {
+		log.Warnln("Rejected a transcoder worker connection from", conn.RemoteAddr(), "with an invalid secret.")
+		return
+	}
+
+	frames := &remoteFrameWriter{w: conn}
+
+	// ffmpeg sends its output to us, and it is passed on to Owncast.
+	output, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	defer output.Close()
+
+	go func() {
+		_ = http.Serve(output, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			content, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if err := frames.write(remoteFrameFile, getRemoteFilePayload(r.URL.Path, content)); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}))
+	}()
+
+	_, port, _ := net.SplitHostPort(output.Addr().String())
+	ready, _ := json.Marshal(remoteReady{Port: port})
+	if err := frames.write(remoteFrameReady, ready); err != nil {
+		return
+	}
+
+	frameType, payload, err = readRemoteFrame(conn, maxRemoteHelloSize*16)
+	if err != nil || frameType != remoteFrameCommand {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	var command remoteCommand
+	if err := json.Unmarshal(payload, &command); err != nil {
+		return
+	}
+
+	if err := validateTranscoderWorkerArguments(command.Arguments, port); err != nil {
+		log.Warnln("Rejected the transcoder arguments from", conn.RemoteAddr(), err)
+		_ = frames.write(remoteFrameExit, []byte(err.Error()))
+		return
+	}
+
+	log.Infoln("Transcoding for", conn.RemoteAddr())
+	err = runTranscoderWorkerCommand(conn, frames, ffmpegPath, command.Arguments)
+
+	exitMessage := ""
+	if err != nil {
+		exitMessage = err.Error()
+	}
+	_ = frames.write(remoteFrameExit, []byte(exitMessage))
+
+	log.Infoln("Finished transcoding for", conn.RemoteAddr())
+}
+
+// runTranscoderWorkerCommand will run ffmpeg with the arguments and the
+// stream Owncast sends, until it exits or the connection is lost.
+func runTranscoderWorkerCommand(conn net.Conn, frames *remoteFrameWriter, ffmpegPath string, arguments []string) error {
+	cmd := exec.Command(ffmpegPath, arguments...) //nolint:gosec
+	cmd.Env = append(os.Environ(), fmt.Sprintf("FFREPORT=file=%s:level=32", logging.GetTranscoderLogFilePath()))
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	messages, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	progress, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go sendTranscoderWorkerOutput(frames, remoteFrameMessage, messages, &output)
+	go sendTranscoderWorkerOutput(frames, remoteFrameProgress, progress, &output)
+
+	go func() {
+		defer stdin.Close()
+
+		for {
+			frameType, payload, err := readRemoteFrame(conn, maxRemoteFrameSize)
+			if err != nil {
+				// Owncast ending the connection ends the transcoder.
+				_ = cmd.Process.Kill()
+				return
+			}
+
+			if frameType != remoteFrameInput {
+				continue
+			}
+
+			// The end of the stream is the end of ffmpeg's input.
+			if len(payload) == 0 {
+				stdin.Close()
+				continue
+			}
+
+			if _, err := stdin.Write(payload); err != nil {
+				log.Debugln(err)
+			}
+		}
+	}()
+
+	// Everything ffmpeg writes is sent before it is reported as exited.
+	output.Wait()
+	return cmd.Wait()
+}
+
+func sendTranscoderWorkerOutput(frames *remoteFrameWriter, frameType byte, r io.Reader, output *sync.WaitGroup) {
+	defer output.Done()
+
+	buffer := make([]byte, 4096)
+	for {
+		n, err := r.Read(buffer)
+		if n > 0 {
+			if writeErr := frames.write(frameType, buffer[:n]); writeErr != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				log.Debugln(err)
+			}
+			return
+		}
+	}
+}
+
+// The ffmpeg options the transcoder is run with, and if they take a value.
+// A worker refuses any other option, as it could read or write files on it.
+var transcoderWorkerOptions = map[string]bool{
+	"-hide_banner":            false,
+	"-re":                     false,
+	"-loglevel":               true,
+	"-progress":               true,
+	"-fflags":                 true,
+	"-i":                      true,
+	"-rtsp_transport":         true,
+	"-reconnect":              true,
+	"-reconnect_streamed":     true,
+	"-reconnect_delay_max":    true,
+	"-hwaccel":                true,
+	"-vaapi_device":           true,
+	"-map":                    true,
+	"-c":                      true,
+	"-b":                      true,
+	"-maxrate":                true,
+	"-bufsize":                true,
+	"-g":                      true,
+	"-keyint_min":             true,
+	"-r":                      true,
+	"-preset":                 true,
+	"-tune":                   true,
+	"-profile":                true,
+	"-tag":                    true,
+	"-x264-params":            true,
+	"-x265-params":            true,
+	"-svtav1-params":          true,
+	"-pix_fmt":                true,
+	"-sws_flags":              true,
+	"-filter":                 true,
+	"-var_stream_map":         true,
+	"-f":                      true,
+	"-hls_time":               true,
+	"-hls_list_size":          true,
+	"-hls_flags":              true,
+	"-start_number":           true,
+	"-hls_segment_type":       true,
+	"-hls_fmp4_init_filename": true,
+	"-segment_format_options": true,
+	"-sc_threshold":           true,
+	"-master_pl_name":         true,
+	"-strftime":               true,
+	"-hls_segment_filename":   true,
+	"-max_muxing_queue_size":  true,
+	"-method":                 true,
+	"-http_persistent":        true,
+}
+
+// transcoderWorkerFilters are the video filters the transcoder uses. Others,
+// such as drawtext or subtitles, can read files on the worker.
+var transcoderWorkerFilters = map[string]bool{
+	"scale":    true,
+	"format":   true,
+	"hwupload": true,
+}
+
+// transcoderWorkerEncoderParams are the encoder parameters the transcoder
+// sets. Others, such as stats or csv, write files on the worker.
+var transcoderWorkerEncoderParams = map[string]map[string]bool{
+	"-x264-params":   {"scenecut": true, "open_gop": true},
+	"-x265-params":   {"scenecut": true, "open-gop": true, "log-level": true},
+	"-svtav1-params": {"scd": true},
+}
+
+// validateTranscoderWorkerArguments will return an error if the arguments
+// use an option the transcoder doesn't, read anything but the inbound stream
+// or a pull source, or write anywhere but the worker's output port.
+func validateTranscoderWorkerArguments(arguments []string, outputPort string) error {
+	outputAddress := "http://127.0.0.1:" + outputPort + "/"
+	hasOutput := false
+
+	for i := 0; i < len(arguments); i++ {
+		argument := arguments[i]
+
+		// Anything that isn't an option or its value is an output.
+		if !strings.HasPrefix(argument, "-") {
+			if !strings.HasPrefix(argument, outputAddress) {
+				return fmt.Errorf("the output %s is not allowed", argument)
+			}
+			hasOutput = true
+			continue
+		}
+
+		// Options apply to streams by a specifier after their name.
+		option := strings.SplitN(argument, ":", 2)[0]
+		takesValue, ok := transcoderWorkerOptions[option]
+		if !ok {
+			return fmt.Errorf("the option %s is not allowed", argument)
+		}
+		if !takesValue {
+			continue
+		}
+
+		if i+1 >= len(arguments) {
+			return fmt.Errorf("the option %s is missing its value", argument)
+		}
+		i++
+		value := arguments[i]
+
+		switch option {
+		case "-i":
+			if !isTranscoderWorkerInput(value) {
+				return fmt.Errorf("the input %s is not allowed", value)
+			}
+		case "-progress":
+			if value != "pipe:1" {
+				return fmt.Errorf("the progress output %s is not allowed", value)
+			}
+		case "-hls_segment_filename":
+			if !strings.HasPrefix(value, outputAddress) {
+				return fmt.Errorf("the segment output %s is not allowed", value)
+			}
+		case "-hls_fmp4_init_filename", "-master_pl_name":
+			// These are written next to the playlist.
+			if path.Base(value) != value || value == ".." {
+				return fmt.Errorf("the file name %s is not allowed", value)
+			}
+		case "-vaapi_device":
+			if !strings.HasPrefix(filepath.Clean(value), "/dev/dri/") {
+				return fmt.Errorf("the device %s is not allowed", value)
+			}
+		case "-filter":
+			if !isAllowedTranscoderWorkerList(value, ",", transcoderWorkerFilters) {
+				return fmt.Errorf("the filter %s is not allowed", value)
+			}
+		case "-x264-params", "-x265-params", "-svtav1-params":
+			if !isAllowedTranscoderWorkerList(value, ":", transcoderWorkerEncoderParams[option]) {
+				return fmt.Errorf("the encoder parameters %s are not allowed", value)
+			}
+		}
+	}
+
+	if !hasOutput {
+		return errors.New("there is no output")
+	}
+
+	return nil
+}
+
+// isAllowedTranscoderWorkerList will return if every entry of a filter chain
+// or encoder parameter list is allowed. Quotes, escapes and paths are
+// refused so no other entry can be hidden in a value.
+func isAllowedTranscoderWorkerList(value string, separator string, allowed map[string]bool) bool {
+	for _, r := range value {
+		isSafe := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("=:_.-"+separator, r)
+		if !isSafe {
+			return false
+		}
+	}
+
+	for _, entry := range strings.Split(value, separator) {
+		if name := strings.SplitN(entry, "=", 2)[0]; !allowed[name] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isTranscoderWorkerInput will return if ffmpeg on a worker can read from
+// the input, which is either the stream sent to it or a pull source.
+func isTranscoderWorkerInput(input string) bool {
+	if input == "pipe:0" {
+		return true
+	}
+
+	u, err := url.Parse(input)
+	return err == nil && u.Host != "" && models.IsValidPullSourceScheme(u.Scheme)
+}
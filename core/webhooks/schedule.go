@@ -0,0 +1,13 @@
+package webhooks
+
+import (
+	"github.com/owncast/owncast/models"
+)
+
+// SendStreamScheduledEvent will send an upcoming stream added to the schedule.
+func SendStreamScheduledEvent(scheduledStream models.ScheduledStream) {
+	SendEventToWebhooks(WebhookEvent{
+		Type:      models.StreamScheduled,
+		EventData: scheduledStream,
+	})
+}
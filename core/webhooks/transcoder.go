@@ -0,0 +1,13 @@
+package webhooks
+
+import (
+	"github.com/owncast/owncast/models"
+)
+
+// SendTranscoderErrorEvent will send an error reported by the transcoder.
+func SendTranscoderErrorEvent(transcoderError models.TranscoderError) {
+	SendEventToWebhooks(WebhookEvent{
+		Type:      models.TranscoderErrorReported,
+		EventData: transcoderError,
+	})
+}
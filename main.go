@@ -12,6 +12,7 @@ import (
 	"github.com/owncast/owncast/config"
 	"github.com/owncast/owncast/core"
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/transcoder"
 	"github.com/owncast/owncast/metrics"
 	"github.com/owncast/owncast/router"
 	"github.com/owncast/owncast/utils"
@@ -27,10 +28,16 @@ func main() {
 	enableDebugOptions := flag.Bool("enableDebugFeatures", false, "Enable additional debugging options.")
 	enableVerboseLogging := flag.Bool("enableVerboseLogging", false, "Enable additional logging.")
 	restoreDatabaseFile := flag.String("restoreDatabase", "", "Restore an Owncast database backup")
-	newStreamKey := flag.String("streamkey", "", "Set your stream key/admin password")
+	newStreamKey := flag.String("streamkey", "", "Set your stream key")
+	adminUsername := flag.String("adminuser", data.DefaultAdminUsername, "The admin account to create or reset with -adminpassword")
+	newAdminPassword := flag.String("adminpassword", "", "Create an owner admin account or reset the password of an existing account")
 	webServerPortOverride := flag.String("webserverport", "", "Force the web server to listen on a specific port")
 	webServerIPOverride := flag.String("webserverip", "", "Force web server to listen on this IP address")
 	rtmpPortOverride := flag.Int("rtmpport", 0, "Set listen port for the RTMP server")
+	srtPortOverride := flag.Int("srtport", 0, "Set listen port for the SRT server")
+	transcoderWorkerAddress := flag.String("transcoderworker", "", "Run only as a transcoder worker for other Owncast servers, accepting unencrypted connections on this address of a trusted private network")
+	transcoderWorkerSecret := flag.String("transcoderworkersecret", "", "The secret Owncast servers connect to the transcoder worker with")
+	transcoderWorkerFfmpeg := flag.String("transcoderworkerffmpeg", "", "The path to ffmpeg the transcoder worker runs. Defaults to ffmpeg in your path")
 
 	flag.Parse()
 
@@ -70,6 +77,14 @@ func main() {
 
 	config.EnableDebugFeatures = *enableDebugOptions
 
+	// A transcoder worker only runs ffmpeg for other servers.
+	if *transcoderWorkerAddress != "" {
+		if err := transcoder.RunTranscoderWorker(*transcoderWorkerAddress, *transcoderWorkerSecret, *transcoderWorkerFfmpeg); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	if *dbFile != "" {
 		config.DatabaseFilePath = *dbFile
 	}
@@ -90,6 +105,16 @@ func main() {
 		log.Exit(0)
 	}
 
+	if *newAdminPassword != "" {
+		if err := data.SetAdminUser(*adminUsername, *newAdminPassword); err != nil {
+			log.Errorln("Error setting the admin password.", err)
+		} else {
+			log.Infoln("Admin password changed for", *adminUsername)
+		}
+
+		log.Exit(0)
+	}
+
 	// Set the web server port
 	if *webServerPortOverride != "" {
 		portNumber, err := strconv.Atoi(*webServerPortOverride)
@@ -116,6 +141,12 @@ func main() {
 		data.SetRTMPPortNumber(float64(*rtmpPortOverride))
 	}
 
+	// Set the srt server port
+	if *srtPortOverride > 0 {
+		log.Println("Saving new SRT server port number to", *srtPortOverride)
+		data.SetSRTPortNumber(float64(*srtPortOverride))
+	}
+
 	// starts the core
 	if err := core.Start(); err != nil {
 		log.Fatalln("failed to start the core package", err)
@@ -4,6 +4,9 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/core/transcoder"
 )
 
 const maxCPUAlertingThresholdPCT = 85
@@ -34,6 +37,10 @@ func handleCPUAlerting() {
 		log.Warnf(alertingError, "CPU", avg)
 		inCpuAlertingState = true
 
+		if data.GetAutoLadderEnabled() {
+			transcoder.DropAutoLadderRung()
+		}
+
 		resetTimer := time.NewTimer(errorResetDuration)
 		go func() {
 			<-resetTimer.C
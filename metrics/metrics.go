@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"time"
+
+	"github.com/owncast/owncast/core/transcoder"
 )
 
 // How often we poll for updates.
@@ -36,6 +38,11 @@ func handlePolling() {
 	collectRAMUtilization()
 	collectDiskUtilization()
 
+	// The auto ladder is picked with how busy the CPU is.
+	if len(Metrics.CPUUtilizations) >= 2 {
+		transcoder.SetCPUUsage(recentAverage(Metrics.CPUUtilizations))
+	}
+
 	// Alerting
 	handleAlerting()
 }
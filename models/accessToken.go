@@ -13,6 +13,8 @@ const (
 	ScopeCanSendSystemMessages = "CAN_SEND_SYSTEM_MESSAGES"
 	// ScopeHasAdminAccess will allow performing administrative actions on the server.
 	ScopeHasAdminAccess = "HAS_ADMIN_ACCESS"
+	// ScopeCanSendCaptions will allow sending live captions for the stream.
+	ScopeCanSendCaptions = "CAN_SEND_CAPTIONS"
 )
 
 // For a scope to be seen as "valid" it must live in this slice.
@@ -20,6 +22,7 @@ var validAccessTokenScopes = []string{
 	ScopeCanSendUserMessages,
 	ScopeCanSendSystemMessages,
 	ScopeHasAdminAccess,
+	ScopeCanSendCaptions,
 }
 
 // AccessToken gives access to 3rd party code to access specific Owncast APIs.
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	// AdminRoleOwner has full access to the server.
+	AdminRoleOwner = "OWNER"
+	// AdminRoleModerator can manage chat and view stats.
+	AdminRoleModerator = "MODERATOR"
+	// AdminRoleStats can only view stats.
+	AdminRoleStats = "STATS"
+)
+
+// Each role includes the access of the roles ranked below it.
+var adminRoleRanks = map[string]int{
+	AdminRoleStats:     1,
+	AdminRoleModerator: 2,
+	AdminRoleOwner:     3,
+}
+
+// AdminUser is an account that can access the admin.
+type AdminUser struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IsValidAdminRole will return if the role is one of the supported admin roles.
+func IsValidAdminRole(role string) bool {
+	_, ok := adminRoleRanks[role]
+	return ok
+}
+
+// AdminRoleHasAccess will return if the role grants the access of the required role.
+func AdminRoleHasAccess(role string, requiredRole string) bool {
+	rank, ok := adminRoleRanks[role]
+	if !ok {
+		return false
+	}
+
+	return rank >= adminRoleRanks[requiredRole]
+}
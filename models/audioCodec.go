@@ -0,0 +1,16 @@
+package models
+
+// The encoders a variant's audio can be encoded with.
+const (
+	// AudioCodecAAC is ffmpeg's built in AAC encoder.
+	AudioCodecAAC = "aac"
+	// AudioCodecFDKAAC is the Fraunhofer AAC encoder, when ffmpeg is built with it.
+	AudioCodecFDKAAC = "libfdk_aac"
+	// AudioCodecOpus is the Opus encoder. Opus is only delivered in fMP4 segments.
+	AudioCodecOpus = "libopus"
+)
+
+// IsValidAudioCodec will return if the codec is one a variant's audio can be encoded with.
+func IsValidAudioCodec(codec string) bool {
+	return codec == AudioCodecAAC || codec == AudioCodecFDKAAC || codec == AudioCodecOpus
+}
@@ -0,0 +1,23 @@
+package models
+
+// AudioTrack is an alternate audio rendition of the stream, such as
+// commentary or a translation, sent from its own inbound stream.
+type AudioTrack struct {
+	// Name is the label players show for the track.
+	Name string `json:"name"`
+	// Language is the optional RFC 5646 language tag of the track.
+	Language string `json:"language,omitempty"`
+	// StreamKey is the key the track's inbound stream connects with.
+	StreamKey string `json:"streamKey"`
+	// AudioBitrate is the bitrate of the track in kbps.
+	AudioBitrate int `json:"audioBitrate"`
+}
+
+// GetAudioBitrate will return the bitrate of the track or the default.
+func (t *AudioTrack) GetAudioBitrate() int {
+	if t.AudioBitrate > 0 {
+		return t.AudioBitrate
+	}
+
+	return 128
+}
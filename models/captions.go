@@ -0,0 +1,40 @@
+package models
+
+// Captions are the settings of the live captions published alongside the stream.
+type Captions struct {
+	// Enabled is if captions sent to the server should be published.
+	Enabled bool `json:"enabled"`
+	// Name is the label players show for the captions.
+	Name string `json:"name"`
+	// Language is the optional RFC 5646 language tag of the captions.
+	Language string `json:"language,omitempty"`
+}
+
+// GetName will return the label of the captions or the default.
+func (c *Captions) GetName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return "Captions"
+}
+
+// CaptionCue is a line of captions shown for part of the stream.
+type CaptionCue struct {
+	// Text is the caption to show.
+	Text string `json:"text"`
+	// Start is the number of seconds into the stream the caption is shown
+	// at. When it is not set the caption is shown from when it was received.
+	Start *float64 `json:"start,omitempty"`
+	// Duration is the number of seconds the caption is shown for.
+	Duration float64 `json:"duration"`
+}
+
+// GetDuration will return the number of seconds the cue is shown for or the default.
+func (c *CaptionCue) GetDuration() float64 {
+	if c.Duration > 0 {
+		return c.Duration
+	}
+
+	return 3
+}
@@ -20,6 +20,10 @@ const (
 	StreamStarted EventType = "STREAM_STARTED"
 	// StreamStopped represents a stream stopped event.
 	StreamStopped EventType = "STREAM_STOPPED"
+	// StreamScheduled is the event sent when an upcoming stream is added to the schedule.
+	StreamScheduled EventType = "STREAM_SCHEDULED"
+	// TranscoderErrorReported is the event sent when the transcoder reports an error.
+	TranscoderErrorReported EventType = "TRANSCODER_ERROR"
 	// SystemMessageSent is the event sent when a system message is sent.
 	SystemMessageSent EventType = "SYSTEM"
 	// ChatActionSent is a generic chat action that can be used for anything that doesn't need specific handling or formatting.
@@ -2,19 +2,23 @@ package models
 
 // LatencyLevel is a representation of HLS configuration values.
 type LatencyLevel struct {
-	Level             int `json:"level"`
-	SecondsPerSegment int `json:"-"`
-	SegmentCount      int `json:"-"`
+	Level             int     `json:"level"`
+	SecondsPerSegment int     `json:"-"`
+	SegmentCount      int     `json:"-"`
+	SecondsPerPart    float64 `json:"-"`
 }
 
 // GetLatencyConfigs will return the available latency level options.
+// Levels 0 to 4 go from the least to the most latency. Low-latency HLS was
+// added later as level 5 so the levels already saved keep their meaning.
 func GetLatencyConfigs() map[int]LatencyLevel {
 	return map[int]LatencyLevel{
-		0: {Level: 0, SecondsPerSegment: 1, SegmentCount: 3}, // Approx 5 seconds
-		1: {Level: 1, SecondsPerSegment: 2, SegmentCount: 2}, // Approx 7-8 seconds
-		2: {Level: 2, SecondsPerSegment: 3, SegmentCount: 3}, // Default Approx 11 seconds
-		3: {Level: 3, SecondsPerSegment: 4, SegmentCount: 3}, // Approx 15 seconds
-		4: {Level: 4, SecondsPerSegment: 5, SegmentCount: 4}, // Approx 18 seconds
+		0: {Level: 0, SecondsPerSegment: 1, SegmentCount: 3},                      // Approx 5 seconds
+		1: {Level: 1, SecondsPerSegment: 2, SegmentCount: 2},                      // Approx 7-8 seconds
+		2: {Level: 2, SecondsPerSegment: 3, SegmentCount: 3},                      // Default Approx 11 seconds
+		3: {Level: 3, SecondsPerSegment: 4, SegmentCount: 3},                      // Approx 15 seconds
+		4: {Level: 4, SecondsPerSegment: 5, SegmentCount: 4},                      // Approx 18 seconds
+		5: {Level: 5, SecondsPerSegment: 2, SegmentCount: 4, SecondsPerPart: 0.5}, // Low-latency HLS, approx 2-3 seconds
 	}
 }
 
@@ -22,3 +26,38 @@ func GetLatencyConfigs() map[int]LatencyLevel {
 func GetLatencyLevel(index int) LatencyLevel {
 	return GetLatencyConfigs()[index]
 }
+
+// GetLowestLatencyLevel will return the level with the least latency that is
+// not delivered as low-latency HLS.
+func GetLowestLatencyLevel() LatencyLevel {
+	var lowest LatencyLevel
+	found := false
+	for _, level := range GetLatencyConfigs() {
+		if level.IsLowLatencyHLS() {
+			continue
+		}
+
+		if !found || level.SecondsPerSegment*level.SegmentCount < lowest.SecondsPerSegment*lowest.SegmentCount {
+			lowest = level
+			found = true
+		}
+	}
+
+	return lowest
+}
+
+// IsLowLatencyHLS will return if this level is delivered as low-latency HLS
+// with partial segments.
+func (l LatencyLevel) IsLowLatencyHLS() bool {
+	return l.SecondsPerPart > 0
+}
+
+// GetPartsPerSegment will return the number of partial segments that make up
+// a full segment at this level.
+func (l LatencyLevel) GetPartsPerSegment() int {
+	if !l.IsLowLatencyHLS() {
+		return 1
+	}
+
+	return int(float64(l.SecondsPerSegment)/l.SecondsPerPart + 0.5)
+}
@@ -0,0 +1,22 @@
+package models
+
+// PullSource is a remote stream Owncast connects to instead of waiting
+// for a broadcaster to push to it.
+type PullSource struct {
+	// Enabled is if Owncast should connect to the source.
+	Enabled bool `json:"enabled"`
+	// URL is the rtsp://, rtsps://, srt://, or http(s):// HLS playlist of the source.
+	URL string `json:"url"`
+	// ReconnectDelay is the number of seconds to wait before connecting again.
+	ReconnectDelay int `json:"reconnectDelay"`
+}
+
+// IsValidPullSourceScheme will return if the url scheme is one ffmpeg can pull from.
+func IsValidPullSourceScheme(scheme string) bool {
+	switch scheme {
+	case "rtsp", "rtsps", "srt", "http", "https":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Recording is a single archived broadcast.
+type Recording struct {
+	ID        int        `json:"id"`
+	Title     string     `json:"title"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+	Duration  float64    `json:"duration"` // In seconds
+	Size      int64      `json:"size"`     // In bytes
+}
+
+// IsInProgress will return if this recording is still being written.
+func (r *Recording) IsInProgress() bool {
+	return r.EndTime == nil
+}
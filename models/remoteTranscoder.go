@@ -0,0 +1,12 @@
+package models
+
+// RemoteTranscoder is a transcoder worker on another server that runs ffmpeg
+// for the stream instead of this server.
+type RemoteTranscoder struct {
+	// Enabled is if the stream should be transcoded by the worker.
+	Enabled bool `json:"enabled"`
+	// Address is the host:port the worker accepts connections on.
+	Address string `json:"address"`
+	// Secret is the secret the worker was started with.
+	Secret string `json:"secret"`
+}
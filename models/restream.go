@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	// RestreamConnecting is when a destination is being connected to.
+	RestreamConnecting = "CONNECTING"
+	// RestreamLive is when the stream is being sent to a destination.
+	RestreamLive = "LIVE"
+	// RestreamReconnecting is when a destination is waiting to reconnect after a failure.
+	RestreamReconnecting = "RECONNECTING"
+	// RestreamFailed is when a destination has stopped after failing.
+	RestreamFailed = "FAILED"
+)
+
+// RestreamDestination is an external RTMP or RTMPS endpoint the inbound stream is forwarded to.
+type RestreamDestination struct {
+	// ID uniquely identifies this destination.
+	ID string `json:"id"`
+	// Name is the display name of this destination.
+	Name string `json:"name"`
+	// URL is the full rtmp:// or rtmps:// URL, including any stream key.
+	URL string `json:"url"`
+	// Enabled is if the stream should be sent to this destination.
+	Enabled bool `json:"enabled"`
+	// Reconnect is if a failed connection should be retried while the stream is live.
+	Reconnect bool `json:"reconnect"`
+	// MaxReconnectAttempts is how many consecutive retries to make. Zero retries forever.
+	MaxReconnectAttempts int `json:"maxReconnectAttempts"`
+	// ReconnectDelay is the number of seconds to wait between retries.
+	ReconnectDelay int `json:"reconnectDelay"`
+}
+
+// RestreamStatus is the current state of a single restream destination.
+type RestreamStatus struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	State          string     `json:"state"`
+	Error          string     `json:"error,omitempty"`
+	ConnectedTime  *time.Time `json:"connectedTime"`
+	ReconnectCount int        `json:"reconnectCount"`
+}
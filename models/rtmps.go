@@ -0,0 +1,13 @@
+package models
+
+// RTMPSConfig is the TLS listener that broadcasters can push to over RTMPS.
+type RTMPSConfig struct {
+	// Enabled is if the RTMPS listener should be started.
+	Enabled bool `json:"enabled"`
+	// Port is the port to listen on. The default is used when it is not set.
+	Port int `json:"port"`
+	// CertificatePath is the PEM encoded certificate chain.
+	CertificatePath string `json:"certificatePath"`
+	// KeyPath is the PEM encoded private key of the certificate.
+	KeyPath string `json:"keyPath"`
+}
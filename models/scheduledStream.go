@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ScheduledStream is an upcoming broadcast announced to viewers.
+type ScheduledStream struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"startTime"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	// Premiere is the video file aired as the stream at its start time, if
+	// it is a premiere of pre-recorded content.
+	Premiere string `json:"premiere,omitempty"`
+	// PremiereStarted is when the premiere was aired.
+	PremiereStarted *time.Time `json:"premiereStarted,omitempty"`
+}
+
+// IsPremiere will return if the scheduled stream airs a video file.
+func (s *ScheduledStream) IsPremiere() bool {
+	return s.Premiere != ""
+}
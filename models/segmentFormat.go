@@ -0,0 +1,14 @@
+package models
+
+// The container formats HLS segments can be written in.
+const (
+	// SegmentFormatMPEGTS is MPEG transport stream segments.
+	SegmentFormatMPEGTS = "mpegts"
+	// SegmentFormatFMP4 is fragmented MP4, or CMAF, segments.
+	SegmentFormatFMP4 = "fmp4"
+)
+
+// IsValidSegmentFormat will return if the format is one HLS segments can be written in.
+func IsValidSegmentFormat(format string) bool {
+	return format == SegmentFormatMPEGTS || format == SegmentFormatFMP4
+}
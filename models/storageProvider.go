@@ -4,8 +4,11 @@ package models
 type StorageProvider interface {
 	Setup() error
 	Save(filePath string, retryCount int) (string, error)
+	SaveVODSegment(localFilePath string, recordingID int) (string, error)
+	DeleteVODSegments(recordingID int) error
 
 	SegmentWritten(localFilePath string)
 	VariantPlaylistWritten(localFilePath string)
 	MasterPlaylistWritten(localFilePath string)
+	DASHManifestWritten(localFilePath string)
 }
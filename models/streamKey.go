@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// StreamKey is an additional key a broadcaster can use to stream.
+type StreamKey struct {
+	Key       string     `json:"key"`
+	Label     string     `json:"label"`
+	Expiry    *time.Time `json:"expiry"`
+	Timestamp time.Time  `json:"timestamp"`
+	LastUsed  *time.Time `json:"lastUsed"`
+}
+
+// IsExpired will return if the key can no longer be used to stream.
+func (k *StreamKey) IsExpired() bool {
+	return k.Expiry != nil && time.Now().After(*k.Expiry)
+}
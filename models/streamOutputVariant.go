@@ -17,6 +17,9 @@ type StreamOutputVariant struct {
 	IsVideoPassthrough bool `yaml:"videoPassthrough" json:"videoPassthrough"`
 	IsAudioPassthrough bool `yaml:"audioPassthrough" json:"audioPassthrough"`
 
+	// Enable audio only to leave out the video, so only the audio is sent.
+	IsAudioOnly bool `yaml:"audioOnly" json:"audioOnly"`
+
 	VideoBitrate int `yaml:"videoBitrate" json:"videoBitrate"`
 	AudioBitrate int `yaml:"audioBitrate" json:"audioBitrate"`
 
@@ -28,11 +31,16 @@ type StreamOutputVariant struct {
 	Framerate int `yaml:"framerate" json:"framerate"`
 	// CPUUsageLevel represents a codec preset to configure CPU usage.
 	CPUUsageLevel int `json:"cpuUsageLevel"`
+
+	// VideoCodec and AudioCodec are the encoders used for this variant.
+	// Leave them empty to use the server's video codec and AAC audio.
+	VideoCodec string `yaml:"videoCodec" json:"videoCodec,omitempty"`
+	AudioCodec string `yaml:"audioCodec" json:"audioCodec,omitempty"`
 }
 
 // GetFramerate returns the framerate or default.
 func (q *StreamOutputVariant) GetFramerate() int {
-	if q.IsVideoPassthrough {
+	if q.IsVideoPassthrough || q.IsAudioOnly {
 		return 0
 	}
 
@@ -62,6 +70,10 @@ func (q *StreamOutputVariant) GetName() string {
 
 	if q.Name != "" {
 		return q.Name
+	} else if q.IsAudioOnly && q.GetIsAudioPassthrough() {
+		return "Audio"
+	} else if q.IsAudioOnly {
+		return fmt.Sprintf("Audio @%s", getBitrateString(q.AudioBitrate))
 	} else if q.IsVideoPassthrough {
 		return "Source"
 	} else if q.ScaledHeight == 720 && q.ScaledWidth == 1080 {
@@ -0,0 +1,13 @@
+package models
+
+// TimedMetadata is ID3 metadata added to the live stream at a point in it,
+// such as what is now playing, a chapter marker, or a poll.
+type TimedMetadata struct {
+	// Title is written as the ID3 title, such as the song that is now playing.
+	Title string `json:"title,omitempty"`
+	// Values are written as ID3 user defined text, described by their key.
+	Values map[string]string `json:"values,omitempty"`
+	// Start is the number of seconds into the stream the metadata is at.
+	// When it is not set the metadata is at when it was received.
+	Start *float64 `json:"start,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	// TranscoderErrorSeverityCritical is an error that stops the transcoder from running.
+	TranscoderErrorSeverityCritical = "CRITICAL"
+	// TranscoderErrorSeverityError is an error the stream may not recover from.
+	TranscoderErrorSeverityError = "ERROR"
+	// TranscoderErrorSeverityWarning is a problem that may affect playback.
+	TranscoderErrorSeverityWarning = "WARNING"
+)
+
+// TranscoderError is a problem reported by the transcoder during a stream.
+// An error reported again in the same stream is counted instead of repeated.
+type TranscoderError struct {
+	ID           int       `json:"id"`
+	Session      string    `json:"session"`
+	Code         string    `json:"code"`
+	Severity     string    `json:"severity"`
+	Message      string    `json:"message"`
+	SuggestedFix string    `json:"suggestedFix,omitempty"`
+	Count        int       `json:"count"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TranscoderHealth is how well the transcoder is keeping up with the live stream.
+type TranscoderHealth struct {
+	Speed            float64   `json:"speed"`
+	Framerate        float64   `json:"framerate"`
+	Frames           int       `json:"frames"`
+	DroppedFrames    int       `json:"droppedFrames"`
+	DuplicatedFrames int       `json:"duplicatedFrames"`
+	Stalled          bool      `json:"stalled"`
+	Restarts         int       `json:"restarts"`
+	LastUpdated      time.Time `json:"lastUpdated"`
+}
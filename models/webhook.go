@@ -19,6 +19,8 @@ var validEvents = []EventType{
 	VisibiltyToggled,
 	StreamStarted,
 	StreamStopped,
+	StreamScheduled,
+	TranscoderErrorReported,
 }
 
 // HasValidEvents will verify that all the events provided are valid.
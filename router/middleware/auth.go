@@ -1,20 +1,18 @@
 package middleware
 
 import (
-	"crypto/subtle"
 	"net/http"
 	"strings"
 
 	"github.com/owncast/owncast/core/data"
+	"github.com/owncast/owncast/models"
 	log "github.com/sirupsen/logrus"
 )
 
-// RequireAdminAuth wraps a handler requiring HTTP basic auth for it using the given
-// the stream key as the password and and a hardcoded "admin" for username.
-func RequireAdminAuth(handler http.HandlerFunc) http.HandlerFunc {
+// RequireAdminAuth wraps a handler requiring HTTP basic auth for it using
+// the credentials of an admin account that has at least the required role.
+func RequireAdminAuth(requiredRole string, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		username := "admin"
-		password := data.GetStreamKey()
 		realm := "Owncast Authenticated Request"
 
 		// The following line is kind of a work around.
@@ -33,14 +31,26 @@ func RequireAdminAuth(handler http.HandlerFunc) http.HandlerFunc {
 
 		user, pass, ok := r.BasicAuth()
 
+		role := ""
+		if ok {
+			role, ok = data.GetAdminRoleForCredentials(user, pass)
+		}
+
 		// Failed
-		if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 || subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+		if !ok {
 			w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`"`)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			log.Debugln("Failed authentication for", r.URL.Path, "from", r.RemoteAddr, r.UserAgent())
 			return
 		}
 
+		// Authenticated, but the account's role does not allow this request
+		if !models.AdminRoleHasAccess(role, requiredRole) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			log.Debugln(user, "does not have the", requiredRole, "role required for", r.URL.Path)
+			return
+		}
+
 		handler(w, r)
 	}
 }
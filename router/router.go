@@ -21,8 +21,11 @@ func Start() error {
 	// static files
 	http.HandleFunc("/", controllers.IndexHandler)
 
+	// hls playlists and segments
+	http.HandleFunc("/hls/", controllers.HandleHLSRequest)
+
 	// admin static files
-	http.HandleFunc("/admin/", middleware.RequireAdminAuth(admin.ServeAdmin))
+	http.HandleFunc("/admin/", middleware.RequireAdminAuth(models.AdminRoleStats, admin.ServeAdmin))
 
 	// status of the system
 	http.HandleFunc("/api/status", controllers.GetStatus)
@@ -43,6 +46,9 @@ func Start() error {
 	// web config api
 	http.HandleFunc("/api/config", controllers.GetWebConfig)
 
+	// the upcoming streams as an iCalendar feed
+	http.HandleFunc("/api/schedule.ics", controllers.GetScheduleCalendar)
+
 	// chat embed
 	http.HandleFunc("/embed/chat", controllers.GetChatEmbed)
 
@@ -64,83 +70,158 @@ func Start() error {
 	// tell the backend you're an active viewer
 	http.HandleFunc("/api/ping", controllers.Ping)
 
+	// list of recorded broadcasts available to watch
+	http.HandleFunc("/api/vod", controllers.GetVODs)
+
+	// playlists and segments of recorded broadcasts
+	http.HandleFunc("/api/vod/", controllers.ServeVOD)
+
 	// Authenticated admin requests
 
 	// Current inbound broadcaster
-	http.HandleFunc("/api/admin/status", middleware.RequireAdminAuth(admin.Status))
+	http.HandleFunc("/api/admin/status", middleware.RequireAdminAuth(models.AdminRoleStats, admin.Status))
 
 	// Disconnect inbound stream
-	http.HandleFunc("/api/admin/disconnect", middleware.RequireAdminAuth(admin.DisconnectInboundConnection))
+	http.HandleFunc("/api/admin/disconnect", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DisconnectInboundConnection))
 
-	// Server config
-	http.HandleFunc("/api/admin/serverconfig", middleware.RequireAdminAuth(admin.GetServerConfig))
+	// Server config, without keys and secrets for accounts that are not owners
+	http.HandleFunc("/api/admin/serverconfig", middleware.RequireAdminAuth(models.AdminRoleStats, admin.GetServerConfig))
 
 	// Get viewer count over time
-	http.HandleFunc("/api/admin/viewersOverTime", middleware.RequireAdminAuth(admin.GetViewersOverTime))
+	http.HandleFunc("/api/admin/viewersOverTime", middleware.RequireAdminAuth(models.AdminRoleStats, admin.GetViewersOverTime))
 
 	// Get hardware stats
-	http.HandleFunc("/api/admin/hardwarestats", middleware.RequireAdminAuth(admin.GetHardwareStats))
+	http.HandleFunc("/api/admin/hardwarestats", middleware.RequireAdminAuth(models.AdminRoleStats, admin.GetHardwareStats))
 
 	// Get a a detailed list of currently connected clients
-	http.HandleFunc("/api/admin/clients", middleware.RequireAdminAuth(controllers.GetConnectedClients))
+	http.HandleFunc("/api/admin/clients", middleware.RequireAdminAuth(models.AdminRoleModerator, controllers.GetConnectedClients))
 
 	// Get all logs
-	http.HandleFunc("/api/admin/logs", middleware.RequireAdminAuth(admin.GetLogs))
+	http.HandleFunc("/api/admin/logs", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetLogs))
 
 	// Get warning/error logs
-	http.HandleFunc("/api/admin/logs/warnings", middleware.RequireAdminAuth(admin.GetWarnings))
+	http.HandleFunc("/api/admin/logs/warnings", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetWarnings))
+
+	// Get the errors reported by the transcoder
+	http.HandleFunc("/api/admin/transcoder/errors", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetTranscoderErrors))
 
 	// Get all chat messages for the admin, unfiltered.
-	http.HandleFunc("/api/admin/chat/messages", middleware.RequireAdminAuth(admin.GetChatMessages))
+	http.HandleFunc("/api/admin/chat/messages", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.GetChatMessages))
 
 	// Update chat message visibility
-	http.HandleFunc("/api/admin/chat/updatemessagevisibility", middleware.RequireAdminAuth(admin.UpdateMessageVisibility))
+	http.HandleFunc("/api/admin/chat/updatemessagevisibility", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.UpdateMessageVisibility))
 	// Update config values
 
 	// Change the current streaming key in memory
-	http.HandleFunc("/api/admin/config/key", middleware.RequireAdminAuth(admin.SetStreamKey))
+	http.HandleFunc("/api/admin/config/key", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetStreamKey))
+
+	// Change the key a backup encoder connects with
+	http.HandleFunc("/api/admin/config/backupkey", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetBackupStreamKey))
+
+	// change the password of the current admin account
+	http.HandleFunc("/api/admin/config/adminpassword", middleware.RequireAdminAuth(models.AdminRoleStats, admin.SetAdminPassword))
 
 	// Change the extra page content in memory
-	http.HandleFunc("/api/admin/config/pagecontent", middleware.RequireAdminAuth(admin.SetExtraPageContent))
+	http.HandleFunc("/api/admin/config/pagecontent", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetExtraPageContent))
 
 	// Stream title
-	http.HandleFunc("/api/admin/config/streamtitle", middleware.RequireAdminAuth(admin.SetStreamTitle))
+	http.HandleFunc("/api/admin/config/streamtitle", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.SetStreamTitle))
 
 	// Server name
-	http.HandleFunc("/api/admin/config/name", middleware.RequireAdminAuth(admin.SetServerName))
+	http.HandleFunc("/api/admin/config/name", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetServerName))
 
 	// Server summary
-	http.HandleFunc("/api/admin/config/serversummary", middleware.RequireAdminAuth(admin.SetServerSummary))
+	http.HandleFunc("/api/admin/config/serversummary", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetServerSummary))
 
 	// Server welcome message
-	http.HandleFunc("/api/admin/config/welcomemessage", middleware.RequireAdminAuth(admin.SetServerWelcomeMessage))
+	http.HandleFunc("/api/admin/config/welcomemessage", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetServerWelcomeMessage))
 
 	// Disable chat
-	http.HandleFunc("/api/admin/config/chat/disable", middleware.RequireAdminAuth(admin.SetChatDisabled))
+	http.HandleFunc("/api/admin/config/chat/disable", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.SetChatDisabled))
 
 	// Set chat usernames that are not allowed
-	http.HandleFunc("/api/admin/config/chat/disallowedusernames", middleware.RequireAdminAuth(admin.SetUsernameBlocklist))
+	http.HandleFunc("/api/admin/config/chat/disallowedusernames", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.SetUsernameBlocklist))
 
 	// Set video codec
-	http.HandleFunc("/api/admin/config/video/codec", middleware.RequireAdminAuth(admin.SetVideoCodec))
+	http.HandleFunc("/api/admin/config/video/codec", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetVideoCodec))
+
+	// Set hls segment format
+	http.HandleFunc("/api/admin/config/video/segmentformat", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetSegmentFormat))
+
+	// Enable or disable the mpeg-dash manifest
+	http.HandleFunc("/api/admin/config/video/dash", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetDASHEnabled))
+
+	// Enable or disable audio-only radio mode
+	http.HandleFunc("/api/admin/config/video/radiomode", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetRadioModeEnabled))
+
+	// Enable or disable picking the stream output variants from the inbound stream
+	http.HandleFunc("/api/admin/config/video/autoladder", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetAutoLadderEnabled))
+
+	// Set the alternate audio tracks
+	http.HandleFunc("/api/admin/config/video/audiotracks", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetAudioTracks))
+
+	// Set if live captions are published
+	http.HandleFunc("/api/admin/config/video/captions", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetCaptions))
+
+	// Set the transcoder worker the stream is transcoded by
+	http.HandleFunc("/api/admin/config/video/remotetranscoder", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetRemoteTranscoder))
+
+	// Return all scheduled streams
+	http.HandleFunc("/api/admin/schedule", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.GetSchedule))
+
+	// Add an upcoming stream to the schedule
+	http.HandleFunc("/api/admin/schedule/create", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.CreateScheduledStream))
+
+	// Remove a stream from the schedule
+	http.HandleFunc("/api/admin/schedule/delete", middleware.RequireAdminAuth(models.AdminRoleModerator, admin.DeleteScheduledStream))
+
+	// Schedule a video file to be aired as the live stream
+	http.HandleFunc("/api/admin/premieres/create", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.CreatePremiere))
+
+	// Return the videos uploaded to be aired as premieres
+	http.HandleFunc("/api/admin/premieres/files", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetPremiereFiles))
+
+	// Upload a video to be aired as a premiere
+	http.HandleFunc("/api/admin/premieres/upload", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.UploadPremiereFile))
 
 	// Return all webhooks
-	http.HandleFunc("/api/admin/webhooks", middleware.RequireAdminAuth(admin.GetWebhooks))
+	http.HandleFunc("/api/admin/webhooks", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetWebhooks))
 
 	// Delete a single webhook
-	http.HandleFunc("/api/admin/webhooks/delete", middleware.RequireAdminAuth(admin.DeleteWebhook))
+	http.HandleFunc("/api/admin/webhooks/delete", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DeleteWebhook))
 
 	// Create a single webhook
-	http.HandleFunc("/api/admin/webhooks/create", middleware.RequireAdminAuth(admin.CreateWebhook))
+	http.HandleFunc("/api/admin/webhooks/create", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.CreateWebhook))
 
 	// Get all access tokens
-	http.HandleFunc("/api/admin/accesstokens", middleware.RequireAdminAuth(admin.GetAccessTokens))
+	http.HandleFunc("/api/admin/accesstokens", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetAccessTokens))
 
 	// Delete a single access token
-	http.HandleFunc("/api/admin/accesstokens/delete", middleware.RequireAdminAuth(admin.DeleteAccessToken))
+	http.HandleFunc("/api/admin/accesstokens/delete", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DeleteAccessToken))
 
 	// Create a single access token
-	http.HandleFunc("/api/admin/accesstokens/create", middleware.RequireAdminAuth(admin.CreateAccessToken))
+	http.HandleFunc("/api/admin/accesstokens/create", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.CreateAccessToken))
+
+	// Get all additional stream keys
+	http.HandleFunc("/api/admin/streamkeys", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetStreamKeys))
+
+	// Delete a single additional stream key
+	http.HandleFunc("/api/admin/streamkeys/delete", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DeleteStreamKey))
+
+	// Create a single additional stream key
+	http.HandleFunc("/api/admin/streamkeys/create", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.CreateStreamKey))
+
+	// Get all admin users
+	http.HandleFunc("/api/admin/users", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetAdminUsers))
+
+	// Delete a single admin user
+	http.HandleFunc("/api/admin/users/delete", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DeleteAdminUser))
+
+	// Create a single admin user
+	http.HandleFunc("/api/admin/users/create", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.CreateAdminUser))
+
+	// Get the admin user making the request
+	http.HandleFunc("/api/admin/users/me", middleware.RequireAdminAuth(models.AdminRoleStats, admin.GetCurrentAdminUser))
 
 	// Send a system message to chat
 	http.HandleFunc("/api/integrations/chat/system", middleware.RequireAccessToken(models.ScopeCanSendSystemMessages, admin.SendSystemMessage))
@@ -154,6 +235,12 @@ func Start() error {
 	// Hide chat message
 	http.HandleFunc("/api/integrations/chat/messagevisibility", middleware.RequireAccessToken(models.ScopeHasAdminAccess, admin.UpdateMessageVisibility))
 
+	// Add timed metadata to the stream
+	http.HandleFunc("/api/integrations/metadata", middleware.RequireAccessToken(models.ScopeHasAdminAccess, admin.SendTimedMetadata))
+
+	// Send live captions
+	http.HandleFunc("/api/integrations/captions", middleware.RequireAccessToken(models.ScopeCanSendCaptions, admin.SendCaptions))
+
 	// Stream title
 	http.HandleFunc("/api/integrations/streamtitle", middleware.RequireAccessToken(models.ScopeHasAdminAccess, admin.SetStreamTitle))
 
@@ -164,52 +251,79 @@ func Start() error {
 	http.HandleFunc("/api/integrations/clients", middleware.RequireAccessToken(models.ScopeHasAdminAccess, controllers.GetConnectedClients))
 
 	// Logo path
-	http.HandleFunc("/api/admin/config/logo", middleware.RequireAdminAuth(admin.SetLogo))
+	http.HandleFunc("/api/admin/config/logo", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetLogo))
 
 	// Server tags
-	http.HandleFunc("/api/admin/config/tags", middleware.RequireAdminAuth(admin.SetTags))
+	http.HandleFunc("/api/admin/config/tags", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetTags))
 
 	// ffmpeg
-	http.HandleFunc("/api/admin/config/ffmpegpath", middleware.RequireAdminAuth(admin.SetFfmpegPath))
+	http.HandleFunc("/api/admin/config/ffmpegpath", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetFfmpegPath))
 
 	// Server http port
-	http.HandleFunc("/api/admin/config/webserverport", middleware.RequireAdminAuth(admin.SetWebServerPort))
+	http.HandleFunc("/api/admin/config/webserverport", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetWebServerPort))
 
 	// Server http listen address
-	http.HandleFunc("/api/admin/config/webserverip", middleware.RequireAdminAuth(admin.SetWebServerIP))
+	http.HandleFunc("/api/admin/config/webserverip", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetWebServerIP))
 
 	// Server rtmp port
-	http.HandleFunc("/api/admin/config/rtmpserverport", middleware.RequireAdminAuth(admin.SetRTMPServerPort))
+	http.HandleFunc("/api/admin/config/rtmpserverport", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetRTMPServerPort))
+
+	// Server rtmps listener
+	http.HandleFunc("/api/admin/config/rtmps", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetRTMPSConfiguration))
+
+	// Server srt port
+	http.HandleFunc("/api/admin/config/srtserverport", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetSRTServerPort))
+
+	// Is the srt server accepting inbound streams
+	http.HandleFunc("/api/admin/config/srt", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetSRTEnabled))
 
 	// Is server marked as NSFW
-	http.HandleFunc("/api/admin/config/nsfw", middleware.RequireAdminAuth(admin.SetNSFW))
+	http.HandleFunc("/api/admin/config/nsfw", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetNSFW))
 
 	// directory enabled
-	http.HandleFunc("/api/admin/config/directoryenabled", middleware.RequireAdminAuth(admin.SetDirectoryEnabled))
+	http.HandleFunc("/api/admin/config/directoryenabled", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetDirectoryEnabled))
 
 	// social handles
-	http.HandleFunc("/api/admin/config/socialhandles", middleware.RequireAdminAuth(admin.SetSocialHandles))
+	http.HandleFunc("/api/admin/config/socialhandles", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetSocialHandles))
 
 	// set the number of video segments and duration per segment in a playlist
-	http.HandleFunc("/api/admin/config/video/streamlatencylevel", middleware.RequireAdminAuth(admin.SetStreamLatencyLevel))
+	http.HandleFunc("/api/admin/config/video/streamlatencylevel", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetStreamLatencyLevel))
 
 	// set an array of video output configurations
-	http.HandleFunc("/api/admin/config/video/streamoutputvariants", middleware.RequireAdminAuth(admin.SetStreamOutputVariants))
+	http.HandleFunc("/api/admin/config/video/streamoutputvariants", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetStreamOutputVariants))
 
 	// set s3 configuration
-	http.HandleFunc("/api/admin/config/s3", middleware.RequireAdminAuth(admin.SetS3Configuration))
+	http.HandleFunc("/api/admin/config/s3", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetS3Configuration))
 
 	// set server url
-	http.HandleFunc("/api/admin/config/serverurl", middleware.RequireAdminAuth(admin.SetServerURL))
+	http.HandleFunc("/api/admin/config/serverurl", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetServerURL))
 
 	// reset the YP registration
-	http.HandleFunc("/api/admin/yp/reset", middleware.RequireAdminAuth(admin.ResetYPRegistration))
+	http.HandleFunc("/api/admin/yp/reset", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.ResetYPRegistration))
 
 	// set external action links
-	http.HandleFunc("/api/admin/config/externalactions", middleware.RequireAdminAuth(admin.SetExternalActions))
+	http.HandleFunc("/api/admin/config/externalactions", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetExternalActions))
 
 	// set custom style css
-	http.HandleFunc("/api/admin/config/customstyles", middleware.RequireAdminAuth(admin.SetCustomStyles))
+	http.HandleFunc("/api/admin/config/customstyles", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetCustomStyles))
+
+	// Enable or disable recording of broadcasts
+	http.HandleFunc("/api/admin/config/recording", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetRecordingEnabled))
+
+	// set the external destinations to restream to
+	http.HandleFunc("/api/admin/config/restream", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetRestreamDestinations))
+
+	// set the remote source to ingest from
+	http.HandleFunc("/api/admin/config/pullsource", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.SetPullSource))
+
+	// Get all recordings
+	http.HandleFunc("/api/admin/recordings", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.GetRecordings))
+
+	// Download a single recording
+	http.HandleFunc("/api/admin/recordings/download", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DownloadRecording))
+
+	// Delete a single recording
+	http.HandleFunc("/api/admin/recordings/delete", middleware.RequireAdminAuth(models.AdminRoleOwner, admin.DeleteRecording))
 
 	port := config.WebServerPort
 	ip := config.WebServerIP
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+const iCalendarTimeFormat = "20060102T150405Z"
+
+// The longest line in an iCalendar file, in bytes, before it is folded.
+const maxICalendarLineLength = 75
+
+// CalendarEvent is a single event in an iCalendar feed.
+type CalendarEvent struct {
+	UID         string
+	Created     time.Time
+	Start       time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// GetICalendar will return an RFC 5545 iCalendar feed of the events.
+func GetICalendar(name string, events []CalendarEvent) string {
+	var calendar strings.Builder
+
+	writeICalendarLine(&calendar, "BEGIN:VCALENDAR")
+	writeICalendarLine(&calendar, "VERSION:2.0")
+	writeICalendarLine(&calendar, "PRODID:-//Owncast//Schedule//EN")
+	writeICalendarLine(&calendar, "CALSCALE:GREGORIAN")
+	writeICalendarLine(&calendar, "METHOD:PUBLISH")
+	writeICalendarLine(&calendar, "X-WR-CALNAME:"+escapeICalendarText(name))
+
+	for _, event := range events {
+		writeICalendarLine(&calendar, "BEGIN:VEVENT")
+		writeICalendarLine(&calendar, "UID:"+event.UID)
+		writeICalendarLine(&calendar, "DTSTAMP:"+event.Created.UTC().Format(iCalendarTimeFormat))
+		writeICalendarLine(&calendar, "DTSTART:"+event.Start.UTC().Format(iCalendarTimeFormat))
+		writeICalendarLine(&calendar, "SUMMARY:"+escapeICalendarText(event.Summary))
+		if event.Description != "" {
+			writeICalendarLine(&calendar, "DESCRIPTION:"+escapeICalendarText(event.Description))
+		}
+		if event.URL != "" {
+			writeICalendarLine(&calendar, "URL:"+event.URL)
+		}
+		writeICalendarLine(&calendar, "END:VEVENT")
+	}
+
+	writeICalendarLine(&calendar, "END:VCALENDAR")
+
+	return calendar.String()
+}
+
+func escapeICalendarText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(text)
+}
+
+// writeICalendarLine will write a line folded so no part of it is longer
+// than the limit, without splitting a character.
+func writeICalendarLine(calendar *strings.Builder, line string) {
+	lineLength := 0
+	for _, r := range line {
+		runeLength := len(string(r))
+		if lineLength+runeLength > maxICalendarLineLength {
+			calendar.WriteString("\r\n ")
+			lineLength = 1
+		}
+		calendar.WriteRune(r)
+		lineLength += runeLength
+	}
+	calendar.WriteString("\r\n")
+}
@@ -63,6 +63,22 @@ func Move(source, destination string) error {
 	return os.Rename(source, destination)
 }
 
+// GetDirectorySize returns the total size in bytes of the files within a directory.
+func GetDirectorySize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
+
 // IsUserAgentABot returns if a web client user-agent is seen as a bot.
 func IsUserAgentABot(userAgent string) bool {
 	if userAgent == "" {
@@ -148,12 +164,12 @@ func GetCacheDurationSecondsForPath(filePath string) int {
 	} else if path.Ext(filePath) == ".js" || path.Ext(filePath) == ".css" {
 		// Cache javascript & CSS
 		return 60
-	} else if path.Ext(filePath) == ".ts" {
+	} else if IsVideoSegment(filePath) || IsCaptionSegment(filePath) || path.Ext(filePath) == ".mp4" {
 		// Cache video segments as long as you want. They can't change.
 		// This matters most for local hosting of segments for recordings
 		// and not for live or 3rd party storage.
 		return 31557600
-	} else if path.Ext(filePath) == ".m3u8" {
+	} else if path.Ext(filePath) == ".m3u8" || path.Ext(filePath) == ".mpd" {
 		return 0
 	}
 
@@ -161,6 +177,37 @@ func GetCacheDurationSecondsForPath(filePath string) int {
 	return 30
 }
 
+// IsVideoSegment will return if the file is a MPEG-TS or fMP4 video segment.
+func IsVideoSegment(filePath string) bool {
+	return path.Ext(filePath) == ".ts" || path.Ext(filePath) == ".m4s"
+}
+
+// IsCaptionSegment will return if the file is a WebVTT captions segment.
+func IsCaptionSegment(filePath string) bool {
+	return path.Ext(filePath) == ".vtt"
+}
+
+// GetContentTypeForPath will return the MIME type of a HLS or DASH playlist
+// or segment, or an empty string for any other file.
+func GetContentTypeForPath(filePath string) string {
+	switch path.Ext(filePath) {
+	case ".m3u8":
+		return "application/x-mpegURL"
+	case ".mpd":
+		return "application/dash+xml"
+	case ".ts":
+		return "video/mp2t"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	case ".vtt":
+		return "text/vtt"
+	}
+
+	return ""
+}
+
 func IsValidUrl(urlToTest string) bool {
 	if _, err := url.ParseRequestURI(urlToTest); err != nil {
 		return false
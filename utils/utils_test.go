@@ -1,6 +1,10 @@
 package utils
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestUserAgent(t *testing.T) {
 	testAgents := []string{
@@ -15,3 +19,50 @@ func TestUserAgent(t *testing.T) {
 		}
 	}
 }
+
+func TestSegmentCaching(t *testing.T) {
+	for _, segment := range []string{"hls/0/stream-abc123.ts", "hls/0/stream-abc123.m4s", "hls/0/stream-abc123-init.mp4"} {
+		if GetCacheDurationSecondsForPath(segment) != 31557600 {
+			t.Error("segment should be cached", segment)
+		}
+	}
+
+	if GetCacheDurationSecondsForPath("hls/0/stream.m3u8") != 0 {
+		t.Error("playlist should not be cached")
+	}
+
+	if contentType := GetContentTypeForPath("hls/0/stream-abc123.m4s"); contentType != "video/iso.segment" {
+		t.Error("unexpected fMP4 segment content type", contentType)
+	}
+}
+
+func TestICalendar(t *testing.T) {
+	start := time.Date(2026, 11, 2, 19, 30, 0, 0, time.UTC)
+	calendar := GetICalendar("My Stream", []CalendarEvent{{
+		UID:         "1@example.com",
+		Created:     start.Add(-24 * time.Hour),
+		Start:       start,
+		Summary:     "Speedruns, part 2; the return",
+		Description: strings.Repeat("a", 80) + "\nsecond line",
+	}})
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:My Stream\r\n",
+		"DTSTART:20261102T193000Z\r\n",
+		"DTSTAMP:20261101T193000Z\r\n",
+		"SUMMARY:Speedruns\\, part 2\\; the return\r\n",
+		"DESCRIPTION:" + strings.Repeat("a", 63) + "\r\n " + strings.Repeat("a", 17) + "\\nsecond line\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, expected) {
+			t.Errorf("expected calendar to contain %q, got\n%s", expected, calendar)
+		}
+	}
+
+	for _, line := range strings.Split(calendar, "\r\n") {
+		if len(line) > maxICalendarLineLength {
+			t.Errorf("line is longer than %d bytes: %q", maxICalendarLineLength, line)
+		}
+	}
+}